@@ -0,0 +1,33 @@
+package chalker
+
+import "sync"
+
+// entry is one kept log (level and body)
+type entry struct {
+	body  string
+	level string
+}
+
+// Buffer keeps logs in order to write them later as one block (IE: logs of concurrent requests)
+type Buffer struct {
+	entries []*entry
+	mu      sync.Mutex
+}
+
+// Log keeps the log in the buffer
+func (b *Buffer) Log(level, body string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = append(b.entries, &entry{body: body, level: level})
+}
+
+// Flush writes all kept logs (using Write, the Hook is not called) and empties the buffer
+func (b *Buffer) Flush() {
+	b.mu.Lock()
+	entries := b.entries
+	b.entries = nil
+	b.mu.Unlock()
+	for _, e := range entries {
+		Write(e.level, e.body)
+	}
+}
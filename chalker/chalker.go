@@ -5,6 +5,7 @@ package chalker
 
 import (
 	"errors"
+	"io"
 
 	"github.com/fatih/color"
 )
@@ -20,6 +21,14 @@ const (
 	WARN    = "warn"
 )
 
+// Hook is an optional function that receives every log (level and body) before it's written
+var Hook func(level, body string)
+
+// SetOutput will change where all logs are written (default is stdout)
+func SetOutput(w io.Writer) {
+	color.Output = w
+}
+
 // Error chalks and returns an error
 func Error(body string) error {
 	return errors.New(color.MagentaString(body))
@@ -27,6 +36,14 @@ func Error(body string) error {
 
 // Log writes chalks to console
 func Log(level, body string) {
+	if Hook != nil {
+		Hook(level, body)
+	}
+	Write(level, body)
+}
+
+// Write writes chalks to console without calling the Hook (IE: the log was already recorded)
+func Write(level, body string) {
 	switch level {
 	case INFO:
 		color.Cyan(body)
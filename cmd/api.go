@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/fatih/color"
+	"github.com/mrz1836/go-sanitize"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/spf13/cobra"
+)
+
+// Defaults for the api command
+const (
+	defaultAPIConcurrency = 8                // Number of lookups running at the same time
+	defaultAPIListen      = "localhost:8080" // Default listen address for the API
+	defaultAPITimeout     = 30 * time.Second // Time allowed for one request
+)
+
+// APIError is the response for a failed request
+type APIError struct {
+	Error string `json:"error"`
+}
+
+// apiServer runs the lookups with bounded concurrency
+type apiServer struct {
+	slots   chan struct{} // One slot per running lookup
+	timeout time.Duration // Time allowed for one request (waiting for a slot and the lookup)
+}
+
+// apiCmd represents the api command
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Start an HTTP JSON API for resolve, capabilities, validate, whois, verify and brfc",
+	Long: color.GreenString(`
+              .__
+_____  ______ |__|
+\__  \ \____ \|  |
+ / __ \|  |_> >  |
+(____  /   __/|__|
+     \/|__|`) + `
+` + color.YellowString(`
+This command will start an HTTP server with JSON endpoints that mirror the commands:
+
+  GET /resolve/{paymail}
+  GET /capabilities/{domain}
+  GET /validate/{target}
+  GET /whois/{handle}
+  GET /verify/{paymail}/{pubkey}
+  GET /brfc (optional: ?search=term)
+
+Responses are the same models as the --output json of each command. All requests share the
+local database cache, run with a --request-timeout and at most --concurrency lookups at the same time
+(a whois search takes a free slot for each provider it searches at the same time).
+
+Errors are {"error": "..."} with a status code: 400 invalid input, 404 not found, 502 the provider
+failed, 503 no free slot in time and 504 timed out.`),
+	Aliases:     []string{"server"},
+	Annotations: map[string]string{annotationLongRunning: "true"},
+	Example: applicationName + ` api
+` + applicationName + ` api --listen 0.0.0.0:8080 --concurrency 16 --request-timeout 15s`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		if apiConcurrency < 1 {
+			chalker.Log(chalker.ERROR, "api --concurrency must be at least 1")
+			return
+		}
+
+		// Create the server
+		api := &apiServer{slots: make(chan struct{}, apiConcurrency), timeout: apiTimeout}
+		server := &http.Server{
+			Addr:              apiListen,
+			Handler:           logServeRequests(api.handler()),
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      apiTimeout + 5*time.Second,
+		}
+
+		// Stop on CTRL+C (or after --timeout)
+		go func() {
+			<-cmd.Context().Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+
+		// Start the server
+		chalker.Log(chalker.SUCCESS, fmt.Sprintf("Listening on %s (press CTRL+C to stop)", color.CyanString("http://"+apiListen)))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error starting server: %s", err.Error()))
+			return
+		}
+
+		chalker.Log(chalker.INFO, "Server stopped")
+	},
+}
+
+// handler returns the routes of the API
+func (a *apiServer) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /resolve/{paymail}", a.resolve)
+	mux.HandleFunc("GET /capabilities/{domain}", a.capabilities)
+	mux.HandleFunc("GET /validate/{target}", a.validate)
+	mux.HandleFunc("GET /whois/{handle}", a.whois)
+	mux.HandleFunc("GET /verify/{paymail}/{pubkey}", a.verify)
+	mux.HandleFunc("GET /brfc", a.brfc)
+	return mux
+}
+
+// resolve will run the resolve flow for a paymail address
+func (a *apiServer) resolve(w http.ResponseWriter, r *http.Request) {
+	address := r.PathValue("paymail")
+	if _, _, paymailAddress := paymail.SanitizePaymail(paymail.ConvertHandle(address, false)); len(paymailAddress) == 0 {
+		writeAPIError(w, http.StatusBadRequest, "paymail address not found or invalid")
+		return
+	}
+	a.run(w, r, "resolve", []string{address}, func(ctx context.Context, report *Report) (interface{}, error) {
+		err := resolvePaymail(ctx, address, report)
+		return report.Paymail, err
+	})
+}
+
+// capabilities will get the capabilities for a domain (or the domain of a paymail address)
+func (a *apiServer) capabilities(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	if strings.Contains(domain, "@") {
+		_, domain, _ = paymail.SanitizePaymail(domain)
+	}
+	domain, _ = sanitize.Domain(domain, false, true)
+	if err := paymail.ValidateDomain(domain); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("domain name %s is invalid: %s", domain, err.Error()))
+		return
+	}
+	a.run(w, r, "capabilities", []string{domain}, func(ctx context.Context, _ *Report) (interface{}, error) {
+		capabilities, err := getCapabilities(ctx, domain, true)
+		if err != nil {
+			return nil, err
+		}
+		return &capabilities.CapabilitiesPayload, nil
+	})
+}
+
+// validate will run all validations for a domain or paymail address
+func (a *apiServer) validate(w http.ResponseWriter, r *http.Request) {
+	target := r.PathValue("target")
+	a.run(w, r, "validate", []string{target}, func(ctx context.Context, report *Report) (interface{}, error) {
+		err := validateTarget(ctx, target, report)
+		return report.Validation, err
+	})
+}
+
+// whois will search all providers for a handle
+func (a *apiServer) whois(w http.ResponseWriter, r *http.Request) {
+	handle := sanitizeHandle(r.PathValue("handle"))
+	if len(handle) == 0 || len(handle) > 255 {
+		writeAPIError(w, http.StatusBadRequest, "handle is invalid")
+		return
+	}
+	a.run(w, r, "whois", []string{handle}, func(ctx context.Context, _ *Report) (interface{}, error) {
+		// Each provider searched at the same time takes a slot (the request already holds one)
+		extra := a.acquire(whoisConcurrency - 1)
+		defer a.release(extra)
+		paymails := searchProviders(ctx, handle, getProviders(nil), 1+extra, false)
+		if len(paymails) == 0 {
+			return nil, &KindError{Err: errors.New("no paymail results returned"), Kind: ErrNotFound}
+		}
+		return paymails, nil
+	})
+}
+
+// verify will verify a pubkey against a paymail address
+func (a *apiServer) verify(w http.ResponseWriter, r *http.Request) {
+	args := []string{r.PathValue("paymail"), r.PathValue("pubkey")}
+	a.run(w, r, "verify", args, func(ctx context.Context, report *Report) (interface{}, error) {
+		err := verifyPaymail(ctx, append([]string{}, args...), report)
+		if report.Verification != nil {
+			return report.Verification, nil // A mismatch is still a valid answer
+		}
+		return nil, err
+	})
+}
+
+// brfc will list (or search) all known BRFC specifications
+func (a *apiServer) brfc(w http.ResponseWriter, r *http.Request) {
+	searchTerm := strings.TrimSpace(sanitize.SingleLine(r.URL.Query().Get("search")))
+	a.run(w, r, "brfc", []string{searchTerm}, func(ctx context.Context, _ *Report) (interface{}, error) {
+		client, err := newPaymailClient(ctx, false, nameServer)
+		if err != nil {
+			return nil, err
+		}
+		brfcs := []*paymail.BRFCSpec{}
+		for _, brfc := range client.GetBRFCs() {
+			if len(searchTerm) == 0 || simpleSearch(brfc.ID, searchTerm) || simpleSearch(brfc.Title, searchTerm) || simpleSearch(brfc.Author, searchTerm) {
+				brfcs = append(brfcs, brfc)
+			}
+		}
+		return brfcs, nil
+	})
+}
+
+// run will run one lookup when a slot is free (the flow returns the model for the response)
+//
+// The request has --request-timeout to get a slot (503) and finish the lookup (504)
+func (a *apiServer) run(w http.ResponseWriter, r *http.Request, command string, args []string,
+	flow func(ctx context.Context, report *Report) (interface{}, error),
+) {
+	ctx, cancel := context.WithTimeout(r.Context(), a.timeout)
+	defer cancel()
+
+	// Wait for a free slot (or until the request times out)
+	select {
+	case a.slots <- struct{}{}:
+	case <-ctx.Done():
+		writeAPIError(w, http.StatusServiceUnavailable, "too many requests in progress")
+		return
+	}
+
+	// The lookup keeps the slot until it's done (canceled when the request times out)
+	// All errors, warnings, traces and cache hits are kept in the report of the request
+	type result struct {
+		data interface{}
+		err  error
+	}
+	done := make(chan *result, 1)
+	go func() {
+		defer func() { <-a.slots }()
+		report := newReport(command, args)
+		data, err := flow(withReport(ctx, report), report)
+		done <- &result{data: data, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		if res.err != nil {
+			writeAPIError(w, apiStatus(res.err), res.err.Error())
+			return
+		}
+		writeAPIJSON(w, http.StatusOK, res.data)
+	case <-ctx.Done():
+		if r.Context().Err() == nil { // Not closed by the client
+			writeAPIError(w, http.StatusGatewayTimeout, fmt.Sprintf("request timed out after %s", a.timeout))
+		}
+	}
+}
+
+// acquire will take up to n more free slots without waiting (returns the number taken, see: release)
+func (a *apiServer) acquire(n int) (taken int) {
+	for ; taken < n; taken++ {
+		select {
+		case a.slots <- struct{}{}:
+		default:
+			return taken
+		}
+	}
+	return taken
+}
+
+// release will free the slots taken with acquire
+func (a *apiServer) release(n int) {
+	for i := 0; i < n; i++ {
+		<-a.slots
+	}
+}
+
+// apiStatus returns the status code for a failed lookup
+//
+// Invalid input is 400, an unknown address is 404, a request that ran out of time is 504 and any other
+// failure of the provider (or a service) is 502
+func apiStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case isTimeout(err), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// writeAPIJSON will write a JSON response
+func writeAPIJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// writeAPIError will write an error response (color codes are removed)
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeAPIJSON(w, status, &APIError{Error: ansiEscapes.ReplaceAllString(message, "")})
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+
+	// Set the listen address
+	apiCmd.Flags().StringVar(&apiListen, "listen", defaultAPIListen, "Address to listen on (host:port)")
+
+	// Set the request timeout
+	apiCmd.Flags().DurationVar(&apiTimeout, "request-timeout", defaultAPITimeout, "Time allowed for one request")
+
+	// Set the concurrency
+	apiCmd.Flags().IntVar(&apiConcurrency, "concurrency", defaultAPIConcurrency, "Number of lookups running at the same time")
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/mrz1836/paymail-inspector/mock"
+)
+
+// TestAPIServer_run will test the method run() (the status code of each kind of failure)
+func TestAPIServer_run(t *testing.T) {
+	// Not parallel: points the requests at the mock provider (global flags)
+
+	// The PKI of alice never answers (in time), the verify pubkey service fails
+	startMockProvider(t, false, func(_ *mock.Server, w http.ResponseWriter, r *http.Request) bool {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/bsvalias/id/alice@"):
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return true
+		case strings.HasPrefix(r.URL.Path, "/v1/bsvalias/verify-pubkey/"):
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"code":"test","message":"test"}`))
+			return true
+		}
+		return false
+	})
+
+	// No third-party services
+	for _, skip := range skipIntegrations {
+		previous := *skip
+		*skip = true
+		t.Cleanup(func() {
+			*skip = previous
+		})
+	}
+
+	// The mock provider is the only provider for whois
+	useApplicationDirectory(t)
+	if err := saveRegistry([]*Provider{{Domain: mock.DefaultDomain}}); err != nil {
+		t.Fatalf("%s Failed: received an error saving the registry: %s", t.Name(), err.Error())
+	}
+
+	api := &apiServer{slots: make(chan struct{}, 2), timeout: 500 * time.Millisecond}
+	full := &apiServer{slots: make(chan struct{}, 1), timeout: 50 * time.Millisecond}
+	full.slots <- struct{}{}
+
+	pubKey := "02" + strings.Repeat("0", 64)
+	var tests = []struct {
+		name           string
+		api            *apiServer
+		path           string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"resolve", api, "/resolve/satoshi@paymail.test", http.StatusOK, ""},
+		{"unknown address", api, "/resolve/nobody@paymail.test", http.StatusNotFound, "Find PKI Failed"},
+		{"invalid address", api, "/resolve/not-an-address", http.StatusBadRequest, "paymail address not found or invalid"},
+		{"invalid domain", api, "/validate/invalid_domain", http.StatusBadRequest, "Domain name"},
+		{"malformed pubkey", api, "/verify/satoshi@paymail.test/" + strings.Repeat("z", 66), http.StatusBadRequest, "PubKey is not valid hex"},
+		{"short pubkey", api, "/verify/satoshi@paymail.test/0201", http.StatusBadRequest, "PubKey is an invalid length"},
+		{"provider failed", api, "/verify/satoshi@paymail.test/" + pubKey, http.StatusBadGateway, "verify pubkey request failed"},
+		{"timed out", api, "/resolve/alice@paymail.test", http.StatusGatewayTimeout, "timed out"},
+		{"no free slot", full, "/brfc", http.StatusServiceUnavailable, "too many requests in progress"},
+		{"whois", api, "/whois/satoshi", http.StatusOK, ""},
+		{"invalid handle", api, "/whois/$", http.StatusBadRequest, "handle is invalid"},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		test.api.handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, test.path, nil))
+		if recorder.Code != test.expectedStatus {
+			t.Errorf("%s Failed: [%s] inputted and [%d] expected, received: [%d] %s", t.Name(), test.name, test.expectedStatus, recorder.Code, recorder.Body.String())
+			continue
+		}
+		if len(test.expectedError) == 0 {
+			continue
+		}
+		var apiErr APIError
+		if err := json.Unmarshal(recorder.Body.Bytes(), &apiErr); err != nil {
+			t.Errorf("%s Failed: [%s] inputted and an error response expected, received: [%s]", t.Name(), test.name, recorder.Body.String())
+		} else if !strings.Contains(apiErr.Error, test.expectedError) {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expectedError, apiErr.Error)
+		}
+	}
+
+	// All slots are free once the lookups are done (the whois providers and a lookup that timed out)
+	for wait := 0; len(api.slots) > 0 && wait < 100; wait++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(api.slots) > 0 {
+		t.Errorf("%s Failed: all slots free expected, received: [%d] taken", t.Name(), len(api.slots))
+	}
+}
+
+// TestAPIServer_acquire will test the methods acquire() and release()
+func TestAPIServer_acquire(t *testing.T) {
+	t.Parallel()
+
+	api := &apiServer{slots: make(chan struct{}, 4)}
+	api.slots <- struct{}{} // Held by the request
+
+	var tests = []struct {
+		name     string
+		n        int
+		expected int
+	}{
+		{"none", 0, 0},
+		{"negative", -1, 0},
+		{"some", 2, 2},
+		{"more than free", 5, 1},
+		{"none free", 1, 0},
+	}
+
+	taken := 0
+	for _, test := range tests {
+		output := api.acquire(test.n)
+		if output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%d] expected, received: [%d]", t.Name(), test.name, test.expected, output)
+		}
+		taken += output
+	}
+	api.release(taken)
+	if len(api.slots) != 1 {
+		t.Errorf("%s Failed: [1] slot taken expected, received: [%d]", t.Name(), len(api.slots))
+	}
+}
+
+// TestAPIStatus will test the method apiStatus()
+func TestAPIStatus(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"invalid input", &KindError{Err: errors.New("domain name is invalid"), Kind: ErrInvalidInput}, http.StatusBadRequest},
+		{"not found", fmt.Errorf("Find PKI Failed: %w", &KindError{Err: errors.New("code 404"), Kind: ErrNotFound}), http.StatusNotFound},
+		{"not marked as not found", fmt.Errorf("resolve: %w", paymail.ErrResolveAddressNotFound), http.StatusBadGateway},
+		{"timed out", fmt.Errorf("Error: %w", &TimeoutError{Err: errors.New("i/o timeout"), Request: "capabilities"}), http.StatusGatewayTimeout},
+		{"deadline", context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{"other error", errors.New("missing a required capability"), http.StatusBadGateway},
+	}
+
+	for _, test := range tests {
+		if output := apiStatus(test.err); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%d] expected, received: [%d]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
@@ -12,10 +12,8 @@ import (
 	"github.com/bsv-blockchain/go-paymail"
 	"github.com/mitchellh/go-homedir"
 	"github.com/mrz1836/paymail-inspector/chalker"
-	"github.com/mrz1836/paymail-inspector/integrations/baemail"
-	"github.com/mrz1836/paymail-inspector/integrations/bitpic"
-	"github.com/mrz1836/paymail-inspector/integrations/powping"
-	"github.com/mrz1836/paymail-inspector/integrations/roundesk"
+	"github.com/mrz1836/paymail-inspector/integrations"
+	"github.com/mrz1836/paymail-inspector/retry"
 	"github.com/spf13/cobra"
 	"github.com/spf13/cobra/doc"
 	"github.com/spf13/viper"
@@ -28,14 +26,15 @@ func init() {
 	// Set up the application resources
 	setupAppResources()
 
-	// Load the configuration
-	cobra.OnInitialize(initConfig)
+	// Set the output and load the configuration
+	cobra.OnInitialize(initOutput, initConfig)
 
 	// Set the user agent for the application's external integrations
-	baemail.UserAgent = applicationFullName + versionPrefix + Version
-	bitpic.UserAgent = applicationFullName + versionPrefix + Version
-	powping.UserAgent = applicationFullName + versionPrefix + Version
-	roundesk.UserAgent = applicationFullName + versionPrefix + Version
+	for _, integration := range integrations.All() {
+		settings := integration.Settings()
+		settings.UserAgent = applicationFullName + versionPrefix + Version
+		integration.Configure(settings)
+	}
 
 	// Add config option
 	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Custom config file (default is $HOME/"+applicationName+"/"+configFileDefault+".yaml)")
@@ -43,12 +42,42 @@ func init() {
 	// Add document generation for all commands
 	rootCmd.PersistentFlags().BoolVar(&generateDocs, "docs", false, "Generate docs from all commands (./"+docsLocation+")")
 
+	// Add a machine-readable output format
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, fmt.Sprintf("Output format: %s, %s, %s or %s (logs are written to stderr)", outputText, outputJSON, outputNDJSON, outputYAML))
+
+	// Add a time limit for the whole command
+	rootCmd.PersistentFlags().DurationVar(&commandTimeout, "timeout", 0, "Time allowed for the whole command, IE: 30s (default: no limit)")
+
+	// Add the timeouts per phase (each DNS lookup, HTTP request and SSL check)
+	rootCmd.PersistentFlags().DurationVar(&dnsTimeout, "dns-timeout", paymailDNSTimeout, "Time allowed for each DNS lookup")
+	rootCmd.PersistentFlags().DurationVar(&httpTimeout, "http-timeout", paymailHTTPTimeout, "Time allowed for each paymail request")
+	rootCmd.PersistentFlags().DurationVar(&sslTimeout, "ssl-timeout", paymailSSLTimeout, "Time allowed for the SSL check")
+
+	// Add the retry policy for transient failures (network errors, 429 and 5xx on idempotent requests)
+	rootCmd.PersistentFlags().IntVar(&maxAttempts, "max-attempts", retry.DefaultAttempts, "Attempts per paymail request (1 turns off retries)")
+	rootCmd.PersistentFlags().DurationVar(&retryWait, "retry-wait", retry.DefaultMinWait, "Wait before the first retry (doubles each attempt, with jitter, unless Retry-After is given)")
+
+	// Add a capability discovery override (skips the SRV lookup)
+	rootCmd.PersistentFlags().StringVar(&hostOverride, "host", "", "Custom host:port for capability discovery (skips the SRV record), IE: localhost:3443")
+
+	// Add a toggle for skipping TLS verification
+	rootCmd.PersistentFlags().BoolVar(&insecure, "insecure", false, "Skip TLS certificate verification (self-signed certificates)")
+
 	// Add a toggle for request tracing
 	rootCmd.PersistentFlags().BoolVarP(&skipTracing, "skip-tracing", "t", false, "Turn off request tracing information")
 
+	// Add a HAR export of all requests
+	rootCmd.PersistentFlags().StringVar(&harFile, "har", "", "Save all HTTP requests (headers, bodies and timings) to a HAR file, IE: requests.har")
+
+	// Add a timing waterfall of all requests
+	rootCmd.PersistentFlags().BoolVar(&showTiming, "timing", false, "Show a timing waterfall of all steps (DNS, connect, TLS, TTFB, transfer) at the end")
+
 	// Add a toggle for disabling request caching
 	rootCmd.PersistentFlags().BoolVar(&disableCache, "no-cache", false, "Turn off caching for this specific command")
 
+	// Add a max age for cached models
+	rootCmd.PersistentFlags().DurationVar(&maxAge, "max-age", 0, "Treat cached models older than this duration as a miss (IE: 10m)")
+
 	// Add a toggle for flushing all the local database cache
 	rootCmd.PersistentFlags().BoolVar(&flushCache, "flush-cache", false, "Flushes ALL cache, empties local database")
 
@@ -86,7 +115,8 @@ func initConfig() {
 		viper.SetConfigName(configFileDefault)
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_")) // IE: integrations.bitpic.network = INTEGRATIONS_BITPIC_NETWORK
+	viper.AutomaticEnv()                                             // read in environment variables that match
 
 	// If a config file is found, read it in
 	if err := viper.ReadInConfig(); err != nil {
@@ -94,6 +124,9 @@ func initConfig() {
 	}
 
 	// chalker.Log(chalker.INFO, fmt.Sprintf("...loaded config file: %s", viper.ConfigFileUsed()))
+
+	// Apply the integration settings (base urls, timeouts, etc)
+	configureIntegrations()
 }
 
 // generateDocumentation will generate all documentation about each command
@@ -106,6 +139,9 @@ func generateDocumentation() {
 	for _, command := range rootCmd.Commands() {
 		rootCmd.RemoveCommand(command)
 		command.Long = replacer.Replace(command.Long)
+		for _, subCommand := range command.Commands() {
+			subCommand.Long = replacer.Replace(subCommand.Long)
+		}
 		rootCmd.AddCommand(command)
 	}
 
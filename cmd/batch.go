@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+)
+
+// Defaults for batch mode
+const (
+	defaultBatchConcurrency = 4   // Number of entries processed at the same time
+	stdinInput              = "-" // Use stdin instead of a file
+)
+
+// batchHeaders are known CSV header names (the first row is skipped if it matches)
+var batchHeaders = []string{"address", "domain", "handle", "paymail", "target"}
+
+// batchFlow is the flow that runs for every entry (fields are the columns of the line)
+type batchFlow func(ctx context.Context, fields []string, report *Report) error
+
+// BatchEntry is the result for one line of the batch input (with the records of its own report)
+type BatchEntry struct {
+	CacheHits    []*CacheEntry                `json:"cache_hits,omitempty"`
+	Error        string                       `json:"error,omitempty"`
+	Errors       []string                     `json:"errors,omitempty"`
+	Fields       []string                     `json:"fields"`
+	Line         int                          `json:"line"`
+	Paymail      *PaymailDetails              `json:"paymail,omitempty"`
+	Success      bool                         `json:"success"`
+	Traces       []*TraceRecord               `json:"traces,omitempty"`
+	Validation   *ValidationResult            `json:"validation,omitempty"`
+	Verification *paymail.VerificationPayload `json:"verification,omitempty"`
+	Warnings     []string                     `json:"warnings,omitempty"`
+}
+
+// addBatchFlags will add the batch mode flags to a command
+func addBatchFlags(command *cobra.Command) {
+	command.Flags().StringVarP(&inputFile, "input", "i", "", "File of entries to process (one per line, plain or CSV), use - for stdin")
+	command.Flags().IntVar(&batchConcurrency, "concurrency", defaultBatchConcurrency, "Number of entries processed at the same time (--input)")
+}
+
+// readBatchInput will read all entries from a file (or stdin)
+func readBatchInput(path string) (entries []*BatchEntry, err error) {
+	var reader io.Reader
+	if path == stdinInput {
+		reader = os.Stdin
+	} else {
+		var file *os.File
+		if file, err = os.Open(path); err != nil { //nolint:gosec // G304 - user supplied file
+			return entries, err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		reader = file
+	}
+
+	// Read line by line (CSV if the line has a comma, otherwise whitespace separated)
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var fields []string
+		if strings.Contains(line, ",") {
+			if fields, err = csv.NewReader(strings.NewReader(line)).Read(); err != nil {
+				return entries, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			for index := range fields {
+				fields[index] = strings.TrimSpace(fields[index])
+			}
+		} else {
+			fields = strings.Fields(line)
+		}
+
+		// Skip the CSV header
+		if len(entries) == 0 && isBatchHeader(fields[0]) {
+			continue
+		}
+
+		entries = append(entries, &BatchEntry{Fields: fields, Line: lineNumber})
+	}
+
+	return entries, scanner.Err()
+}
+
+// isBatchHeader returns true if the value is a known CSV header name
+func isBatchHeader(value string) bool {
+	value = strings.ToLower(value)
+	for _, header := range batchHeaders {
+		if value == header {
+			return true
+		}
+	}
+	return false
+}
+
+// runBatch will run the flow for every entry in the input with bounded concurrency
+func runBatch(ctx context.Context, path string, requiredFields int, flow batchFlow) {
+	// Read all the entries
+	entries, err := readBatchInput(path)
+	if err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error reading input: %s", err.Error()))
+		return
+	} else if len(entries) == 0 {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("No entries found in input: %s", path))
+		return
+	}
+
+	// Run the entries (the logs of each entry are written as one block, in the order of the input)
+	runJobs(ctx, len(entries), batchConcurrency, true, func(ctx context.Context, index int) {
+		runBatchEntry(ctx, entries[index], requiredFields, flow)
+	}, func(_ int, buffer *chalker.Buffer) {
+		buffer.Flush()
+	})
+
+	runReport.Entries = entries
+	displayBatchSummary(entries)
+}
+
+// runBatchEntry will run the flow for one entry and keep the results
+func runBatchEntry(ctx context.Context, entry *BatchEntry, requiredFields int, flow batchFlow) {
+	if len(entry.Fields) < requiredFields {
+		entry.Error = fmt.Sprintf("expected %d column(s) but got %d", requiredFields, len(entry.Fields))
+		return
+	}
+
+	// The traces, errors and warnings of the entry are kept in its own report
+	report := newReport(runReport.Command, entry.Fields)
+	if err := flow(withReport(ctx, report), entry.Fields, report); err != nil {
+		entry.Error = err.Error()
+	} else {
+		entry.Success = true
+	}
+
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	entry.CacheHits = report.CacheHits
+	entry.Errors = report.Errors
+	entry.Paymail = report.Paymail
+	entry.Traces = report.Traces
+	entry.Validation = report.Validation
+	entry.Verification = report.Verification
+	entry.Warnings = report.Warnings
+}
+
+// displayBatchSummary will display a table with the result of every entry
+func displayBatchSummary(entries []*BatchEntry) {
+	displayHeader(chalker.BOLD, fmt.Sprintf("Batch results for %d entries...", len(entries)))
+
+	passed := 0
+	output := []string{"Line | Input | Status | Result"}
+	for _, entry := range entries {
+		status := color.MagentaString("failed")
+		result := entry.Error
+		if entry.Success {
+			passed++
+			status = color.GreenString("passed")
+			result = entry.summary()
+		}
+		output = append(output, fmt.Sprintf("%d | %s | %s | %s", entry.Line, strings.Join(entry.Fields, " "), status, result))
+	}
+	chalker.Log(chalker.DEFAULT, columnize.SimpleFormat(output))
+
+	if passed == len(entries) {
+		chalker.Log(chalker.SUCCESS, fmt.Sprintf("All %d entries passed", passed))
+	} else {
+		chalker.Log(chalker.WARN, fmt.Sprintf("%d of %d entries failed", len(entries)-passed, len(entries)))
+	}
+}
+
+// summary returns a short description of a successful entry
+func (b *BatchEntry) summary() string {
+	switch {
+	case b.Paymail != nil && b.Paymail.Resolution != nil:
+		return "address: " + b.Paymail.Resolution.Address
+	case b.Verification != nil:
+		return fmt.Sprintf("match: %v", b.Verification.Match)
+	case b.Validation != nil && len(b.Validation.PubKey) > 0:
+		return "pubkey: " + b.Validation.PubKey
+	case b.Validation != nil:
+		return "target: " + b.Validation.Target
+	}
+	return ""
+}
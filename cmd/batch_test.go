@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestReadBatchInput will test the method readBatchInput()
+func TestReadBatchInput(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name          string
+		input         string
+		expected      []*BatchEntry
+		expectedError string
+	}{
+		{"whitespace separated", "satoshi@paymail.test\n  alice@paymail.test   bob@example.com \n", []*BatchEntry{
+			{Fields: []string{"satoshi@paymail.test"}, Line: 1},
+			{Fields: []string{"alice@paymail.test", "bob@example.com"}, Line: 2},
+		}, ""},
+		{"csv with a header", "paymail,pubkey\nsatoshi@paymail.test, 02abc\n\"alice@paymail.test\",\"03def\"\n", []*BatchEntry{
+			{Fields: []string{"satoshi@paymail.test", "02abc"}, Line: 2},
+			{Fields: []string{"alice@paymail.test", "03def"}, Line: 3},
+		}, ""},
+		{"header names are case insensitive", "Domain\nexample.com\n", []*BatchEntry{
+			{Fields: []string{"example.com"}, Line: 2},
+		}, ""},
+		{"header is only skipped first", "example.com\ndomain\n", []*BatchEntry{
+			{Fields: []string{"example.com"}, Line: 1},
+			{Fields: []string{"domain"}, Line: 2},
+		}, ""},
+		{"comments and blank lines", "# paymails to check\n\n   \nsatoshi@paymail.test\n  # indented comment\nalice@paymail.test\n", []*BatchEntry{
+			{Fields: []string{"satoshi@paymail.test"}, Line: 4},
+			{Fields: []string{"alice@paymail.test"}, Line: 6},
+		}, ""},
+		{"windows line endings", "satoshi@paymail.test\r\nalice@paymail.test,02abc\r\n", []*BatchEntry{
+			{Fields: []string{"satoshi@paymail.test"}, Line: 1},
+			{Fields: []string{"alice@paymail.test", "02abc"}, Line: 2},
+		}, ""},
+		{"empty", "# nothing\n\n", nil, ""},
+		{"invalid csv", "satoshi@paymail.test\nalice@paymail.test,\"02abc\n", []*BatchEntry{
+			{Fields: []string{"satoshi@paymail.test"}, Line: 1},
+		}, "line 2: "},
+	}
+
+	for _, test := range tests {
+		filename := filepath.Join(t.TempDir(), "input.txt")
+		if err := os.WriteFile(filename, []byte(test.input), 0o600); err != nil {
+			t.Fatalf("%s Failed: error writing the input: %s", t.Name(), err.Error())
+		}
+
+		output, err := readBatchInput(filename)
+		if len(test.expectedError) == 0 && err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.name, err.Error())
+		} else if len(test.expectedError) > 0 && (err == nil || !strings.HasPrefix(err.Error(), test.expectedError)) {
+			t.Errorf("%s Failed: [%s] inputted and error [%s] expected, received: [%v]", t.Name(), test.name, test.expectedError, err)
+		}
+		if !reflect.DeepEqual(output, test.expected) {
+			t.Errorf("%s Failed: [%s] inputted and [%+v] expected, received: [%+v]", t.Name(), test.name, test.expected, output)
+		}
+	}
+
+	if _, err := readBatchInput(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Errorf("%s Failed: an error expected for a missing file", t.Name())
+	}
+}
+
+// TestIsBatchHeader will test the method isBatchHeader()
+func TestIsBatchHeader(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		value    string
+		expected bool
+	}{
+		{"paymail", true},
+		{"Handle", true},
+		{"TARGET", true},
+		{"address", true},
+		{"domain", true},
+		{"pubkey", false},
+		{"satoshi@paymail.test", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if output := isBatchHeader(test.value); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.value, test.expected, output)
+		}
+	}
+}
@@ -52,9 +52,11 @@ Read more at: `+color.CyanString("http://bsvalias.org/01-brfc-specifications.htm
 		}
 		return nil
 	},
-	Run: func(_ *cobra.Command, args []string) {
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		// Load the BRFC specifications via new client
-		client, err := newPaymailClient(false, nameServer)
+		client, err := newPaymailClient(ctx, false, nameServer)
 		if err != nil {
 			chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading BRFC specifications: %s", err.Error()))
 			return
@@ -83,9 +85,11 @@ Read more at: `+color.CyanString("http://bsvalias.org/01-brfc-specifications.htm
 
 			// Loop the list
 			found := 0
+			runReport.BRFCs = []*paymail.BRFCSpec{}
 			for _, brfc := range brfcs {
 				if simpleSearch(brfc.ID, searchTerm) || simpleSearch(brfc.Title, searchTerm) || simpleSearch(brfc.Author, searchTerm) {
 					showBrfc(brfc)
+					runReport.BRFCs = append(runReport.BRFCs, brfc)
 					found = found + 1
 				}
 			}
@@ -109,6 +113,7 @@ Read more at: `+color.CyanString("http://bsvalias.org/01-brfc-specifications.htm
 			}
 
 			// Loop the list
+			runReport.BRFCs = brfcs
 			for _, brfc := range brfcs {
 
 				// Skip an invalid specs in the JSON (there should NOT be any invalid specs)
@@ -179,6 +184,7 @@ Read more at: `+color.CyanString("http://bsvalias.org/01-brfc-specifications.htm
 			}
 
 			displayHeader(chalker.BOLD, "Generating BRFC ID...")
+			runReport.BRFCs = []*paymail.BRFCSpec{brfc}
 
 			// Show the generated ID
 			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Generated ID: %s", color.CyanString(brfc.ID)))
@@ -0,0 +1,342 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/go-sanitize"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/database"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+)
+
+// cacheModels are the known key prefixes (model types) in the local database (integrations are added on init)
+var cacheModels = []string{
+	capabilitiesSnapshotPrefix,
+	"model-capabilities-",
+	"model-pki-",
+	"model-public-profile-",
+	"model-srv-",
+	p2pReferencePrefix,
+	watchKeyPrefix,
+}
+
+// savedModels are the key prefixes of saved user data (not cache), only purged by the explicit prefix
+var savedModels = []string{
+	capabilitiesSnapshotPrefix,
+	watchKeyPrefix,
+}
+
+// CacheEntry is one key in the local database
+type CacheEntry struct {
+	Age       time.Duration   `json:"age,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+	Key       string          `json:"key"`
+	Model     string          `json:"model"`
+	Size      int64           `json:"size"`
+	StoredAt  *time.Time      `json:"stored_at,omitempty"`
+	Subject   string          `json:"subject"`
+	TTL       time.Duration   `json:"ttl,omitempty"`
+	Value     json.RawMessage `json:"value,omitempty"`
+}
+
+// CacheStats is the summary of the local database
+type CacheStats struct {
+	Keys     int            `json:"keys"`
+	LSMSize  int64          `json:"lsm_size"`
+	Models   map[string]int `json:"models"`
+	VLogSize int64          `json:"vlog_size"`
+}
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "List, show, purge or get stats for the local database cache",
+	Long: color.GreenString(`
+                     .__
+  ____ _____    ____ |  |__   ____
+_/ ___\\__  \ _/ ___\|  |  \_/ __ \
+\  \___ / __ \\  \___|   Y  \  ___/
+ \___  >____  /\___  >___|  /\___  >
+     \/     \/     \/     \/     \/`) + `
+` + color.YellowString(`
+Use the [list] argument to show all cached keys with their age and time until expiration (optional: key prefix).
+
+Use the [show] argument to display one cached model as JSON.
+
+Use the [purge] argument to remove cached keys by domain, paymail address or model type (key prefix, IE: model-pki-).
+Saved capabilities and watch snapshots are only removed by their prefix (`+capabilitiesSnapshotPrefix+` or `+watchKeyPrefix+`).
+
+Use the [stats] argument to show the number of keys per model type and the size of the database.`),
+	Aliases:    []string{"db"},
+	SuggestFor: []string{"database"},
+	Example: applicationName + ` cache list
+` + applicationName + ` cache list model-pki-
+` + applicationName + ` cache show model-capabilities-` + defaultDomainName + `
+` + applicationName + ` cache purge ` + defaultDomainName + `
+` + applicationName + ` cache purge mrz@` + defaultDomainName + `
+` + applicationName + ` cache purge model-pki-
+` + applicationName + ` cache stats`,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return chalker.Error("cache requires either [list], [show], [purge] or [stats]")
+		}
+		switch args[0] {
+		case "list", "stats":
+			return nil
+		case "show", "purge":
+			if len(args) < 2 {
+				return chalker.Error(fmt.Sprintf("cache %s requires a second argument", args[0]))
+			}
+			return nil
+		}
+		return chalker.Error("cache requires either [list], [show], [purge] or [stats]")
+	},
+	Run: func(_ *cobra.Command, args []string) {
+		// The database is required
+		if !databaseEnabled {
+			chalker.Log(chalker.ERROR, "The local database is not available")
+			return
+		}
+
+		var target string
+		if len(args) > 1 {
+			target = strings.TrimSpace(args[1])
+		}
+
+		switch args[0] {
+		case "list":
+			listCache(target)
+		case "show":
+			showCache(target)
+		case "purge":
+			purgeCache(target)
+		case "stats":
+			cacheStats()
+		}
+	},
+}
+
+// listCache will display all keys that start with the prefix
+func listCache(prefix string) {
+	items, err := database.List(prefix)
+	if err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error listing cache: %s", err.Error()))
+		return
+	}
+	entries := make([]*CacheEntry, 0, len(items))
+	for _, item := range items {
+		entries = append(entries, newCacheEntry(item))
+	}
+	runReport.Cache = entries
+
+	if len(entries) == 0 {
+		chalker.Log(chalker.DEFAULT, "No cached keys found")
+		return
+	}
+
+	displayHeader(chalker.BOLD, fmt.Sprintf("Listing %d cached key(s)...", len(entries)))
+	output := []string{"Key | Age | Expires In | Size"}
+	for _, entry := range entries {
+		output = append(output, fmt.Sprintf("%s | %s | %s | %d", entry.Key, entry.ageString(), entry.ttlString(), entry.Size))
+	}
+	chalker.Log(chalker.DEFAULT, columnize.SimpleFormat(output))
+}
+
+// showCache will display one cached model as JSON
+func showCache(key string) {
+	item, err := database.GetItem(key)
+	if err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error reading cache: %s", err.Error()))
+		return
+	} else if item == nil {
+		chalker.Log(chalker.WARN, fmt.Sprintf("Key not found: %s", key))
+		return
+	}
+	entry := newCacheEntry(item)
+	runReport.Cache = []*CacheEntry{entry}
+
+	displayHeader(chalker.BOLD, fmt.Sprintf("Showing %s...", color.CyanString(entry.Key)))
+	chalker.Log(chalker.DEFAULT, fmt.Sprintf("Model     : %s", color.CyanString(entry.Model)))
+	chalker.Log(chalker.DEFAULT, fmt.Sprintf("Age       : %s", color.CyanString(entry.ageString())))
+	chalker.Log(chalker.DEFAULT, fmt.Sprintf("Expires In: %s", color.CyanString(entry.ttlString())))
+
+	// Pretty print JSON (otherwise the raw value)
+	var pretty bytes.Buffer
+	if json.Indent(&pretty, []byte(item.Value), "", "  ") == nil {
+		chalker.Log(chalker.DEFAULT, pretty.String())
+	} else {
+		chalker.Log(chalker.DEFAULT, item.Value)
+	}
+}
+
+// purgeCache will remove keys by model type (prefix), paymail address or domain
+func purgeCache(target string) {
+	var keys []string
+	var err error
+
+	if isCacheModel(target) || strings.HasSuffix(target, "-") {
+		// Model type or key prefix
+		var removed int
+		if removed, err = database.DeletePrefix(target); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error purging cache: %s", err.Error()))
+			return
+		}
+		chalker.Log(chalker.SUCCESS, fmt.Sprintf("Purged %d key(s) with prefix: %s", removed, target))
+		return
+	}
+
+	// Find all keys for the address or domain
+	var items []*database.Item
+	if items, err = database.List(""); err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error purging cache: %s", err.Error()))
+		return
+	}
+	target = strings.ToLower(target)
+	if !strings.Contains(target, "@") {
+		target, _ = sanitize.Domain(target, false, true)
+	}
+	for _, item := range items {
+		if model, subject := splitCacheKey(item.Key); !isSavedModel(model) && cacheSubjectMatches(subject, target) {
+			keys = append(keys, item.Key)
+		}
+	}
+
+	if len(keys) == 0 {
+		chalker.Log(chalker.WARN, fmt.Sprintf("No cached keys found for: %s", target))
+		return
+	}
+	if err = database.Delete(keys...); err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error purging cache: %s", err.Error()))
+		return
+	}
+	for _, key := range keys {
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Removed: %s", key))
+	}
+	chalker.Log(chalker.SUCCESS, fmt.Sprintf("Purged %d key(s) for: %s", len(keys), target))
+}
+
+// cacheStats will display the number of keys per model type and the database size
+func cacheStats() {
+	items, err := database.List("")
+	if err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error reading cache: %s", err.Error()))
+		return
+	}
+	stats := &CacheStats{Keys: len(items), Models: map[string]int{}}
+	for _, item := range items {
+		model, _ := splitCacheKey(item.Key)
+		stats.Models[model]++
+	}
+	if stats.LSMSize, stats.VLogSize, err = database.Size(); err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error reading database size: %s", err.Error()))
+	}
+	runReport.CacheStats = stats
+
+	displayHeader(chalker.BOLD, fmt.Sprintf("Cache stats for %s...", color.CyanString(applicationDirectory)))
+	models := make([]string, 0, len(stats.Models))
+	for model := range stats.Models {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	output := []string{"Model | Keys"}
+	for _, model := range models {
+		output = append(output, fmt.Sprintf("%s | %d", model, stats.Models[model]))
+	}
+	chalker.Log(chalker.DEFAULT, columnize.SimpleFormat(output))
+	chalker.Log(chalker.DEFAULT, fmt.Sprintf("Total Keys: %s", color.CyanString(fmt.Sprintf("%d", stats.Keys))))
+	chalker.Log(chalker.DEFAULT, fmt.Sprintf("LSM Size  : %s", color.CyanString(fmt.Sprintf("%d bytes", stats.LSMSize))))
+	chalker.Log(chalker.DEFAULT, fmt.Sprintf("VLog Size : %s", color.CyanString(fmt.Sprintf("%d bytes", stats.VLogSize))))
+}
+
+// newCacheEntry will create a cache entry from a database item
+func newCacheEntry(item *database.Item) *CacheEntry {
+	entry := &CacheEntry{Key: item.Key, Size: item.Size}
+	entry.Model, entry.Subject = splitCacheKey(item.Key)
+	if !item.StoredAt.IsZero() {
+		entry.StoredAt = &item.StoredAt
+		entry.Age = time.Since(item.StoredAt)
+	}
+	if !item.ExpiresAt.IsZero() {
+		entry.ExpiresAt = &item.ExpiresAt
+		entry.TTL = time.Until(item.ExpiresAt)
+	}
+	if len(item.Value) > 0 && json.Valid([]byte(item.Value)) {
+		entry.Value = json.RawMessage(item.Value)
+	}
+	return entry
+}
+
+// ageString returns the age for display
+func (c *CacheEntry) ageString() string {
+	if c.StoredAt == nil {
+		return "unknown"
+	}
+	return c.Age.Round(time.Second).String()
+}
+
+// ttlString returns the time until expiration for display
+func (c *CacheEntry) ttlString() string {
+	if c.ExpiresAt == nil {
+		return "never"
+	}
+	return c.TTL.Round(time.Second).String()
+}
+
+// splitCacheKey will split a key into the model type (longest known prefix) and the subject (domain or address)
+func splitCacheKey(key string) (model, subject string) {
+	for _, prefix := range cacheModels {
+		if strings.HasPrefix(key, prefix) && len(prefix) > len(model) {
+			model = prefix
+		}
+	}
+	if len(model) == 0 {
+		return "other", key
+	}
+	return model, strings.TrimPrefix(key, model)
+}
+
+// isCacheModel returns true if the value is a known model type
+func isCacheModel(value string) bool {
+	for _, model := range cacheModels {
+		if value == model {
+			return true
+		}
+	}
+	return false
+}
+
+// isSavedModel returns true if the model type is saved user data (not purged by domain or address)
+func isSavedModel(model string) bool {
+	for _, saved := range savedModels {
+		if model == saved {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheSubjectMatches returns true if the key subject belongs to the address or domain
+func cacheSubjectMatches(subject, target string) bool {
+	if subject == target {
+		return true
+	} else if strings.Contains(target, "@") {
+		// Address: a P2P submission is keyed by the address and the reference
+		return strings.HasPrefix(subject, target+"-")
+	}
+	// Domain: any address on the domain (or its P2P submissions), or a capabilities key with a host override
+	if _, domain, found := strings.Cut(subject, "@"); found {
+		return domain == target || strings.HasPrefix(domain, target+"-")
+	}
+	return strings.HasPrefix(subject, target+"-")
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import "testing"
+
+// TestSplitCacheKey will test the method splitCacheKey()
+func TestSplitCacheKey(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		key             string
+		expectedModel   string
+		expectedSubject string
+	}{
+		{"model-pki-mrz@moneybutton.com", "model-pki-", "mrz@moneybutton.com"},
+		{"model-capabilities-moneybutton.com", "model-capabilities-", "moneybutton.com"},
+		{"model-capabilities-localhost-3443", "model-capabilities-", "localhost-3443"},
+		{"model-public-profile-mrz@moneybutton.com", "model-public-profile-", "mrz@moneybutton.com"},
+		{"model-srv-moneybutton.com", "model-srv-", "moneybutton.com"},
+		{"p2p-reference-mrz@moneybutton.com-abc", p2pReferencePrefix, "mrz@moneybutton.com-abc"},
+		{"app-bitpic-mrz@moneybutton.com", "app-bitpic-", "mrz@moneybutton.com"},
+		{"capabilities-snapshot-moneybutton.com", capabilitiesSnapshotPrefix, "moneybutton.com"},
+		{"watch-snapshot-mrz@moneybutton.com", watchKeyPrefix, "mrz@moneybutton.com"},
+		{"model-", "other", "model-"},
+		{"unknown-key", "other", "unknown-key"},
+		{"", "other", ""},
+	}
+
+	for _, test := range tests {
+		model, subject := splitCacheKey(test.key)
+		if model != test.expectedModel || subject != test.expectedSubject {
+			t.Errorf("%s Failed: [%s] inputted and [%s] [%s] expected, received: [%s] [%s]", t.Name(), test.key, test.expectedModel, test.expectedSubject, model, subject)
+		}
+	}
+}
+
+// TestIsCacheModel will test the method isCacheModel()
+func TestIsCacheModel(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		value    string
+		expected bool
+	}{
+		{"model-pki-", true},
+		{"model-srv-", true},
+		{"app-powping-", true},
+		{"model-pki", false},
+		{"model-", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if output := isCacheModel(test.value); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.value, test.expected, output)
+		}
+	}
+}
+
+// TestCacheSubjectMatches will test the method cacheSubjectMatches()
+func TestCacheSubjectMatches(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		subject  string
+		target   string
+		expected bool
+	}{
+		{"moneybutton.com", "moneybutton.com", true},
+		{"mrz@moneybutton.com", "moneybutton.com", true},
+		{"moneybutton.com-localhost-3443", "moneybutton.com", true},
+		{"mrz@moneybutton.com", "mrz@moneybutton.com", true},
+		{"moneybutton.com", "mrz@moneybutton.com", false},
+		{"other@moneybutton.com", "mrz@moneybutton.com", false},
+		{"mrz@notmoneybutton.com", "moneybutton.com", false},
+		{"sub.moneybutton.com", "moneybutton.com", false},
+		{"moneybutton.co", "moneybutton.com", false},
+		{"mrz@moneybutton.com-abc-123", "mrz@moneybutton.com", true},
+		{"mrz@moneybutton.com-abc-123", "moneybutton.com", true},
+		{"other@moneybutton.com-abc-123", "mrz@moneybutton.com", false},
+		{"mrz@notmoneybutton.com-abc-123", "moneybutton.com", false},
+		{"mrz@moneybutton.co-abc-123", "moneybutton.com", false},
+	}
+
+	for _, test := range tests {
+		if output := cacheSubjectMatches(test.subject, test.target); output != test.expected {
+			t.Errorf("%s Failed: [%s] [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.subject, test.target, test.expected, output)
+		}
+	}
+}
+
+// TestIsSavedModel will test the method isSavedModel()
+func TestIsSavedModel(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		model    string
+		expected bool
+	}{
+		{capabilitiesSnapshotPrefix, true},
+		{watchKeyPrefix, true},
+		{"model-capabilities-", false},
+		{"model-pki-", false},
+		{"other", false},
+	}
+
+	for _, test := range tests {
+		if output := isSavedModel(test.model); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.model, test.expected, output)
+		}
+	}
+}
@@ -2,8 +2,7 @@ package cmd
 
 import (
 	"fmt"
-	"reflect"
-	"strings"
+	"sort"
 
 	"github.com/bsv-blockchain/go-paymail"
 	"github.com/fatih/color"
@@ -35,7 +34,9 @@ dictates that a machine-readable document is placed in a predictable location on
 Read more at: `+color.CyanString("http://bsvalias.org/02-02-capability-discovery.html")),
 	Aliases: []string{"c", "inspect"},
 	Example: applicationName + " capabilities " + defaultDomainName + `
-` + applicationName + " c " + defaultDomainName,
+` + applicationName + " c " + defaultDomainName + `
+` + applicationName + " capabilities diff handcash.io " + defaultDomainName + `
+` + applicationName + " capabilities snapshot " + defaultDomainName,
 	Args: func(_ *cobra.Command, args []string) error {
 		if len(args) < 1 {
 			return chalker.Error("capabilities requires either a domain or paymail address")
@@ -44,7 +45,9 @@ Read more at: `+color.CyanString("http://bsvalias.org/02-02-capability-discovery
 		}
 		return nil
 	},
-	Run: func(_ *cobra.Command, args []string) {
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		// Sanitize the domain
 		domain, _ := sanitize.Domain(args[0], false, true)
 
@@ -57,36 +60,125 @@ Read more at: `+color.CyanString("http://bsvalias.org/02-02-capability-discovery
 
 		// Get the capabilities
 		var capabilities *paymail.CapabilitiesResponse
-		capabilities, err = getCapabilities(domain, false)
+		capabilities, err = getCapabilities(ctx, domain, false)
 		if err != nil {
-			if strings.Contains(err.Error(), "context deadline exceeded") {
-				chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s", domain))
+			if isTimeout(err) {
+				chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s (%s)", domain, err.Error()))
 			} else {
 				chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
 			}
 			return
 		}
 
+		runReport.Capabilities = &capabilities.CapabilitiesPayload
+
+		// Join the capabilities with the known BRFC specifications
+		var client paymail.ClientInterface
+		if client, err = newPaymailClient(ctx, false, nameServer); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading BRFC specifications: %s", err.Error()))
+			return
+		}
+		details := annotateCapabilities(capabilities.Capabilities, client.GetBRFCs())
+		runReport.CapabilityDetails = details
+
 		// Rendering profile information
-		displayHeader(chalker.BOLD, fmt.Sprintf("Listing %d capabilities...", len(capabilities.Capabilities)))
-
-		// Show all the found capabilities
-		// todo: loop known BRFCs and display "more" info in this display for all detected BRFCs
-		for key, val := range capabilities.Capabilities {
-			valType := reflect.TypeOf(val).String()
-			if valType == "string" {
-				chalker.Log(chalker.INFO, fmt.Sprintf("%s: %-28v %s: %s", color.WhiteString("Capability"), color.CyanString(key), color.WhiteString("Target"), color.YellowString(fmt.Sprintf("%s", val))))
-			} else if valType == "bool" { // See: http://bsvalias.org/04-02-sender-validation.html
-				if val.(bool) {
-					chalker.Log(chalker.INFO, fmt.Sprintf("%s: %-28v Is    : %s", color.WhiteString("Capability"), color.CyanString(key), color.GreenString("Enabled")))
-				} else {
-					chalker.Log(chalker.INFO, fmt.Sprintf("%s: %-28v Is    : %s", color.WhiteString("Capability"), color.CyanString(key), color.MagentaString("Disabled")))
+		displayHeader(chalker.BOLD, fmt.Sprintf("Listing %d capabilities...", len(details)))
+
+		// Show all the found capabilities (known BRFCs first, then unknown or custom keys)
+		for _, known := range []bool{true, false} {
+			var group []*CapabilityDetail
+			for _, detail := range details {
+				if detail.Known == known {
+					group = append(group, detail)
 				}
 			}
+			if len(group) == 0 {
+				continue
+			}
+			if known {
+				displayHeader(chalker.DEFAULT, fmt.Sprintf("Known BRFC specifications (%d)...", len(group)))
+			} else {
+				displayHeader(chalker.DEFAULT, fmt.Sprintf("Unknown or custom capabilities (%d)...", len(group)))
+			}
+			for _, detail := range group {
+				detail.Display()
+			}
 		}
 	},
 }
 
+// CapabilityDetail is one capability joined with its BRFC specification (if known)
+type CapabilityDetail struct {
+	BRFC  *paymail.BRFCSpec `json:"brfc,omitempty"`
+	Key   string            `json:"key"`
+	Known bool              `json:"known"`
+	Value interface{}       `json:"value"`
+}
+
+// annotateCapabilities will match each capability key to a BRFC (by ID or alias), sorted by key
+func annotateCapabilities(capabilities map[string]interface{}, brfcs []*paymail.BRFCSpec) []*CapabilityDetail {
+	// Index the specifications (first one wins)
+	specs := make(map[string]*paymail.BRFCSpec, len(brfcs))
+	for _, brfc := range brfcs {
+		for _, key := range []string{brfc.ID, brfc.Alias} {
+			if _, ok := specs[key]; len(key) > 0 && !ok {
+				specs[key] = brfc
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(capabilities))
+	for key := range capabilities {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	details := make([]*CapabilityDetail, 0, len(keys))
+	for _, key := range keys {
+		brfc := specs[key]
+		details = append(details, &CapabilityDetail{BRFC: brfc, Key: key, Known: brfc != nil, Value: capabilities[key]})
+	}
+	return details
+}
+
+// Display will show the capability with its BRFC specification
+func (c *CapabilityDetail) Display() {
+	name := color.MagentaString("(unknown or custom)")
+	if c.Known {
+		name = color.WhiteString(c.BRFC.Title)
+		if len(c.BRFC.Version) > 0 {
+			name += color.WhiteString(" v" + c.BRFC.Version)
+		}
+	}
+	chalker.Log(chalker.INFO, fmt.Sprintf("%s: %-28v %s", color.WhiteString("Capability"), color.CyanString(c.Key), name))
+
+	switch value := c.Value.(type) {
+	case string:
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("  Target : %s", color.YellowString(value)))
+	case bool: // See: http://bsvalias.org/04-02-sender-validation.html
+		if value {
+			chalker.Log(chalker.DEFAULT, fmt.Sprintf("  Is     : %s", color.GreenString("Enabled")))
+		} else {
+			chalker.Log(chalker.DEFAULT, fmt.Sprintf("  Is     : %s", color.MagentaString("Disabled")))
+		}
+	default:
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("  Value  : %s", color.YellowString(fmt.Sprintf("%v", value))))
+	}
+
+	if !c.Known {
+		return
+	}
+	if c.Key != c.BRFC.ID {
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("  BRFC   : %s", color.CyanString(c.BRFC.ID)))
+	}
+	if len(c.BRFC.Author) > 0 {
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("  Author : %s", color.CyanString(c.BRFC.Author)))
+	}
+	if len(c.BRFC.URL) > 0 {
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("  Spec   : %s", color.CyanString(c.BRFC.URL)))
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(capabilitiesCmd)
 }
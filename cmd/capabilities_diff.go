@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/fatih/color"
+	"github.com/mrz1836/go-sanitize"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+)
+
+// Sources for a capabilities document (otherwise a live domain)
+const (
+	capabilitiesSourceCache    = "cache:"
+	capabilitiesSourceSnapshot = "snapshot:"
+)
+
+// CapabilityChange is one BRFC key that is different between two capabilities documents
+type CapabilityChange struct {
+	BRFC   string `json:"brfc"`
+	Change string `json:"change"`
+	New    string `json:"new,omitempty"`
+	Old    string `json:"old,omitempty"`
+}
+
+// CapabilitiesDiff is the comparison of two capabilities documents
+type CapabilitiesDiff struct {
+	Changes   []*CapabilityChange `json:"changes"`
+	From      string              `json:"from"`
+	To        string              `json:"to"`
+	Unchanged int                 `json:"unchanged"`
+}
+
+// capabilitiesDiffCmd represents the capabilities diff command
+var capabilitiesDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the capabilities of two domains, or a domain and a saved or cached snapshot",
+	Long: color.YellowString(`
+This command will compare two capabilities documents and show the added, removed and changed BRFC keys.
+
+Each side is either a live domain, a saved snapshot (snapshot:<name>) or the cached capabilities
+of a domain (cache:<domain>). Save a named snapshot using: ` + applicationName + ` capabilities snapshot
+
+Read more at: ` + color.CyanString("http://bsvalias.org/02-02-capability-discovery.html")),
+	Example: applicationName + " capabilities diff handcash.io " + defaultDomainName + `
+` + applicationName + ` capabilities diff snapshot:relayx-last-week relayx.io
+` + applicationName + ` capabilities diff cache:relayx.io relayx.io`,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return chalker.Error("capabilities diff requires two domains or snapshots")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// Load both documents
+		from, err := loadCapabilitiesSource(ctx, args[0])
+		if err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading %s: %s", args[0], err.Error()))
+			return
+		}
+		var to *paymail.CapabilitiesPayload
+		if to, err = loadCapabilitiesSource(ctx, args[1]); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading %s: %s", args[1], err.Error()))
+			return
+		}
+
+		// Compare
+		changes := diffCapabilities(capabilityStrings(from.Capabilities), capabilityStrings(to.Capabilities))
+		diff := &CapabilitiesDiff{
+			Changes:   changes,
+			From:      args[0],
+			To:        args[1],
+			Unchanged: countUnchanged(from.Capabilities, to.Capabilities),
+		}
+		runReport.CapabilitiesDiff = diff
+
+		// Rendering the results
+		displayHeader(chalker.BOLD, fmt.Sprintf("Comparing %s to %s...", color.CyanString(diff.From), color.CyanString(diff.To)))
+		if from.BsvAlias != to.BsvAlias {
+			chalker.Log(chalker.WARN, fmt.Sprintf("%s version: %s -> %s", flagBsvAlias, from.BsvAlias, to.BsvAlias))
+		}
+		if len(changes) == 0 {
+			chalker.Log(chalker.SUCCESS, fmt.Sprintf("No differences found (%d capabilities)", diff.Unchanged))
+			return
+		}
+		output := []string{"Change | BRFC | " + diff.From + " | " + diff.To}
+		for _, change := range changes {
+			output = append(output, fmt.Sprintf("%s | %s | %s | %s", change.Change, change.BRFC, dashIfEmpty(change.Old), dashIfEmpty(change.New)))
+		}
+		chalker.Log(chalker.DEFAULT, columnize.SimpleFormat(output))
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Changes: %s Unchanged: %s", color.CyanString(fmt.Sprint(len(changes))), color.CyanString(fmt.Sprint(diff.Unchanged))))
+	},
+}
+
+// loadCapabilitiesSource will load a capabilities document from a live domain, a snapshot or the cache
+func loadCapabilitiesSource(ctx context.Context, source string) (*paymail.CapabilitiesPayload, error) {
+	switch {
+	case strings.HasPrefix(source, capabilitiesSourceSnapshot):
+		snapshot, err := getCapabilitiesSnapshot(strings.TrimPrefix(source, capabilitiesSourceSnapshot))
+		if err != nil {
+			return nil, err
+		}
+		chalker.Log(chalker.INFO, fmt.Sprintf("Loaded snapshot %s of %s (saved %s)", color.CyanString(snapshot.Name), snapshot.Domain, snapshot.SavedAt.Format(time.RFC3339)))
+		return snapshot.Capabilities, nil
+	case strings.HasPrefix(source, capabilitiesSourceCache):
+		if !databaseEnabled {
+			return nil, fmt.Errorf("the local database is not available")
+		}
+		domain, _ := sanitize.Domain(strings.TrimPrefix(source, capabilitiesSourceCache), false, true)
+		keyName := "model-capabilities-" + domain
+		if len(hostOverride) > 0 {
+			keyName += "-" + hostOverride
+		}
+		item, err := getCache(ctx, keyName)
+		if err != nil {
+			return nil, err
+		} else if item == nil {
+			return nil, fmt.Errorf("no cached capabilities for: %s", domain)
+		}
+		capabilities := new(paymail.CapabilitiesResponse)
+		if err = json.Unmarshal([]byte(item.Value), capabilities); err != nil {
+			return nil, err
+		}
+		chalker.Log(chalker.INFO, fmt.Sprintf("Loaded cached capabilities for %s %s", color.CyanString(domain), fromCache(item)))
+		return &capabilities.CapabilitiesPayload, nil
+	}
+
+	// Live domain
+	domain := source
+	if strings.Contains(domain, "@") {
+		_, domain, _ = paymail.SanitizePaymail(domain)
+	}
+	domain, _ = sanitize.Domain(domain, false, true)
+	if err := paymail.ValidateDomain(domain); err != nil {
+		return nil, fmt.Errorf("domain name %s is invalid: %w", domain, err)
+	}
+	capabilities, err := getCapabilities(ctx, domain, false)
+	if err != nil {
+		return nil, err
+	}
+	return &capabilities.CapabilitiesPayload, nil
+}
+
+// capabilityStrings will convert the capability values to strings (targets or JSON for other values)
+func capabilityStrings(capabilities map[string]interface{}) map[string]string {
+	values := make(map[string]string, len(capabilities))
+	for brfcID, value := range capabilities {
+		if target, ok := value.(string); ok {
+			values[brfcID] = target
+		} else if data, err := json.Marshal(value); err == nil {
+			values[brfcID] = string(data)
+		} else {
+			values[brfcID] = fmt.Sprint(value)
+		}
+	}
+	return values
+}
+
+// diffCapabilities will return the added, removed and changed keys (sorted by BRFC ID)
+func diffCapabilities(from, to map[string]string) []*CapabilityChange {
+	changes := []*CapabilityChange{}
+	brfcIDs := make([]string, 0, len(from)+len(to))
+	for brfcID := range from {
+		brfcIDs = append(brfcIDs, brfcID)
+	}
+	for brfcID := range to {
+		if _, ok := from[brfcID]; !ok {
+			brfcIDs = append(brfcIDs, brfcID)
+		}
+	}
+	sort.Strings(brfcIDs)
+
+	for _, brfcID := range brfcIDs {
+		oldValue, inFrom := from[brfcID]
+		newValue, inTo := to[brfcID]
+		switch {
+		case !inFrom:
+			changes = append(changes, &CapabilityChange{BRFC: brfcID, Change: watchChangeAdded, New: newValue})
+		case !inTo:
+			changes = append(changes, &CapabilityChange{BRFC: brfcID, Change: watchChangeRemoved, Old: oldValue})
+		case oldValue != newValue:
+			changes = append(changes, &CapabilityChange{BRFC: brfcID, Change: watchChangeChanged, New: newValue, Old: oldValue})
+		}
+	}
+	return changes
+}
+
+// countUnchanged returns the number of keys that are the same in both documents
+func countUnchanged(from, to map[string]interface{}) (count int) {
+	fromValues, toValues := capabilityStrings(from), capabilityStrings(to)
+	for brfcID, value := range fromValues {
+		if newValue, ok := toValues[brfcID]; ok && newValue == value {
+			count++
+		}
+	}
+	return count
+}
+
+// dashIfEmpty returns a dash for empty values (table display)
+func dashIfEmpty(value string) string {
+	if len(value) == 0 {
+		return "-"
+	}
+	return value
+}
+
+func init() {
+	capabilitiesCmd.AddCommand(capabilitiesDiffCmd)
+}
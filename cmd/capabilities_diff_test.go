@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDiffCapabilities will test the method diffCapabilities()
+func TestDiffCapabilities(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		from     map[string]string
+		to       map[string]string
+		expected []*CapabilityChange
+	}{
+		{"both empty", nil, nil, []*CapabilityChange{}},
+		{"no changes", map[string]string{"pki": "a"}, map[string]string{"pki": "a"}, []*CapabilityChange{}},
+		{"added", nil, map[string]string{"pki": "a"}, []*CapabilityChange{
+			{BRFC: "pki", Change: watchChangeAdded, New: "a"},
+		}},
+		{"removed", map[string]string{"pki": "a"}, map[string]string{}, []*CapabilityChange{
+			{BRFC: "pki", Change: watchChangeRemoved, Old: "a"},
+		}},
+		{"changed", map[string]string{"pki": "a"}, map[string]string{"pki": "b"}, []*CapabilityChange{
+			{BRFC: "pki", Change: watchChangeChanged, New: "b", Old: "a"},
+		}},
+		{"sorted by brfc", map[string]string{"c": "1", "a": "1", "b": "1"}, map[string]string{"d": "1", "b": "2", "a": "1"}, []*CapabilityChange{
+			{BRFC: "b", Change: watchChangeChanged, New: "2", Old: "1"},
+			{BRFC: "c", Change: watchChangeRemoved, Old: "1"},
+			{BRFC: "d", Change: watchChangeAdded, New: "1"},
+		}},
+	}
+
+	for _, test := range tests {
+		if output := diffCapabilities(test.from, test.to); !reflect.DeepEqual(output, test.expected) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
+
+// TestCapabilityStrings will test the method capabilityStrings()
+func TestCapabilityStrings(t *testing.T) {
+	t.Parallel()
+
+	output := capabilityStrings(map[string]interface{}{
+		"pki":    "https://example.com/{alias}@{domain.tld}/id",
+		"flag":   true,
+		"number": 1.5,
+		"object": map[string]interface{}{"b": 2, "a": "x"},
+		"null":   nil,
+	})
+	expected := map[string]string{
+		"pki":    "https://example.com/{alias}@{domain.tld}/id",
+		"flag":   "true",
+		"number": "1.5",
+		"object": `{"a":"x","b":2}`,
+		"null":   "null",
+	}
+	if !reflect.DeepEqual(output, expected) {
+		t.Errorf("%s Failed: [%v] expected, received: [%v]", t.Name(), expected, output)
+	}
+
+	if output = capabilityStrings(nil); output == nil || len(output) != 0 {
+		t.Errorf("%s Failed: [nil] inputted and an empty map expected, received: [%v]", t.Name(), output)
+	}
+}
+
+// TestCountUnchanged will test the method countUnchanged()
+func TestCountUnchanged(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		from     map[string]interface{}
+		to       map[string]interface{}
+		expected int
+	}{
+		{"empty", nil, nil, 0},
+		{"all the same", map[string]interface{}{"a": "1", "b": true}, map[string]interface{}{"a": "1", "b": true}, 2},
+		{"one changed", map[string]interface{}{"a": "1", "b": true}, map[string]interface{}{"a": "1", "b": false}, 1},
+		{"one removed and one added", map[string]interface{}{"a": "1", "b": "2"}, map[string]interface{}{"a": "1", "c": "2"}, 1},
+	}
+
+	for _, test := range tests {
+		if output := countUnchanged(test.from, test.to); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%d] expected, received: [%d]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/fatih/color"
+	"github.com/mrz1836/go-sanitize"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/database"
+	"github.com/spf13/cobra"
+)
+
+// capabilitiesSnapshotPrefix is the key prefix for saved snapshots (no expiration)
+const capabilitiesSnapshotPrefix = "capabilities-snapshot-"
+
+// CapabilitiesSnapshot is a named copy of a capabilities document (kept in the local database)
+type CapabilitiesSnapshot struct {
+	Capabilities *paymail.CapabilitiesPayload `json:"capabilities"`
+	Domain       string                       `json:"domain"`
+	Name         string                       `json:"name"`
+	SavedAt      time.Time                    `json:"saved_at"`
+}
+
+// capabilitiesSnapshotCmd represents the capabilities snapshot command
+var capabilitiesSnapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save the capabilities of a domain as a named snapshot (for capabilities diff)",
+	Long: color.YellowString(`
+This command will save the live capabilities of a domain as a named snapshot in the local database.
+
+Compare a snapshot later using: ` + applicationName + ` capabilities diff snapshot:<name> <domain>
+
+The name defaults to the domain (saving again replaces the snapshot).`),
+	Example: applicationName + ` capabilities snapshot relayx.io relayx-last-week
+` + applicationName + " capabilities snapshot " + defaultDomainName,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return chalker.Error("capabilities snapshot requires a domain")
+		} else if len(args) > 2 {
+			return chalker.Error("capabilities snapshot only supports a domain and a name")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// The database is required
+		if !databaseEnabled {
+			chalker.Log(chalker.ERROR, "The local database is not available")
+			return
+		}
+
+		// Sanitize the domain and name
+		domain := args[0]
+		if strings.Contains(domain, "@") {
+			_, domain, _ = paymail.SanitizePaymail(domain)
+		}
+		domain, _ = sanitize.Domain(domain, false, true)
+		if err := paymail.ValidateDomain(domain); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Domain name %s is invalid: %s", domain, err.Error()))
+			return
+		}
+		name := domain
+		if len(args) > 1 {
+			name = sanitize.Custom(args[1], `[^a-zA-Z0-9-_.@:]`)
+		}
+		if len(name) == 0 {
+			chalker.Log(chalker.ERROR, "Snapshot name is invalid")
+			return
+		}
+
+		// Get the live capabilities
+		capabilities, err := getCapabilities(ctx, domain, false)
+		if err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+			return
+		}
+
+		// Save the snapshot
+		snapshot := &CapabilitiesSnapshot{
+			Capabilities: &capabilities.CapabilitiesPayload,
+			Domain:       domain,
+			Name:         name,
+			SavedAt:      time.Now().UTC(),
+		}
+		var data []byte
+		if data, err = json.Marshal(snapshot); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error saving snapshot: %s", err.Error()))
+			return
+		}
+		if err = database.Set(capabilitiesSnapshotPrefix+name, string(data), 0); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error saving snapshot: %s", err.Error()))
+			return
+		}
+		runReport.Capabilities = snapshot.Capabilities
+
+		chalker.Log(chalker.SUCCESS, fmt.Sprintf("Saved %d capabilities as snapshot: %s", len(capabilities.Capabilities), color.CyanString(name)))
+	},
+}
+
+// getCapabilitiesSnapshot will load a saved snapshot by name
+func getCapabilitiesSnapshot(name string) (*CapabilitiesSnapshot, error) {
+	if !databaseEnabled {
+		return nil, fmt.Errorf("the local database is not available")
+	}
+	jsonStr, err := database.Get(capabilitiesSnapshotPrefix + name)
+	if err != nil {
+		return nil, err
+	} else if len(jsonStr) == 0 {
+		return nil, fmt.Errorf("snapshot not found: %s", name)
+	}
+	snapshot := new(CapabilitiesSnapshot)
+	if err = json.Unmarshal([]byte(jsonStr), snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+func init() {
+	capabilitiesCmd.AddCommand(capabilitiesSnapshotCmd)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-paymail"
+)
+
+// TestAnnotateCapabilities will test the method annotateCapabilities()
+func TestAnnotateCapabilities(t *testing.T) {
+	t.Parallel()
+
+	pki := &paymail.BRFCSpec{Alias: "pki", ID: "0c4339ef99c2", Title: "Public Key Infrastructure"}
+	duplicate := &paymail.BRFCSpec{Alias: "pki", ID: "0c4339ef99c2", Title: "Duplicate"}
+	resolution := &paymail.BRFCSpec{ID: "759684b1a19a", Title: "Payment Destination"}
+	brfcs := []*paymail.BRFCSpec{pki, duplicate, resolution, {ID: "", Title: "No ID"}}
+
+	details := annotateCapabilities(map[string]interface{}{
+		"pki":          "https://example.com/pki",
+		"759684b1a19a": "https://example.com/resolve",
+		"0c4339ef99c2": "https://example.com/id",
+		"f12f968c92d6": true,
+		"":             "empty",
+	}, brfcs)
+
+	var tests = []struct {
+		key   string
+		brfc  *paymail.BRFCSpec
+		known bool
+	}{
+		{"", nil, false},
+		{"0c4339ef99c2", pki, true},
+		{"759684b1a19a", resolution, true},
+		{"f12f968c92d6", nil, false},
+		{"pki", pki, true},
+	}
+
+	if len(details) != len(tests) {
+		t.Fatalf("%s Failed: [%d] details expected, received: [%d]", t.Name(), len(tests), len(details))
+	}
+	for index, test := range tests {
+		detail := details[index]
+		if detail.Key != test.key || detail.BRFC != test.brfc || detail.Known != test.known {
+			t.Errorf("%s Failed: [%s] %v %v expected at %d, received: [%s] %v %v", t.Name(), test.key, test.brfc, test.known, index, detail.Key, detail.BRFC, detail.Known)
+		}
+	}
+
+	if details[3].Value != true {
+		t.Errorf("%s Failed: [true] value expected, received: [%v]", t.Name(), details[3].Value)
+	}
+
+	// Nothing to annotate
+	if output := annotateCapabilities(nil, brfcs); output == nil || len(output) != 0 {
+		t.Errorf("%s Failed: [nil] inputted and an empty list expected, received: [%v]", t.Name(), output)
+	}
+}
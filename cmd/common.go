@@ -2,28 +2,39 @@ package cmd
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bsv-blockchain/go-paymail"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/fatih/color"
 	"github.com/go-resty/resty/v2"
 	"github.com/mrz1836/paymail-inspector/chalker"
 	"github.com/mrz1836/paymail-inspector/database"
-	"github.com/mrz1836/paymail-inspector/integrations/baemail"
-	"github.com/mrz1836/paymail-inspector/integrations/bitpic"
-	"github.com/mrz1836/paymail-inspector/integrations/powping"
-	"github.com/mrz1836/paymail-inspector/integrations/roundesk"
+	"github.com/mrz1836/paymail-inspector/har"
+	"github.com/mrz1836/paymail-inspector/integrations"
+	"github.com/mrz1836/paymail-inspector/retry"
 	"github.com/ryanuber/columnize"
 	"github.com/spf13/viper"
 )
 
-// Creates a new client for Paymail
-func newPaymailClient(tracing bool, nameServer string) (paymail.ClientInterface, error) {
-	opts := []paymail.ClientOps{paymail.WithUserAgent(applicationFullName + ": v" + Version)}
+// Creates a new client for Paymail (all requests and lookups use the context and the per-phase timeouts)
+func newPaymailClient(ctx context.Context, tracing bool, nameServer string) (paymail.ClientInterface, error) {
+	opts := []paymail.ClientOps{
+		paymail.WithDNSTimeout(dnsTimeout),
+		paymail.WithHTTPTimeout(httpTimeout),
+		paymail.WithSSLDeadline(sslTimeout),
+		paymail.WithSSLTimeout(sslTimeout),
+		paymail.WithUserAgent(applicationFullName + ": v" + Version),
+	}
 
 	if tracing {
 		opts = append(opts, paymail.WithRequestTracing())
@@ -32,50 +43,164 @@ func newPaymailClient(tracing bool, nameServer string) (paymail.ClientInterface,
 		opts = append(opts, paymail.WithNameServer(nameServer))
 	}
 
-	return paymail.NewClient(opts...)
+	client, err := paymail.NewClient(opts...)
+	if err != nil {
+		return client, err
+	}
+
+	// Run all requests with the context of the command (CTRL+C or --timeout)
+	httpClient := resty.New().
+		SetTimeout(httpTimeout).
+		OnBeforeRequest(func(_ *resty.Client, request *resty.Request) error {
+			request.SetContext(ctx)
+			return nil
+		})
+
+	// Retry transient failures (each failed attempt is shown in the tracing results)
+	retryPolicy().Apply(httpClient, func(attempt *retry.Attempt) {
+		displayRetry(ctx, attempt, tracing)
+	})
+
+	// Record all requests (--har)
+	har.Apply(ctx, httpClient)
+
+	// Skip TLS verification (self-signed certificates, IE: serve)
+	if insecure {
+		httpClient.SetTLSClientConfig(&tls.Config{InsecureSkipVerify: true}) //nolint:gosec // G402 - user requested --insecure
+	}
+
+	return client.WithCustomHTTPClient(httpClient).WithCustomResolver(&contextResolver{ctx: ctx, resolver: client.GetResolver()}), nil
+}
+
+// retryPolicy returns the retry policy for paymail requests (--max-attempts and --retry-wait)
+func retryPolicy() retry.Policy {
+	return retry.Policy{Attempts: maxAttempts, MaxWait: retry.DefaultMaxWait, MinWait: retryWait}
+}
+
+// displayRetry will show a failed attempt that is being retried (and the tracing results if enabled)
+func displayRetry(ctx context.Context, attempt *retry.Attempt, tracing bool) {
+	reason := fmt.Sprintf("status code %d", attempt.StatusCode)
+	if attempt.Err != nil {
+		reason = attempt.Err.Error()
+	}
+	logWithContext(ctx, chalker.WARN, fmt.Sprintf("Attempt %d failed for %s (%s), retrying...", attempt.Number, attempt.URL, reason))
+	if tracing {
+		displayTracingResults(ctx, fmt.Sprintf("attempt-%d", attempt.Number), attempt.URL, attempt.Tracing, attempt.StatusCode)
+	}
+}
+
+// splitHostOverride will split the --host value into a host and port (port defaults to 443)
+func splitHostOverride(hostPort string) (string, int, error) {
+	if !strings.Contains(hostPort, ":") {
+		return hostPort, paymail.DefaultPort, nil
+	}
+	host, portString, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid host %s: %w", hostPort, err)
+	}
+	var hostPortNumber int
+	if hostPortNumber, err = strconv.Atoi(portString); err != nil || hostPortNumber < 1 || hostPortNumber > 65535 {
+		return "", 0, fmt.Errorf("invalid port in host %s", hostPort)
+	}
+	return host, hostPortNumber, nil
+}
+
+// cacheTTL returns the time to live for a cached model (cache-ttl.<model> in the config, default: 1h)
+func cacheTTL(ctx context.Context, keyName string) time.Duration {
+	model, _ := splitCacheKey(keyName)
+	model = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(model, "model-"), "app-"), "-")
+	value := viper.GetString(flagCacheTTL + "." + model)
+	if len(value) == 0 {
+		return defaultCacheTTL
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("Invalid %s.%s in config: %s (using %s)", flagCacheTTL, model, value, defaultCacheTTL))
+		return defaultCacheTTL
+	}
+	return ttl
+}
+
+// getCache will get a cached model (nil if not found, or older than --max-age), the hit is kept in the report of the context
+func getCache(ctx context.Context, keyName string) (*database.Item, error) {
+	start := time.Now()
+	item, err := database.GetItem(keyName)
+	if err != nil || item == nil || len(item.Value) == 0 {
+		return nil, err
+	}
+
+	// Entries without a fetched time (older versions) are treated as stale if --max-age is set
+	if maxAge > 0 && (item.StoredAt.IsZero() || time.Since(item.StoredAt) > maxAge) {
+		return nil, nil
+	}
+
+	reportFromContext(ctx).addCacheHit(newCacheEntry(&database.Item{
+		ExpiresAt: item.ExpiresAt,
+		Key:       item.Key,
+		Size:      item.Size,
+		StoredAt:  item.StoredAt,
+	}))
+	timeStep("cache "+keyName, start, true, nil)
+	return item, nil
+}
+
+// setCache will store a model using the TTL for the model type
+func setCache(ctx context.Context, keyName, value string) error {
+	return database.Set(keyName, value, cacheTTL(ctx, keyName))
+}
+
+// fromCache returns the log suffix for a cached model (with the age if known)
+func fromCache(item *database.Item) string {
+	if item.StoredAt.IsZero() {
+		return "(from cache)"
+	}
+	return fmt.Sprintf("(from cache, fetched %s ago)", time.Since(item.StoredAt).Round(time.Second))
 }
 
 // getPki will get a pki response (logging and basic error handling)
-func getPki(pkiURL, alias, domain string, allowCache bool) (pki *paymail.PKIResponse, err error) {
+func getPki(ctx context.Context, pkiURL, alias, domain string, allowCache bool) (pki *paymail.PKIResponse, err error) {
 	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Retrieving public key information for %s...", color.CyanString(alias+"@"+domain)))
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Retrieving public key information for %s...", color.CyanString(alias+"@"+domain)))
 
 	// Cache key
 	keyName := "model-pki-" + alias + "@" + domain
 
 	// Do we have cache and db?
 	if !disableCache && databaseEnabled && allowCache {
-		var jsonStr string
-		if jsonStr, err = database.Get(keyName); err != nil {
+		var item *database.Item
+		if item, err = getCache(ctx, keyName); err != nil {
 			return pki, err
 		}
-		if len(jsonStr) > 0 {
-			if err = json.Unmarshal([]byte(jsonStr), &pki); err != nil {
+		if item != nil {
+			if err = json.Unmarshal([]byte(item.Value), &pki); err != nil {
 				return pki, err
 			}
-			chalker.Log(chalker.SUCCESS, fmt.Sprintf("Found pubkey %s... (from cache)", pki.PubKey[:10]))
+			logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Found pubkey %s... %s", pki.PubKey[:10], fromCache(item)))
 			return pki, err
 		}
 	}
 
 	// New Client
 	var client paymail.ClientInterface
-	if client, err = newPaymailClient(!skipTracing, nameServer); err != nil {
+	if client, err = newPaymailClient(har.WithName(ctx, "pki"), !skipTracing, nameServer); err != nil {
 		return pki, err
 	}
 
 	// Get the PKI for the given address
 	if pki, err = client.GetPKI(pkiURL, alias, domain); err != nil {
-		return pki, err
+		if pki != nil {
+			err = notFoundError(pki.StatusCode, err)
+		}
+		return pki, requestError(ctx, "pki", err)
 	}
 
 	// Display the tracing results
 	if !skipTracing {
-		displayTracingResults(pki.Tracing, pki.StatusCode)
+		displayTracingResults(ctx, "pki", alias+"@"+domain, pki.Tracing, pki.StatusCode)
 	}
 
 	// Success
-	chalker.Log(chalker.SUCCESS, fmt.Sprintf("Found pubkey %s...", pki.PubKey[:10]))
+	logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Found pubkey %s...", pki.PubKey[:10]))
 
 	// Store in db?
 	if databaseEnabled {
@@ -83,7 +208,7 @@ func getPki(pkiURL, alias, domain string, allowCache bool) (pki *paymail.PKIResp
 		if jsonStr, err = json.Marshal(pki); err != nil {
 			return pki, err
 		}
-		if err = database.Set(keyName, string(jsonStr), 1*time.Hour); err != nil {
+		if err = setCache(ctx, keyName, string(jsonStr)); err != nil {
 			return pki, err
 		}
 	}
@@ -91,122 +216,99 @@ func getPki(pkiURL, alias, domain string, allowCache bool) (pki *paymail.PKIResp
 	return pki, err
 }
 
-// getSrvRecord will return a srv record, and optional validation
-func getSrvRecord(domain string, validate, allowCache bool) (srv *net.SRV, err error) {
-	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Retrieving SRV record for %s...", color.CyanString(domain)))
-
-	// Cache key
-	keyName := "model-srv-" + domain
-
-	// Do we have cache and db?
-	if !disableCache && databaseEnabled && allowCache {
-		var jsonStr string
-		if jsonStr, err = database.Get(keyName); err != nil {
-			return srv, err
+// getCapabilities will check the SRV targets first (failing over in RFC 2782 order), then attempt default domain:port check
+func getCapabilities(ctx context.Context, domain string, allowCache bool) (capabilities *paymail.CapabilitiesResponse, err error) {
+	// Use the custom host (or the targets from the SRV records)
+	var targets []*net.SRV
+	if len(hostOverride) > 0 {
+		var host string
+		var hostPort int
+		if host, hostPort, err = splitHostOverride(hostOverride); err != nil {
+			return capabilities, err
 		}
-		if len(jsonStr) > 0 {
-			if err = json.Unmarshal([]byte(jsonStr), &srv); err != nil {
-				return srv, err
+		targets = []*net.SRV{{Target: host, Port: uint16(hostPort)}}
+	} else {
+		var records []*net.SRV
+		if records, err = getSrvRecords(ctx, domain, allowCache); err != nil {
+			if records, err = srvFallback(ctx, domain, err); err != nil {
+				return capabilities, err
 			}
-			chalker.Log(chalker.SUCCESS, fmt.Sprintf("SRV target: %s:%d --weight %d --priority %d (from cache)", srv.Target, srv.Port, srv.Weight, srv.Priority))
-			return srv, err
-		}
-	}
-
-	// New Client
-	var client paymail.ClientInterface
-	if client, err = newPaymailClient(false, nameServer); err != nil {
-		return srv, err
-	}
-
-	// Get the record
-	if srv, err = client.GetSRVRecord(serviceName, protocol, domain); err != nil {
-		return srv, err
-	}
-
-	// Run validation on the SRV record?
-	if validate {
-		if srv == nil {
-			err = fmt.Errorf("missing SRV record for: %s", domain)
-			return srv, err
 		}
-
-		// Validate the SRV record for the domain name (using all flags or default values)
-		if err = client.ValidateSRVRecord(
-			context.Background(), srv, port, priority, weight,
-		); err != nil {
-			err = fmt.Errorf("validation error: %w", err)
-			return srv, err
-		}
-
-		// Validation good
-		chalker.Log(chalker.SUCCESS, "SRV record passed all validations (target, port, priority, weight)")
-	}
-
-	if srv != nil {
-		chalker.Log(chalker.SUCCESS, fmt.Sprintf("SRV target: %s:%d --weight %d --priority %d", srv.Target, srv.Port, srv.Weight, srv.Priority))
-
-		// Store in db?
-		if databaseEnabled {
-			var jsonStr []byte
-			if jsonStr, err = json.Marshal(srv); err != nil {
-				return srv, err
-			}
-			if err = database.Set(keyName, string(jsonStr), 1*time.Hour); err != nil {
-				return srv, err
-			}
+		if targets = selectSrvTargets(records); len(targets) > 1 {
+			logWithContext(ctx, chalker.DIM, fmt.Sprintf("Target order: %s", srvTargetsString(targets)))
 		}
 	}
 
-	return srv, err
+	return getFailoverCapabilities(ctx, domain, targets, allowCache)
 }
 
-// getCapabilities will check SRV first, then attempt default domain:port check (logging and basic error handling)
-func getCapabilities(domain string, allowCache bool) (capabilities *paymail.CapabilitiesResponse, err error) {
-	capabilityDomain := ""
-	capabilityPort := paymail.DefaultPort
+// srvFallback returns the paymail default (domain:443) to use when the SRV lookup failed
+//
+// There is no fallback if the command was stopped, or if the domain publishes a "." target (the service is not available)
+func srvFallback(ctx context.Context, domain string, err error) ([]*net.SRV, error) {
+	if ctx.Err() != nil {
+		return nil, err
+	} else if errors.Is(err, ErrSRVUnavailable) {
+		return nil, fmt.Errorf("capabilities for %s: %w", domain, err)
+	}
+	logWithContext(ctx, chalker.ERROR, fmt.Sprintf("retrieving SRV record failed: %s", err.Error()))
+	return []*net.SRV{{Target: domain, Port: uint16(paymail.DefaultPort)}}, nil
+}
 
-	// Get the details from the SRV record
-	var srv *net.SRV
-	if srv, err = getSrvRecord(domain, false, allowCache); err != nil {
-		chalker.Log(chalker.ERROR, fmt.Sprintf("retrieving SRV record failed: %s", err.Error()))
-		capabilityDomain = domain
-	} else if srv != nil {
-		capabilityDomain = srv.Target
-		capabilityPort = int(srv.Port)
+// getFailoverCapabilities will try each target (in order) until the capabilities are found
+func getFailoverCapabilities(ctx context.Context, domain string, targets []*net.SRV, allowCache bool) (capabilities *paymail.CapabilitiesResponse, err error) {
+	for index, target := range targets {
+		if capabilities, err = getTargetCapabilities(ctx, domain, target.Target, int(target.Port), allowCache); err == nil || ctx.Err() != nil {
+			return capabilities, err
+		} else if index < len(targets)-1 {
+			next := targets[index+1]
+			logWithContext(ctx, chalker.WARN, fmt.Sprintf("Capability discovery failed on %s:%d (%s), failing over to %s:%d...",
+				target.Target, target.Port, err.Error(), next.Target, next.Port))
+		}
 	}
+	return capabilities, err
+}
 
+// getTargetCapabilities will get the capabilities for the domain from one target (host:port)
+func getTargetCapabilities(ctx context.Context, domain, capabilityDomain string, capabilityPort int,
+	allowCache bool,
+) (capabilities *paymail.CapabilitiesResponse, err error) {
 	// Get the capabilities for the given target domain
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Retrieving available capabilities for %s...", color.CyanString(fmt.Sprintf("%s:%d", capabilityDomain, capabilityPort))))
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Retrieving available capabilities for %s...", color.CyanString(fmt.Sprintf("%s:%d", capabilityDomain, capabilityPort))))
 
 	// Cache key
 	keyName := "model-capabilities-" + domain
+	if len(hostOverride) > 0 {
+		keyName += "-" + hostOverride
+	}
 
 	// Do we have cache and db?
 	if !disableCache && databaseEnabled && allowCache {
-		var jsonStr string
-		if jsonStr, err = database.Get(keyName); err != nil {
+		var item *database.Item
+		if item, err = getCache(ctx, keyName); err != nil {
 			return capabilities, err
 		}
-		if len(jsonStr) > 0 {
-			if err = json.Unmarshal([]byte(jsonStr), &capabilities); err != nil {
+		if item != nil {
+			if err = json.Unmarshal([]byte(item.Value), &capabilities); err != nil {
 				return capabilities, err
 			}
-			chalker.Log(chalker.SUCCESS, fmt.Sprintf("Found [%d] capabilities (from cache)", len(capabilities.Capabilities)))
+			logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Found [%d] capabilities %s", len(capabilities.Capabilities), fromCache(item)))
 			return capabilities, err
 		}
 	}
 
 	// New Client
 	var client paymail.ClientInterface
-	if client, err = newPaymailClient(!skipTracing, nameServer); err != nil {
+	if client, err = newPaymailClient(har.WithName(ctx, "capabilities"), !skipTracing, nameServer); err != nil {
 		return capabilities, err
 	}
 
 	// Look up the capabilities
 	if capabilities, err = client.GetCapabilities(capabilityDomain, capabilityPort); err != nil {
-		return capabilities, err
+		if capabilities != nil {
+			err = notFoundError(capabilities.StatusCode, err)
+		}
+		return capabilities, requestError(ctx, "capabilities", err)
 	}
 
 	// Check the version
@@ -217,11 +319,11 @@ func getCapabilities(domain string, allowCache bool) (capabilities *paymail.Capa
 
 	// Display the tracing results
 	if !skipTracing {
-		displayTracingResults(capabilities.Tracing, capabilities.StatusCode)
+		displayTracingResults(ctx, "capabilities", fmt.Sprintf("%s:%d", capabilityDomain, capabilityPort), capabilities.Tracing, capabilities.StatusCode)
 	}
 
 	// Success
-	chalker.Log(chalker.SUCCESS, fmt.Sprintf("Found [%d] capabilities", len(capabilities.Capabilities)))
+	logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Found [%d] capabilities", len(capabilities.Capabilities)))
 
 	// Store in db?
 	if databaseEnabled {
@@ -229,7 +331,7 @@ func getCapabilities(domain string, allowCache bool) (capabilities *paymail.Capa
 		if jsonStr, err = json.Marshal(capabilities); err != nil {
 			return capabilities, err
 		}
-		if err = database.Set(keyName, string(jsonStr), 1*time.Hour); err != nil {
+		if err = setCache(ctx, keyName, string(jsonStr)); err != nil {
 			return capabilities, err
 		}
 	}
@@ -238,15 +340,15 @@ func getCapabilities(domain string, allowCache bool) (capabilities *paymail.Capa
 }
 
 // resolveAddress will resolve an address (logging and basic error handling)
-func resolveAddress(resolveURL, alias, domain, senderHandle,
-	signature, purpose string, amount uint64,
+func resolveAddress(ctx context.Context, resolveURL, alias, domain string,
+	senderRequest *paymail.SenderRequest,
 ) (response *paymail.ResolutionResponse, err error) {
 	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Resolving address for %s...", color.CyanString(alias+"@"+domain)))
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Resolving address for %s...", color.CyanString(alias+"@"+domain)))
 
 	// New Client
 	var client paymail.ClientInterface
-	if client, err = newPaymailClient(!skipTracing, nameServer); err != nil {
+	if client, err = newPaymailClient(har.WithName(ctx, "resolve-address"), !skipTracing, nameServer); err != nil {
 		return response, err
 	}
 
@@ -255,39 +357,32 @@ func resolveAddress(resolveURL, alias, domain, senderHandle,
 		resolveURL,
 		alias,
 		domain,
-		&paymail.SenderRequest{
-			Amount:       amount,
-			Dt:           time.Now().UTC().Format(time.RFC3339), // UTC is assumed
-			Purpose:      purpose,
-			SenderHandle: senderHandle,
-			SenderName:   viper.GetString(flagSenderName),
-			Signature:    signature,
-		},
+		senderRequest,
 	); err != nil {
-		return response, err
+		return response, requestError(ctx, "resolve-address", notFoundError(0, err))
 	}
 
 	// Display the tracing results
 	if !skipTracing {
-		displayTracingResults(response.Tracing, response.StatusCode)
+		displayTracingResults(ctx, "resolve-address", alias+"@"+domain, response.Tracing, response.StatusCode)
 	}
 
 	// Success
-	chalker.Log(chalker.SUCCESS, fmt.Sprintf("Found address %s...", response.Address[:10]))
+	logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Found address %s...", response.Address[:10]))
 
 	return response, err
 }
 
 // getP2PPaymentDestination will start a new p2p transaction request (logging and basic error handling)
-func getP2PPaymentDestination(destinationURL, alias,
+func getP2PPaymentDestination(ctx context.Context, destinationURL, alias,
 	domain string, satoshis uint64,
 ) (response *paymail.PaymentDestinationResponse, err error) {
 	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Starting new P2P payment request for %s...", color.CyanString(alias+"@"+domain)))
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Starting new P2P payment request for %s...", color.CyanString(alias+"@"+domain)))
 
 	// New Client
 	var client paymail.ClientInterface
-	if client, err = newPaymailClient(!skipTracing, nameServer); err != nil {
+	if client, err = newPaymailClient(har.WithName(ctx, "p2p-destination"), !skipTracing, nameServer); err != nil {
 		return response, err
 	}
 
@@ -298,219 +393,93 @@ func getP2PPaymentDestination(destinationURL, alias,
 		domain,
 		&paymail.PaymentRequest{Satoshis: satoshis},
 	); err != nil {
-		return response, err
+		return response, requestError(ctx, "p2p-destination", err)
 	}
 
 	// Display the tracing results
 	if !skipTracing {
-		displayTracingResults(response.Tracing, response.StatusCode)
+		displayTracingResults(ctx, "p2p-destination", alias+"@"+domain, response.Tracing, response.StatusCode)
 	}
 
 	// Success
-	chalker.Log(chalker.SUCCESS, fmt.Sprintf("Found [%d] payment output(s)", len(response.Outputs)))
+	logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Found [%d] payment output(s)", len(response.Outputs)))
 
 	return response, err
 }
 
-// getPublicProfile will get a public profile (logging and basic error handling)
-func getPublicProfile(profileURL, alias,
-	domain string, allowCache bool,
-) (profile *paymail.PublicProfileResponse, err error) {
+// sendP2PTransaction will submit a transaction to the receive transaction capability (logging and basic error handling)
+func sendP2PTransaction(ctx context.Context, p2pURL, alias, domain string,
+	transaction *paymail.P2PTransaction,
+) (response *paymail.P2PTransactionResponse, err error) {
 	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Retrieving public profile for %s...", color.CyanString(alias+"@"+domain)))
-
-	// Cache key
-	keyName := "model-public-profile-" + alias + "@" + domain
-
-	// Do we have cache and db?
-	if !disableCache && databaseEnabled && allowCache {
-		var jsonStr string
-		if jsonStr, err = database.Get(keyName); err != nil {
-			return profile, err
-		}
-		if len(jsonStr) > 0 {
-			if err = json.Unmarshal([]byte(jsonStr), &profile); err != nil {
-				return profile, err
-			}
-			chalker.Log(chalker.SUCCESS, "Valid profile found [name, avatar] (from cache)")
-			return profile, err
-		}
-	}
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Sending P2P transaction to %s...", color.CyanString(alias+"@"+domain)))
 
 	// New Client
 	var client paymail.ClientInterface
-	if client, err = newPaymailClient(!skipTracing, nameServer); err != nil {
-		return profile, err
+	if client, err = newPaymailClient(har.WithName(ctx, "p2p-transaction"), !skipTracing, nameServer); err != nil {
+		return response, err
 	}
 
-	// Get the profile
-	if profile, err = client.GetPublicProfile(profileURL, alias, domain); err != nil {
-		return profile, err
+	// Submit the transaction
+	if response, err = client.SendP2PTransaction(p2pURL, alias, domain, transaction); err != nil {
+		return response, requestError(ctx, "p2p-transaction", err)
 	}
 
 	// Display the tracing results
 	if !skipTracing {
-		displayTracingResults(profile.Tracing, profile.StatusCode)
+		displayTracingResults(ctx, "p2p-transaction", alias+"@"+domain, response.Tracing, response.StatusCode)
 	}
 
 	// Success
-	if len(profile.Name) > 0 {
-		chalker.Log(chalker.SUCCESS, "Valid profile found [name, avatar]")
-
-		// Store in db?
-		if databaseEnabled {
-			var jsonStr []byte
-			if jsonStr, err = json.Marshal(profile); err != nil {
-				return profile, err
-			}
-			if err = database.Set(keyName, string(jsonStr), 1*time.Hour); err != nil {
-				return profile, err
-			}
-		}
-	}
+	logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Transaction accepted: %s", response.TxID))
 
-	return profile, err
-}
-
-// getBitPic will get a bitpic if the pic exists
-func getBitPic(alias, domain string, allowCache bool) (url string, err error) {
-	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Checking %s for a Bitpic...", color.CyanString(alias+"@"+domain)))
-
-	// Cache key
-	keyName := "app-bitpic-" + alias + "@" + domain
-
-	// Do we have caching and db?
-	if !disableCache && databaseEnabled && allowCache {
-		if url, err = database.Get(keyName); err != nil {
-			return url, err
-		}
-		if len(url) > 0 {
-			chalker.Log(chalker.SUCCESS, "Bitpic was found for "+alias+"@"+domain+" (from cache)")
-			return url, err
-		}
-	}
-
-	// Does this paymail have a bitpic profile?
-	var resp *bitpic.Response
-	if resp, err = bitpic.GetPic(alias, domain, !skipTracing); err != nil {
-		return url, err
-	}
-
-	// Display the tracing results
-	if !skipTracing {
-		displayTracingResults(resp.Tracing, resp.StatusCode)
-	}
-
-	// Checks if the response was good
-	if resp != nil && resp.Found {
-		url = resp.URL
-		chalker.Log(chalker.SUCCESS, "Bitpic was found for "+alias+"@"+domain)
-
-		// Store in db?
-		if databaseEnabled {
-			if err = database.Set(keyName, url, 1*time.Hour); err != nil {
-				return url, err
-			}
-		}
-	} else {
-		chalker.Log(chalker.DEFAULT, "Bitpic was not found")
-	}
-
-	return url, err
+	return response, err
 }
 
-// getBitPics will search for all bitpics by an alias
-func getBitPics(alias, domain string, allowCache bool) (searchResult *bitpic.SearchResponse, err error) {
+// getPublicProfile will get a public profile (logging and basic error handling)
+func getPublicProfile(ctx context.Context, profileURL, alias,
+	domain string, allowCache bool,
+) (profile *paymail.PublicProfileResponse, err error) {
 	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Searching Bitpic for %s@%s...", color.CyanString(alias), color.CyanString(domain)))
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Retrieving public profile for %s...", color.CyanString(alias+"@"+domain)))
 
 	// Cache key
-	keyName := "app-bitpic-search-" + alias + "@" + domain
+	keyName := "model-public-profile-" + alias + "@" + domain
 
 	// Do we have cache and db?
 	if !disableCache && databaseEnabled && allowCache {
-		var jsonStr string
-		if jsonStr, err = database.Get(keyName); err != nil {
-			return searchResult, err
-		}
-		if len(jsonStr) > 0 {
-			if err = json.Unmarshal([]byte(jsonStr), &searchResult); err != nil {
-				return searchResult, err
-			}
-			chalker.Log(chalker.SUCCESS, fmt.Sprintf("Found %d possible matches (from cache)", len(searchResult.Result.Posts)))
-			return searchResult, err
-		}
-	}
-
-	// Search
-	if searchResult, err = bitpic.Search(alias, domain, true); err != nil {
-		return searchResult, err
-	}
-
-	// Display the tracing results
-	if !skipTracing {
-		displayTracingResults(searchResult.Tracing, searchResult.StatusCode)
-	}
-
-	// Got results
-	if searchResult != nil && searchResult.Result != nil && len(searchResult.Result.Posts) > 0 {
-
-		chalker.Log(chalker.SUCCESS, fmt.Sprintf("Found %d possible matches", len(searchResult.Result.Posts)))
-
-		// Store in db?
-		if databaseEnabled {
-			var jsonStr []byte
-			if jsonStr, err = json.Marshal(searchResult); err != nil {
-				return searchResult, err
-			}
-			if err = database.Set(keyName, string(jsonStr), 1*time.Hour); err != nil {
-				return searchResult, err
-			}
-		}
-	} else {
-		chalker.Log(chalker.DEFAULT, "No bitpics were found")
-	}
-
-	return searchResult, err
-}
-
-// getRoundeskProfile will get a Roundesk profile if it exists
-func getRoundeskProfile(alias, domain string, allowCache bool) (profile *roundesk.Response, err error) {
-	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Checking %s for a Roundesk profile...", color.CyanString(alias+"@"+domain)))
-
-	// Cache key
-	keyName := "app-roundesk-" + alias + "@" + domain
-
-	// Do we have caching and db?
-	if !disableCache && databaseEnabled && allowCache {
-		var jsonStr string
-		if jsonStr, err = database.Get(keyName); err != nil {
+		var item *database.Item
+		if item, err = getCache(ctx, keyName); err != nil {
 			return profile, err
 		}
-		if len(jsonStr) > 0 {
-			if err = json.Unmarshal([]byte(jsonStr), &profile); err != nil {
+		if item != nil {
+			if err = json.Unmarshal([]byte(item.Value), &profile); err != nil {
 				return profile, err
 			}
-			chalker.Log(chalker.SUCCESS, "Roundesk profile was found (from cache)")
+			logWithContext(ctx, chalker.SUCCESS, "Valid profile found [name, avatar] "+fromCache(item))
 			return profile, err
 		}
 	}
 
-	// Find a roundesk profile
-	if profile, err = roundesk.GetProfile(alias, domain, !skipTracing); err != nil {
+	// New Client
+	var client paymail.ClientInterface
+	if client, err = newPaymailClient(har.WithName(ctx, "public-profile"), !skipTracing, nameServer); err != nil {
 		return profile, err
 	}
 
+	// Get the profile
+	if profile, err = client.GetPublicProfile(profileURL, alias, domain); err != nil {
+		return profile, requestError(ctx, "public-profile", err)
+	}
+
 	// Display the tracing results
 	if !skipTracing {
-		displayTracingResults(profile.Tracing, profile.StatusCode)
+		displayTracingResults(ctx, "public-profile", alias+"@"+domain, profile.Tracing, profile.StatusCode)
 	}
 
-	// Success or failure
-	if profile != nil && profile.Profile != nil && len(profile.Profile.Paymail) > 0 {
-		chalker.Log(chalker.SUCCESS, "Roundesk profile was found")
+	// Success
+	if len(profile.Name) > 0 {
+		logWithContext(ctx, chalker.SUCCESS, "Valid profile found [name, avatar]")
 
 		// Store in db?
 		if databaseEnabled {
@@ -518,163 +487,106 @@ func getRoundeskProfile(alias, domain string, allowCache bool) (profile *roundes
 			if jsonStr, err = json.Marshal(profile); err != nil {
 				return profile, err
 			}
-			if err = database.Set(keyName, string(jsonStr), 1*time.Hour); err != nil {
+			if err = setCache(ctx, keyName, string(jsonStr)); err != nil {
 				return profile, err
 			}
 		}
-
-	} else {
-		chalker.Log(chalker.DEFAULT, "Roundesk profile was not found")
 	}
 
 	return profile, err
 }
 
-// getPowPingProfile will get a PowPing profile if it exists
-func getPowPingProfile(alias, domain string, allowCache bool) (profile *powping.Response, err error) {
+// verifyPubKey will verify a given pubkey against a paymail address (logging and basic error handling)
+func verifyPubKey(ctx context.Context, verifyURL, alias, domain, pubKey string) (response *paymail.VerificationResponse, err error) {
 	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Checking %s for a PowPing account...", color.CyanString(alias+"@"+domain)))
-
-	// Cache key
-	keyName := "app-powping-" + alias + "@" + domain
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Verifing pubkey for %s...", color.CyanString(alias+"@"+domain)))
 
-	// Do we have caching and db?
-	if !disableCache && databaseEnabled && allowCache {
-		var jsonStr string
-		if jsonStr, err = database.Get(keyName); err != nil {
-			return profile, err
-		}
-		if len(jsonStr) > 0 {
-			if err = json.Unmarshal([]byte(jsonStr), &profile); err != nil {
-				return profile, err
-			}
-			chalker.Log(chalker.SUCCESS, "PowPing account was found (from cache)")
-			return profile, err
-		}
+	// New Client
+	var client paymail.ClientInterface
+	if client, err = newPaymailClient(har.WithName(ctx, "verify-pubkey"), !skipTracing, nameServer); err != nil {
+		return response, err
 	}
 
-	// Find a powping profile
-	if profile, err = powping.GetProfile(alias, domain, !skipTracing); err != nil {
-		return profile, err
+	// Verify the given pubkey
+	if response, err = client.VerifyPubKey(verifyURL, alias, domain, pubKey); err != nil {
+		return response, requestError(ctx, "verify-pubkey", err)
 	}
 
 	// Display the tracing results
 	if !skipTracing {
-		displayTracingResults(profile.Tracing, profile.StatusCode)
-	}
-
-	// Success or failure
-	if profile != nil && profile.Profile != nil && len(profile.Profile.Username) > 0 {
-		chalker.Log(chalker.SUCCESS, "PowPing account was found")
-
-		// Store in db?
-		if databaseEnabled {
-			var jsonStr []byte
-			if jsonStr, err = json.Marshal(profile); err != nil {
-				return profile, err
-			}
-			if err = database.Set(keyName, string(jsonStr), 1*time.Hour); err != nil {
-				return profile, err
-			}
-		}
-
-	} else {
-		chalker.Log(chalker.DEFAULT, "PowPing profile was not found")
+		displayTracingResults(ctx, "verify-pubkey", alias+"@"+domain, response.Tracing, response.StatusCode)
 	}
 
-	return profile, err
+	return response, err
 }
 
-// getBaemail will check to see if a Baemail account exists for a given paymail
-func getBaemail(alias, domain string, allowCache bool) (response *baemail.Response, err error) {
-	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Checking %s for a Baemail account...", color.CyanString(alias+"@"+domain)))
-
-	// Cache key
-	keyName := "app-baemail-" + alias + "@" + domain
-
-	// Do we have caching and db?
-	if !disableCache && databaseEnabled && allowCache {
-		var jsonStr string
-		if jsonStr, err = database.Get(keyName); err != nil {
-			return response, err
-		}
-		if len(jsonStr) > 0 {
-			if err = json.Unmarshal([]byte(jsonStr), &response); err != nil {
-				return response, err
-			}
-			chalker.Log(chalker.SUCCESS, "Baemail account was found (from cache)")
-			return response, err
+// loadSenderKey will load the sender's private key (WIF or hex)
+func loadSenderKey(key string) (*ec.PrivateKey, error) {
+	key = strings.TrimSpace(key)
+	if len(key) == privateKeyHexLength {
+		if _, err := hex.DecodeString(key); err == nil {
+			return ec.PrivateKeyFromHex(key)
 		}
 	}
+	return ec.PrivateKeyFromWif(key)
+}
 
-	// Does this paymail have a Baemail account
-	if response, err = baemail.HasProfile(alias, domain, !skipTracing); err != nil {
-		return response, err
-	}
-
-	// Display the tracing results
-	if !skipTracing {
-		displayTracingResults(response.Tracing, response.StatusCode)
+// signSenderRequest will sign the sender request (bsvalias sender validation) using the private key
+// Specs: https://bsvalias.org/04-02-sender-validation.html
+func signSenderRequest(senderRequest *paymail.SenderRequest, privateKey *ec.PrivateKey) error {
+	signature, err := senderRequest.Sign(privateKey.Hex())
+	if err != nil {
+		return err
 	}
+	senderRequest.Signature = paymail.EncodeSignature(signature)
+	return nil
+}
 
-	// Success or failure
-	if response != nil && len(response.ComposeURL) > 0 {
-		chalker.Log(chalker.SUCCESS, "Baemail account was found")
-
-		// Store in db?
-		if databaseEnabled {
-			var jsonStr []byte
-			if jsonStr, err = json.Marshal(response); err != nil {
-				return response, err
-			}
-			if err = database.Set(keyName, string(jsonStr), 1*time.Hour); err != nil {
-				return response, err
-			}
-		}
-
-	} else {
-		chalker.Log(chalker.DEFAULT, "Baemail account was not found")
+// flagOrConfig returns the flag value if set, otherwise the value from the config
+func flagOrConfig(value, key string) string {
+	if len(value) > 0 {
+		return value
 	}
-
-	return response, err
+	return viper.GetString(key)
 }
 
-// verifyPubKey will verify a given pubkey against a paymail address (logging and basic error handling)
-func verifyPubKey(verifyURL, alias, domain, pubKey string) (response *paymail.VerificationResponse, err error) {
-	// Start the request
-	displayHeader(chalker.DEFAULT, fmt.Sprintf("Verifing pubkey for %s...", color.CyanString(alias+"@"+domain)))
+// logError will log the message as an error and return it (used by flows that also run in batch mode)
+func logError(ctx context.Context, message string) error {
+	logWithContext(ctx, chalker.ERROR, message)
+	return errors.New(message)
+}
 
-	// New Client
-	var client paymail.ClientInterface
-	if client, err = newPaymailClient(!skipTracing, nameServer); err != nil {
-		return response, err
-	}
+// logFailure will log the failed step as an error and return it (the original error is wrapped, IE: a TimeoutError)
+func logFailure(ctx context.Context, step string, err error) error {
+	logWithContext(ctx, chalker.ERROR, step+": "+err.Error())
+	return fmt.Errorf("%s: %w", step, err)
+}
 
-	// Verify the given pubkey
-	if response, err = client.VerifyPubKey(verifyURL, alias, domain, pubKey); err != nil {
-		return response, err
-	}
+// inputError will log the message as an error and return it as an ErrInvalidInput (IE: a malformed domain)
+func inputError(ctx context.Context, message string) error {
+	logWithContext(ctx, chalker.ERROR, message)
+	return &KindError{Err: errors.New(message), Kind: ErrInvalidInput}
+}
 
-	// Display the tracing results
-	if !skipTracing {
-		displayTracingResults(response.Tracing, response.StatusCode)
+// notFoundError will mark the error of a request as ErrNotFound if the provider answered with a 404
+func notFoundError(statusCode int, err error) error {
+	if err != nil && (statusCode == http.StatusNotFound || errors.Is(err, paymail.ErrResolveAddressNotFound)) {
+		return &KindError{Err: err, Kind: ErrNotFound}
 	}
-
-	return response, err
+	return err
 }
 
 // validatePaymailAndDomain will do a basic validation on the paymail format
-func validatePaymailAndDomain(paymailAddress, domain string) (valid bool) {
+func validatePaymailAndDomain(ctx context.Context, paymailAddress, domain string) (valid bool) {
 	// Validate the format for the paymail address (paymail addresses follow conventional email requirements)
 	if err := paymail.ValidatePaymail(paymailAddress); err != nil {
-		chalker.Log(chalker.ERROR, fmt.Sprintf("Paymail address failed format validation: %s", err.Error()))
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Paymail address failed format validation: %s", err.Error()))
 		return valid
 	}
 
 	// Check for a real domain (require at least one period)
 	if err := paymail.ValidateDomain(domain); err != nil {
-		chalker.Log(chalker.ERROR, fmt.Sprintf("Domain name %s is invalid: %s", domain, err.Error()))
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Domain name %s is invalid: %s", domain, err.Error()))
 		return valid
 	}
 
@@ -682,8 +594,11 @@ func validatePaymailAndDomain(paymailAddress, domain string) (valid bool) {
 	return valid
 }
 
-// displayTracingResults displays the tracing results into the terminal per request
-func displayTracingResults(tracing resty.TraceInfo, statusCode int) {
+// displayTracingResults displays the tracing results into the terminal per request (and adds them to the report of the context)
+func displayTracingResults(ctx context.Context, request, target string, tracing resty.TraceInfo, statusCode int) {
+	// Keep the trace for the structured output
+	reportFromContext(ctx).addTrace(request, target, tracing, statusCode)
+
 	// Add the network time columns
 	output := []string{
 		fmt.Sprintf(`DNSLookup | %s | TTFB | %s`, tracing.DNSLookup.String(), tracing.ServerTime.String()),
@@ -706,16 +621,24 @@ func displayTracingResults(tracing resty.TraceInfo, statusCode int) {
 	}
 
 	// Render the data
-	chalker.Log(chalker.DIM, columnize.SimpleFormat(output))
+	logWithContext(ctx, chalker.DIM, columnize.SimpleFormat(output))
 }
 
+// headerPrefix is the start of a standard header
+const headerPrefix = "\n==========| "
+
 // displayHeader will display a standard header
 func displayHeader(level, text string) {
-	chalker.Log(level, "\n==========| "+text)
+	chalker.Log(level, headerPrefix+text)
+}
+
+// displayRequestHeader will display a standard header within a request flow (kept in the log buffer of the context, if any)
+func displayRequestHeader(ctx context.Context, level, text string) {
+	logWithContext(ctx, level, headerPrefix+text)
 }
 
 // GetPublicInfo will get all the public info for a given paymail
-func (p *PaymailDetails) GetPublicInfo(capabilities *paymail.CapabilitiesResponse) (err error) {
+func (p *PaymailDetails) GetPublicInfo(ctx context.Context, capabilities *paymail.CapabilitiesResponse) (err error) {
 	// Requirements
 	if len(p.Handle) == 0 {
 		err = fmt.Errorf("missing required field: %s", "Handle")
@@ -730,46 +653,31 @@ func (p *PaymailDetails) GetPublicInfo(capabilities *paymail.CapabilitiesRespons
 	// Attempt to get a public profile if the capability is found
 	publicURL := capabilities.GetString(paymail.BRFCPublicProfile, "")
 	if len(publicURL) > 0 && !skipPublicProfile && p.PKI != nil && len(p.PKI.Handle) > 0 {
-		if p.PublicProfile, err = getPublicProfile(publicURL, p.Handle, p.Provider.Domain, true); err != nil {
+		if p.PublicProfile, err = getPublicProfile(ctx, publicURL, p.Handle, p.Provider.Domain, true); err != nil {
 			err = fmt.Errorf("get public profile failed: %w", err)
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
-		}
-	}
-
-	// Attempt to get a bitpic (if enabled)
-	if !skipBitpic && p.PKI != nil && len(p.PKI.Handle) > 0 {
-		// todo: make this one request to get all bitpics
-		if p.Bitpic, err = getBitPic(p.Handle, p.Provider.Domain, true); err != nil {
-			err = fmt.Errorf("checking for bitpic failed: %w", err)
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
-		}
-		if p.Bitpics, err = getBitPics(p.Handle, p.Provider.Domain, true); err != nil {
-			err = fmt.Errorf("searching for bitpic failed: %w", err)
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
 		}
 	}
 
-	// Attempt to check for a Baemail profile (if enabled)
-	if !skipBaemail && p.PKI != nil && len(p.PKI.Handle) > 0 {
-		if p.Baemail, err = getBaemail(p.Handle, p.Provider.Domain, true); err != nil {
-			err = fmt.Errorf("checking for baemail account failed: %w", err)
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
-		}
-	}
-
-	// Attempt to get a Roundesk profile (if enabled)
-	if !skipRoundesk && p.PKI != nil && len(p.PKI.Handle) > 0 {
-		if p.Roundesk, err = getRoundeskProfile(p.Handle, p.Provider.Domain, true); err != nil {
-			err = fmt.Errorf("checking for roundesk profile failed: %w", err)
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
-		}
-	}
-
-	// Attempt to get a PowPing profile (if enabled)
-	if !skipPowPing && p.PKI != nil && len(p.PKI.Handle) > 0 {
-		if p.PowPing, err = getPowPingProfile(p.Handle, p.Provider.Domain, true); err != nil {
-			err = fmt.Errorf("checking for powping account failed: %w", err)
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+	// Check all the integrations (if enabled)
+	if p.PKI != nil && len(p.PKI.Handle) > 0 {
+		for _, integration := range integrations.All() {
+			if ctx.Err() != nil {
+				break
+			} else if !integrationEnabled(integration) {
+				continue
+			}
+			var result *integrations.Result
+			if result, err = getIntegration(ctx, integration, p.Handle, p.Provider.Domain, true); err != nil {
+				err = fmt.Errorf("checking %s failed: %w", integration.Name(), err)
+				logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+			}
+			if result != nil {
+				if p.Integrations == nil {
+					p.Integrations = make(map[string]*integrations.Result)
+				}
+				p.Integrations[integration.Name()] = result
+			}
 		}
 	}
 
@@ -793,95 +701,51 @@ func (p *PaymailDetails) Paymail() string {
 }
 
 // Display all the paymail results for a given paymail search/resolution
-func (p *PaymailDetails) Display() {
+func (p *PaymailDetails) Display(ctx context.Context) {
 	displayPaymail := p.Paymail()
 
 	// Rendering profile information
-	displayHeader(chalker.BOLD, fmt.Sprintf("Results for %s", color.CyanString(displayPaymail)))
+	displayRequestHeader(ctx, chalker.BOLD, fmt.Sprintf("Results for %s", color.CyanString(displayPaymail)))
 
 	// No PKI - then we don't have a paymail
 	if p.PKI == nil || len(p.PKI.PubKey) == 0 {
-		chalker.Log(chalker.SUCCESS, fmt.Sprintf("The handle: %s might be available! Reserve it now: %s", p.Handle, p.Provider.Link))
+		logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("The handle: %s might be available! Reserve it now: %s", p.Handle, p.Provider.Link))
 		return
 	}
 
 	// Display the public profile if found
 	if p.PublicProfile != nil {
 		if len(p.PublicProfile.Name) > 0 {
-			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Name         : %s", color.CyanString(p.PublicProfile.Name)))
+			logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Name         : %s", color.CyanString(p.PublicProfile.Name)))
 		}
 		if len(p.PublicProfile.Avatar) > 0 {
-			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Avatar       : %s", color.CyanString(p.PublicProfile.Avatar)))
+			logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Avatar       : %s", color.CyanString(p.PublicProfile.Avatar)))
 		}
 	}
 
-	// Display the baemail compose url if found
-	if p.Baemail != nil && len(p.Baemail.ComposeURL) > 0 {
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Baemail      : %s", color.CyanString(p.Baemail.ComposeURL)))
-	}
-
 	// Display dime.ly if found
 	if len(p.Dimely) > 0 {
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Dime.ly      : %s", color.CyanString(p.Dimely)))
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Dime.ly      : %s", color.CyanString(p.Dimely)))
 	}
 
-	// Display PowPing if found
-	if p.PowPing != nil && p.PowPing.Profile != nil && len(p.PowPing.Profile.Username) > 0 {
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("PowPing      : %s", color.CyanString("https://powping.com/@"+p.PowPing.Profile.Username)))
-	}
-
-	// Do we have possible matches?
-	if p.Bitpics != nil && p.Bitpics.Result != nil && len(p.Bitpics.Result.Posts) > 0 {
-		resultNum := 1
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Bitpic URL   : %s", color.CyanString(bitpic.URLFromPaymail(p.Bitpics.Result.Posts[0].Data.Paymail))))
-		for _, post := range p.Bitpics.Result.Posts {
-			if len(post.Data.Paymail) > 0 {
-				chalker.Log(chalker.DEFAULT, fmt.Sprintf("Bitpic Img #%d: %s", resultNum, color.CyanString(post.Data.BitFs)))
-			}
-			resultNum = resultNum + 1
-		}
-	} else if len(p.Bitpic) > 0 { // todo: maybe deprecate if search works indefinitely
-
-		// Display bitpic if found
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Bitpic       : %s", color.CyanString(p.Bitpic)))
+	// Display the integrations that were found
+	for _, integration := range integrations.All() {
+		displayIntegration(ctx, p.Integrations[integration.Name()])
 	}
 
 	// Show pubkey
 	if p.PKI != nil && len(p.PKI.PubKey) > 0 {
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("PubKey       : %s", color.CyanString(p.PKI.PubKey)))
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("PubKey       : %s", color.CyanString(p.PKI.PubKey)))
 	}
 
 	// Show address resolution details
 	if p.Resolution != nil && len(p.Resolution.Address) > 0 {
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Output Script: %s", color.CyanString(p.Resolution.Output)))
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Address      : %s", color.CyanString(p.Resolution.Address)))
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Output Script: %s", color.CyanString(p.Resolution.Output)))
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Address      : %s", color.CyanString(p.Resolution.Address)))
 
 		// If we have a signature
 		if len(p.Resolution.Signature) > 0 {
-			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Signature    : %s", color.CyanString(p.Resolution.Signature)))
+			logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Signature    : %s", color.CyanString(p.Resolution.Signature)))
 		}
 	}
-
-	// Display the roundesk profile if found
-	if p.Roundesk != nil && p.Roundesk.Profile != nil {
-
-		// Rendering profile information
-		displayHeader(chalker.BOLD, fmt.Sprintf("Roundesk profile for %s", color.CyanString(displayPaymail)))
-
-		if len(p.Roundesk.Profile.Name) > 0 {
-			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Name      : %s", color.CyanString(p.Roundesk.Profile.Name)))
-		}
-		if len(p.Roundesk.Profile.Headline) > 0 {
-			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Headline  : %s", color.CyanString(p.Roundesk.Profile.Headline)))
-		}
-		if len(p.Roundesk.Profile.Bio) > 0 {
-			p.Roundesk.Profile.Bio = strings.TrimSuffix(p.Roundesk.Profile.Bio, "\n")
-			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Bio       : %s", color.CyanString(p.Roundesk.Profile.Bio)))
-		}
-		if len(p.Roundesk.Profile.Twetch) > 0 {
-			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Twetch    : %s", color.CyanString("https://twetch.app/u/"+p.Roundesk.Profile.Twetch)))
-		}
-
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("URL       : %s", color.CyanString("https://roundesk.co/u/"+displayPaymail)))
-	}
 }
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+)
+
+// Keys for local testing only (same as serve-example.yaml)
+const (
+	testKeyAlice   = "L1YwponZeY3juwv9RZ3DEszqQxbnCB1jg7H3Uzmk3RWiqbm8esbi"
+	testKeySatoshi = "L5Z8DRrx5ysuMJe3nZMhBTewrm4Z5egxemskvzKRWeFhkaMxKZRU"
+)
+
+// TestLoadSenderKey will test the method loadSenderKey()
+func TestLoadSenderKey(t *testing.T) {
+	t.Parallel()
+
+	wif, err := loadSenderKey(testKeyAlice)
+	if err != nil {
+		t.Fatalf("%s Failed: [%s] inputted, received an error: %s", t.Name(), testKeyAlice, err.Error())
+	}
+
+	var tests = []struct {
+		key           string
+		expectedError bool
+	}{
+		{testKeyAlice, false},
+		{"  " + testKeyAlice + "\n", false},
+		{wif.Hex(), false},
+		{" " + wif.Hex() + " ", false},
+		{wif.Hex()[:62], true},
+		{"zz" + wif.Hex()[2:], true},
+		{"invalid-key", true},
+		{"", true},
+	}
+
+	for _, test := range tests {
+		output, err := loadSenderKey(test.key)
+		if (err != nil) != test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted and error %v expected, received: [%v]", t.Name(), test.key, test.expectedError, err)
+		} else if err == nil && output.Hex() != wif.Hex() {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.key, wif.Hex(), output.Hex())
+		}
+	}
+}
+
+// TestSignSenderRequest will test the method signSenderRequest()
+func TestSignSenderRequest(t *testing.T) {
+	t.Parallel()
+
+	privateKey, err := loadSenderKey(testKeyAlice)
+	if err != nil {
+		t.Fatalf("%s Failed: received an error loading the key: %s", t.Name(), err.Error())
+	}
+	var address *script.Address
+	if address, err = script.NewAddressFromPublicKey(privateKey.PubKey(), true); err != nil {
+		t.Fatalf("%s Failed: received an error creating the address: %s", t.Name(), err.Error())
+	}
+
+	var tests = []struct {
+		name          string
+		request       *paymail.SenderRequest
+		expectedError bool
+	}{
+		{"full request", &paymail.SenderRequest{
+			Amount: 550, Dt: "2020-04-09T16:08:06.419Z", Purpose: "test", SenderHandle: "alice@paymail.test", SenderName: "Alice",
+		}, false},
+		{"no amount or purpose", &paymail.SenderRequest{Dt: "2020-04-09T16:08:06.419Z", SenderHandle: "alice@paymail.test"}, false},
+		{"missing dt", &paymail.SenderRequest{SenderHandle: "alice@paymail.test"}, true},
+		{"missing handle", &paymail.SenderRequest{Dt: "2020-04-09T16:08:06.419Z"}, true},
+	}
+
+	for _, test := range tests {
+		if err = signSenderRequest(test.request, privateKey); (err != nil) != test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted and error %v expected, received: [%v]", t.Name(), test.name, test.expectedError, err)
+		} else if err != nil {
+			if len(test.request.Signature) > 0 {
+				t.Errorf("%s Failed: [%s] inputted and no signature expected, received: [%s]", t.Name(), test.name, test.request.Signature)
+			}
+		} else if err = test.request.Verify(address.AddressString, test.request.Signature); err != nil {
+			t.Errorf("%s Failed: [%s] inputted and a valid signature expected, received: [%s]", t.Name(), test.name, err.Error())
+		}
+	}
+
+	// A changed request no longer verifies
+	request := &paymail.SenderRequest{Amount: 1, Dt: "2020-04-09T16:08:06.419Z", SenderHandle: "alice@paymail.test"}
+	if err = signSenderRequest(request, privateKey); err != nil {
+		t.Fatalf("%s Failed: received an error signing: %s", t.Name(), err.Error())
+	}
+	request.Amount = 2
+	if err = request.Verify(address.AddressString, request.Signature); err == nil {
+		t.Errorf("%s Failed: an invalid signature expected for a changed amount", t.Name())
+	}
+}
+
+// TestCacheTTL will test the method cacheTTL()
+func TestCacheTTL(t *testing.T) {
+	for model, value := range map[string]string{
+		"bitpic":         "2h",
+		"capabilities":   "30m",
+		"pki":            "10m",
+		"public-profile": "invalid",
+		"srv":            "-5m",
+	} {
+		viper.Set(flagCacheTTL+"."+model, value)
+	}
+	t.Cleanup(func() {
+		viper.Set(flagCacheTTL, nil)
+	})
+
+	var tests = []struct {
+		key      string
+		expected time.Duration
+	}{
+		{"model-pki-mrz@moneybutton.com", 10 * time.Minute},
+		{"model-capabilities-moneybutton.com", 30 * time.Minute},
+		{"app-bitpic-mrz@moneybutton.com", 2 * time.Hour},
+		{"app-powping-mrz@moneybutton.com", defaultCacheTTL},
+		{"model-public-profile-mrz@moneybutton.com", defaultCacheTTL},
+		{"model-srv-moneybutton.com", defaultCacheTTL},
+		{"unknown-key", defaultCacheTTL},
+	}
+
+	for _, test := range tests {
+		if output := cacheTTL(context.Background(), test.key); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.key, test.expected, output)
+		}
+	}
+}
+
+// TestSrvFallback will test the method srvFallback()
+func TestSrvFallback(t *testing.T) {
+	// Not parallel: discards the logs
+
+	previous := color.Output
+	color.Output = io.Discard
+	t.Cleanup(func() {
+		color.Output = previous
+	})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	lookupFailed := errors.New("lookup failed")
+
+	var tests = []struct {
+		name          string
+		ctx           context.Context
+		err           error
+		expected      string
+		expectedError error
+	}{
+		{"lookup failed", context.Background(), lookupFailed, "moneybutton.com:443", nil},
+		{"service not available", context.Background(), ErrSRVUnavailable, "", ErrSRVUnavailable},
+		{"canceled", canceled, lookupFailed, "", lookupFailed},
+	}
+
+	for _, test := range tests {
+		targets, err := srvFallback(test.ctx, "moneybutton.com", test.err)
+		if output := srvTargetsString(targets); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expected, output)
+		}
+		if !errors.Is(err, test.expectedError) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.name, test.expectedError, err)
+		}
+	}
+}
@@ -0,0 +1,524 @@
+package cmd
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/fatih/color"
+	"github.com/mrz1836/go-sanitize"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+)
+
+// Conformance statuses (per BRFC)
+const (
+	conformanceFail = "fail"
+	conformancePass = "pass"
+	conformanceSkip = "skip"
+)
+
+// brfcServiceDiscovery is the BRFC for the capabilities document (not a capability itself)
+const brfcServiceDiscovery = "b2aa66e26b43"
+
+// ConformanceResult is the result of all checks for one BRFC
+type ConformanceResult struct {
+	BRFC    string   `json:"brfc"`
+	Details []string `json:"details"`
+	Status  string   `json:"status"`
+	Title   string   `json:"title"`
+}
+
+// conformanceSuite is the state shared by all checks for one target
+type conformanceSuite struct {
+	address      string                        // alias@domain (empty if only a domain was given)
+	alias        string                        // alias (empty if only a domain was given)
+	capabilities *paymail.CapabilitiesResponse // Capabilities of the domain
+	ctx          context.Context               // Context of the command (CTRL+C or --timeout)
+	domain       string                        // Domain being tested
+	pki          *paymail.PKIResponse          // PKI of the address (used by other checks)
+	results      []*ConformanceResult          // All results (in order)
+	specs        []*paymail.BRFCSpec           // Known BRFC specs (for titles)
+}
+
+// conformanceCmd represents the conformance command
+var conformanceCmd = &cobra.Command{
+	Use:   "conformance",
+	Short: "Run the bsvalias conformance suite against a paymail provider",
+	Long: color.GreenString(`
+                     _____
+  ____  ____   _____/ ____\___________  _____ _____    ____   ____  ____
+_/ ___\/  _ \ /    \   __\/  _ \_  __ \/     \\__  \  /    \_/ ___\/ __ \
+\  \__(  <_> )   |  \  | (  <_> )  | \/  Y Y  \/ __ \|   |  \  \__\  ___/
+ \___  >____/|___|  /__|  \____/|__|  |__|_|  (____  /___|  /\___  >___  >
+     \/           \/                        \/     \/     \/     \/    \/`) + `
+` + color.YellowString(`
+This command will exercise every capability advertised by a paymail provider and report pass, fail or skip per BRFC.
+
+Checks include the PKI shape, address resolution (output script and signature), P2P payment destination outputs,
+verify-pubkey (true and false cases), public profile fields and rejection of bad sender validation signatures.
+
+Alias checks require a paymail address (alias@domain.tld), only the capabilities are checked for a domain.
+
+Read more at: `+color.CyanString("http://bsvalias.org/index.html")),
+	Aliases:    []string{"conform", "cert"},
+	SuggestFor: []string{"certify", "test"},
+	Example: applicationName + " conformance mrz@" + defaultDomainName + `
+` + applicationName + " conformance " + defaultDomainName + `
+` + applicationName + " conformance satoshi@paymail.test --host localhost:3443 --insecure",
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return chalker.Error("conformance requires either a domain or paymail address")
+		} else if len(args) > 1 {
+			return chalker.Error("conformance only supports one domain or address at a time")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		suite := &conformanceSuite{ctx: cmd.Context()}
+
+		// Extract the parts given
+		if strings.Contains(args[0], "@") {
+			suite.alias, suite.domain, suite.address = paymail.SanitizePaymail(args[0])
+			if ok := validatePaymailAndDomain(suite.ctx, suite.address, suite.domain); !ok {
+				return
+			}
+		} else {
+			suite.domain, _ = sanitize.Domain(args[0], false, true)
+			if err := paymail.ValidateDomain(suite.domain); err != nil {
+				chalker.Log(chalker.ERROR, fmt.Sprintf("Domain name %s is invalid: %s", suite.domain, err.Error()))
+				return
+			}
+		}
+
+		// Load the known specs (for titles)
+		if client, err := newPaymailClient(suite.ctx, false, nameServer); err == nil {
+			suite.specs = client.GetBRFCs()
+		}
+
+		// Run all the checks and show the results
+		suite.run()
+		runReport.Conformance = suite.results
+		suite.display()
+	},
+}
+
+// run will run all the checks (in order)
+func (s *conformanceSuite) run() {
+	// Service discovery is required for everything else
+	if !s.checkServiceDiscovery() {
+		return
+	}
+	checked := map[string]bool{}
+	checks := []struct {
+		brfc      string
+		alternate string
+		check     func(result *ConformanceResult)
+	}{
+		{paymail.BRFCPkiAlternate, paymail.BRFCPki, s.checkPKI},
+		{paymail.BRFCBasicAddressResolution, paymail.BRFCPaymentDestination, s.checkAddressResolution},
+		{paymail.BRFCSenderValidation, "", s.checkSenderValidation},
+		{paymail.BRFCP2PPaymentDestination, "", s.checkP2PDestination},
+		{paymail.BRFCVerifyPublicKeyOwner, "", s.checkVerifyPubKey},
+		{paymail.BRFCPublicProfile, "", s.checkPublicProfile},
+	}
+	for _, c := range checks {
+		checked[c.brfc], checked[c.alternate] = true, true
+		result := s.newResult(c.brfc)
+		c.check(result)
+	}
+
+	// Advertised capabilities without a check
+	var others []string
+	for key := range s.capabilities.Capabilities {
+		if !checked[key] {
+			others = append(others, key)
+		}
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		s.newResult(key).skip("advertised, no conformance check available")
+	}
+}
+
+// newResult will start a new result for a BRFC
+func (s *conformanceSuite) newResult(brfc string) *ConformanceResult {
+	result := &ConformanceResult{BRFC: brfc, Details: []string{}, Status: conformancePass, Title: s.title(brfc)}
+	s.results = append(s.results, result)
+	return result
+}
+
+// title returns the title of a BRFC (if known)
+func (s *conformanceSuite) title(brfc string) string {
+	if brfc == brfcServiceDiscovery {
+		return "Service Discovery"
+	}
+	for _, spec := range s.specs {
+		if spec.ID == brfc || (len(spec.Alias) > 0 && spec.Alias == brfc) {
+			return spec.Title
+		}
+	}
+	return ""
+}
+
+// requireAddress will skip the result if there is no paymail address to test with
+func (s *conformanceSuite) requireAddress(result *ConformanceResult) bool {
+	if len(s.address) == 0 {
+		result.skip("requires a paymail address (alias@domain.tld)")
+		return false
+	}
+	return true
+}
+
+// checkServiceDiscovery will check the capabilities document
+func (s *conformanceSuite) checkServiceDiscovery() bool {
+	result := s.newResult(brfcServiceDiscovery)
+
+	var err error
+	if s.capabilities, err = getCapabilities(s.ctx, s.domain, false); err != nil {
+		result.fail("capabilities: " + err.Error())
+		return false
+	}
+	result.pass(fmt.Sprintf("%s version %s with %d capabilities", flagBsvAlias, s.capabilities.BsvAlias, len(s.capabilities.Capabilities)))
+
+	// All endpoint templates should be https
+	for key, value := range s.capabilities.Capabilities {
+		if target, ok := value.(string); ok && !strings.HasPrefix(target, "https://") {
+			result.fail(fmt.Sprintf("%s is not an https url: %s", key, target))
+		}
+	}
+	return true
+}
+
+// checkPKI will check the PKI response shape and pubkey
+func (s *conformanceSuite) checkPKI(result *ConformanceResult) {
+	pkiURL := s.capabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
+	if len(pkiURL) == 0 {
+		result.fail("required capability is missing")
+		return
+	} else if !s.requireAddress(result) {
+		return
+	}
+
+	pki, err := getPki(s.ctx, pkiURL, s.alias, s.domain, false)
+	if err != nil {
+		result.fail("pki: " + err.Error())
+		return
+	}
+	if _, err = ec.PublicKeyFromString(pki.PubKey); err != nil {
+		result.fail("pubkey is not a valid compressed public key: " + err.Error())
+		return
+	}
+	s.pki = pki
+	result.pass(fmt.Sprintf("handle %s has pubkey %s", pki.Handle, pki.PubKey))
+}
+
+// checkAddressResolution will check the output script and the optional signature
+func (s *conformanceSuite) checkAddressResolution(result *ConformanceResult) {
+	resolveURL := s.capabilities.GetString(paymail.BRFCPaymentDestination, paymail.BRFCBasicAddressResolution)
+	if len(resolveURL) == 0 {
+		result.fail("required capability is missing")
+		return
+	} else if !s.requireAddress(result) {
+		return
+	}
+
+	// Start a sender request (signed if a sender key is set)
+	senderRequest, signed, err := s.senderRequest()
+	if err != nil {
+		result.fail(err.Error())
+		return
+	} else if !signed && s.capabilities.GetBool(paymail.BRFCSenderValidation, "") {
+		result.skip(fmt.Sprintf("sender validation is enforced, requires --%s", flagSenderKey))
+		return
+	}
+
+	var response *paymail.ResolutionResponse
+	if response, err = resolveAddress(s.ctx, resolveURL, s.alias, s.domain, senderRequest); err != nil {
+		result.fail("resolve: " + err.Error())
+		return
+	}
+	result.pass(fmt.Sprintf("output resolves to address %s", response.Address))
+
+	// The signature is optional (signature of the output using the receiver's PKI)
+	if len(response.Signature) == 0 {
+		result.pass("no signature in the response (optional)")
+		return
+	} else if s.pki == nil {
+		result.pass("signature found but no PKI to verify it")
+		return
+	}
+	if err = verifyWithPubKey(s.pki.PubKey, response.Signature, response.Output); err != nil {
+		result.fail("signature of the output is invalid: " + err.Error())
+		return
+	}
+	result.pass("signature of the output is valid")
+}
+
+// checkSenderValidation will check that a bad sender signature is rejected
+func (s *conformanceSuite) checkSenderValidation(result *ConformanceResult) {
+	if !s.capabilities.GetBool(paymail.BRFCSenderValidation, "") {
+		result.skip("not enforced")
+		return
+	} else if !s.requireAddress(result) {
+		return
+	}
+	resolveURL := s.capabilities.GetString(paymail.BRFCPaymentDestination, paymail.BRFCBasicAddressResolution)
+	if len(resolveURL) == 0 {
+		result.skip("no address resolution capability")
+		return
+	}
+
+	// Sign the request with a random key (valid format, wrong signer)
+	senderRequest, _, err := s.senderRequest()
+	if err != nil {
+		result.fail(err.Error())
+		return
+	}
+	var randomKey *ec.PrivateKey
+	if randomKey, err = ec.NewPrivateKey(); err != nil {
+		result.fail(err.Error())
+		return
+	}
+	if err = signSenderRequest(senderRequest, randomKey); err != nil {
+		result.fail(err.Error())
+		return
+	}
+
+	// Only a 4xx response is a rejection (401 or 400 per the BRFC), a server error or a failed request is not
+	var response *paymail.ResolutionResponse
+	response, err = resolveAddress(s.ctx, resolveURL, s.alias, s.domain, senderRequest)
+	switch {
+	case err == nil:
+		result.fail("a request with a bad signature was accepted")
+	case response == nil || response.StatusCode == 0:
+		result.fail("the request failed, expected a rejection (401 or 400): " + err.Error())
+	case response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusTooManyRequests:
+		result.fail(fmt.Sprintf("status code %d is not a rejection of the signature (expected 401 or 400): %s", response.StatusCode, err.Error()))
+	case response.StatusCode >= http.StatusBadRequest && response.StatusCode < http.StatusInternalServerError:
+		result.pass(fmt.Sprintf("a request with a bad signature was rejected (status code %d)", response.StatusCode))
+	default:
+		result.fail(fmt.Sprintf("status code %d is not a rejection (expected 401 or 400): %s", response.StatusCode, err.Error()))
+	}
+}
+
+// checkP2PDestination will check the outputs and reference
+func (s *conformanceSuite) checkP2PDestination(result *ConformanceResult) {
+	destinationURL := s.capabilities.GetString(paymail.BRFCP2PPaymentDestination, "")
+	if len(destinationURL) == 0 {
+		result.skip("not advertised")
+		return
+	} else if !s.requireAddress(result) {
+		return
+	}
+
+	response, err := getP2PPaymentDestination(s.ctx, destinationURL, s.alias, s.domain, defaultSatoshiValue)
+	if err != nil {
+		result.fail("p2p destination: " + err.Error())
+		return
+	}
+	if len(response.Reference) == 0 {
+		result.fail("missing reference")
+	}
+	if len(response.Outputs) == 0 {
+		result.fail("no outputs")
+		return
+	}
+
+	var total uint64
+	for index, output := range response.Outputs {
+		total += output.Satoshis
+		if _, err = script.NewFromHex(output.Script); err != nil {
+			result.fail(fmt.Sprintf("output #%d has an invalid script: %s", index+1, err.Error()))
+		}
+	}
+	if total != defaultSatoshiValue {
+		result.fail(fmt.Sprintf("outputs total %d satoshis, expected: %d", total, defaultSatoshiValue))
+	}
+	result.pass(fmt.Sprintf("%d output(s) for %d satoshis, reference: %s", len(response.Outputs), total, response.Reference))
+}
+
+// checkVerifyPubKey will check the true (PKI pubkey) and false (random pubkey) cases
+func (s *conformanceSuite) checkVerifyPubKey(result *ConformanceResult) {
+	verifyURL := s.capabilities.GetString(paymail.BRFCVerifyPublicKeyOwner, "")
+	if len(verifyURL) == 0 {
+		result.skip("not advertised")
+		return
+	} else if !s.requireAddress(result) {
+		return
+	} else if s.pki == nil {
+		result.skip("requires a valid PKI")
+		return
+	}
+
+	// True case
+	response, err := verifyPubKey(s.ctx, verifyURL, s.alias, s.domain, s.pki.PubKey)
+	if err != nil {
+		result.fail("verify (pki pubkey): " + err.Error())
+		return
+	} else if !response.Match {
+		result.fail("the PKI pubkey did not match")
+	} else {
+		result.pass("the PKI pubkey matches")
+	}
+
+	// False case
+	var randomKey *ec.PrivateKey
+	if randomKey, err = ec.NewPrivateKey(); err != nil {
+		result.fail(err.Error())
+		return
+	}
+	if response, err = verifyPubKey(s.ctx, verifyURL, s.alias, s.domain, hex.EncodeToString(randomKey.PubKey().Compressed())); err != nil {
+		result.fail("verify (random pubkey): " + err.Error())
+		return
+	} else if response.Match {
+		result.fail("a random pubkey matched")
+		return
+	}
+	result.pass("a random pubkey does not match")
+}
+
+// checkPublicProfile will check the name and avatar fields
+func (s *conformanceSuite) checkPublicProfile(result *ConformanceResult) {
+	profileURL := s.capabilities.GetString(paymail.BRFCPublicProfile, "")
+	if len(profileURL) == 0 {
+		result.skip("not advertised")
+		return
+	} else if !s.requireAddress(result) {
+		return
+	}
+
+	profile, err := getPublicProfile(s.ctx, profileURL, s.alias, s.domain, false)
+	if err != nil {
+		result.fail("public profile: " + err.Error())
+		return
+	}
+	if len(profile.Name) == 0 {
+		result.fail("missing name")
+	} else {
+		result.pass("name: " + profile.Name)
+	}
+	if len(profile.Avatar) > 0 {
+		if avatar, parseErr := url.Parse(profile.Avatar); parseErr != nil || (avatar.Scheme != "https" && avatar.Scheme != "http") {
+			result.fail("avatar is not a valid url: " + profile.Avatar)
+		} else {
+			result.pass("avatar: " + profile.Avatar)
+		}
+	}
+}
+
+// senderRequest will create a new sender request (signed if a sender key is set)
+func (s *conformanceSuite) senderRequest() (senderRequest *paymail.SenderRequest, signed bool, err error) {
+	senderHandle := flagOrConfig(conformanceHandle, flagSenderHandle)
+	if len(senderHandle) == 0 {
+		senderHandle = s.address
+	}
+	senderRequest = &paymail.SenderRequest{
+		Dt:           time.Now().UTC().Format(time.RFC3339),
+		SenderHandle: senderHandle,
+		SenderName:   applicationFullName,
+	}
+
+	senderKeyValue := flagOrConfig(conformanceKey, flagSenderKey)
+	if len(senderKeyValue) == 0 {
+		return senderRequest, false, nil
+	}
+
+	var senderKey *ec.PrivateKey
+	if senderKey, err = loadSenderKey(senderKeyValue); err != nil {
+		return senderRequest, false, fmt.Errorf("invalid --%s (expected WIF or hex): %w", flagSenderKey, err)
+	}
+	if err = signSenderRequest(senderRequest, senderKey); err != nil {
+		return senderRequest, false, err
+	}
+	return senderRequest, true, nil
+}
+
+// verifyWithPubKey will verify a message signature against a hex pubkey
+func verifyWithPubKey(pubKey, signature, message string) error {
+	publicKey, err := ec.PublicKeyFromString(pubKey)
+	if err != nil {
+		return err
+	}
+	var address *script.Address
+	if address, err = script.NewAddressFromPublicKey(publicKey, true); err != nil {
+		return err
+	}
+	var decoded []byte
+	if decoded, err = paymail.DecodeSignature(signature); err != nil {
+		return err
+	}
+	return bsm.VerifyMessage(address.AddressString, decoded, []byte(message))
+}
+
+// display will show all the results and a summary
+func (s *conformanceSuite) display() {
+	target := s.address
+	if len(target) == 0 {
+		target = s.domain
+	}
+	displayHeader(chalker.BOLD, fmt.Sprintf("Conformance results for %s...", color.CyanString(target)))
+
+	counts := map[string]int{}
+	output := []string{"BRFC | Title | Status | Details"}
+	for _, result := range s.results {
+		counts[result.Status]++
+		status := color.GreenString(result.Status)
+		if result.Status == conformanceFail {
+			status = color.MagentaString(result.Status)
+		} else if result.Status == conformanceSkip {
+			status = color.YellowString(result.Status)
+		}
+		output = append(output, fmt.Sprintf("%s | %s | %s | %s", result.BRFC, result.Title, status, strings.Join(result.Details, "; ")))
+	}
+	chalker.Log(chalker.DEFAULT, columnize.SimpleFormat(output))
+
+	summary := fmt.Sprintf("%d passed, %d failed, %d skipped", counts[conformancePass], counts[conformanceFail], counts[conformanceSkip])
+	if counts[conformanceFail] > 0 {
+		chalker.Log(chalker.ERROR, summary)
+	} else {
+		chalker.Log(chalker.SUCCESS, summary)
+	}
+}
+
+// addDetail will add a detail (on a single line)
+func (r *ConformanceResult) addDetail(detail string) {
+	r.Details = append(r.Details, strings.Join(strings.Fields(detail), " "))
+}
+
+// pass will add a detail (the status is kept if already failed)
+func (r *ConformanceResult) pass(detail string) {
+	r.addDetail(detail)
+}
+
+// fail will add a detail and mark the result as failed
+func (r *ConformanceResult) fail(detail string) {
+	r.addDetail(detail)
+	r.Status = conformanceFail
+}
+
+// skip will add a detail and mark the result as skipped
+func (r *ConformanceResult) skip(detail string) {
+	r.addDetail(detail)
+	r.Status = conformanceSkip
+}
+
+func init() {
+	rootCmd.AddCommand(conformanceCmd)
+
+	// Set the sender handle (default: sender-handle from the config or the paymail address)
+	conformanceCmd.Flags().StringVar(&conformanceHandle, flagSenderHandle, "", "Sender's paymail handle used for address resolution")
+
+	// Set the sender key (default: sender-key from the config)
+	conformanceCmd.Flags().StringVar(&conformanceKey, flagSenderKey, "", "Sender's private key (WIF or hex) to sign requests (required if sender validation is enforced)")
+}
@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/mock"
+)
+
+// startMockProvider will start the mock provider over TLS and point all requests at it (--host and --insecure)
+//
+// The override can answer a request first (returns true if it did), the logs are discarded
+func startMockProvider(t *testing.T, senderValidation bool,
+	override func(provider *mock.Server, w http.ResponseWriter, r *http.Request) bool,
+) *mock.Server {
+	config := &mock.Config{
+		Aliases: []*mock.Alias{
+			{Alias: "satoshi", Name: "Satoshi Nakamoto", PrivateKey: testKeySatoshi},
+			{Alias: "alice", Name: "Alice", PrivateKey: testKeyAlice},
+		},
+		SenderValidation: senderValidation,
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("%s Failed: invalid mock config: %s", t.Name(), err.Error())
+	}
+
+	var provider *mock.Server
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if override == nil || !override(provider, w, r) {
+			provider.ServeHTTP(w, r)
+		}
+	}))
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	provider = mock.NewServer(config, server.URL)
+
+	previousHost, previousInsecure, previousAttempts, previousOutput := hostOverride, insecure, maxAttempts, color.Output
+	hostOverride, insecure, maxAttempts, color.Output = server.Listener.Addr().String(), true, 1, io.Discard
+	t.Cleanup(func() {
+		hostOverride, insecure, maxAttempts, color.Output = previousHost, previousInsecure, previousAttempts, previousOutput
+	})
+	return provider
+}
+
+// advertiseSenderValidation returns an override that advertises sender validation (the provider does not enforce it)
+func advertiseSenderValidation(provider *mock.Server, w http.ResponseWriter, r *http.Request) bool {
+	if r.URL.Path != "/.well-known/"+paymail.DefaultServiceName {
+		return false
+	}
+	capabilities := provider.Capabilities()
+	capabilities.Capabilities[paymail.BRFCSenderValidation] = true
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(capabilities)
+	return true
+}
+
+// resolveStatus returns an override that answers all address resolution requests with the status code
+func resolveStatus(status int) func(provider *mock.Server, w http.ResponseWriter, r *http.Request) bool {
+	return func(_ *mock.Server, w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasPrefix(r.URL.Path, "/v1/bsvalias/address/") {
+			return false
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"code":"test","message":"test"}`))
+		return true
+	}
+}
+
+// TestConformanceSuite_run will test the method run() (pass, fail or skip per BRFC)
+func TestConformanceSuite_run(t *testing.T) {
+	// Not parallel: points the requests at the mock provider (global flags)
+
+	var tests = []struct {
+		name             string
+		target           string
+		senderValidation bool
+		override         func(provider *mock.Server, w http.ResponseWriter, r *http.Request) bool
+		senderKey        string
+		senderHandle     string
+		expected         []string // BRFC and status (in order)
+		expectedDetail   string   // Detail of the sender validation check
+	}{
+		{"domain only", "paymail.test", false, nil, "", "", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " skip",
+			paymail.BRFCBasicAddressResolution + " skip",
+			paymail.BRFCSenderValidation + " skip",
+			paymail.BRFCP2PPaymentDestination + " skip",
+			paymail.BRFCVerifyPublicKeyOwner + " skip",
+			paymail.BRFCPublicProfile + " skip",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "not enforced"},
+		{"address", "satoshi@paymail.test", false, nil, "", "", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " pass",
+			paymail.BRFCBasicAddressResolution + " pass",
+			paymail.BRFCSenderValidation + " skip",
+			paymail.BRFCP2PPaymentDestination + " pass",
+			paymail.BRFCVerifyPublicKeyOwner + " pass",
+			paymail.BRFCPublicProfile + " pass",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "not enforced"},
+		{"unknown alias", "bob@paymail.test", false, nil, "", "", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " fail",
+			paymail.BRFCBasicAddressResolution + " fail",
+			paymail.BRFCSenderValidation + " skip",
+			paymail.BRFCP2PPaymentDestination + " fail",
+			paymail.BRFCVerifyPublicKeyOwner + " skip",
+			paymail.BRFCPublicProfile + " fail",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "not enforced"},
+		{"sender validation without a key", "satoshi@paymail.test", true, nil, "", "", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " pass",
+			paymail.BRFCBasicAddressResolution + " skip",
+			paymail.BRFCSenderValidation + " pass",
+			paymail.BRFCP2PPaymentDestination + " pass",
+			paymail.BRFCVerifyPublicKeyOwner + " pass",
+			paymail.BRFCPublicProfile + " pass",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "a request with a bad signature was rejected (status code 401)"},
+		{"sender validation with a key", "satoshi@paymail.test", true, nil, testKeyAlice, "alice@paymail.test", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " pass",
+			paymail.BRFCBasicAddressResolution + " pass",
+			paymail.BRFCSenderValidation + " pass",
+			paymail.BRFCP2PPaymentDestination + " pass",
+			paymail.BRFCVerifyPublicKeyOwner + " pass",
+			paymail.BRFCPublicProfile + " pass",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "a request with a bad signature was rejected (status code 401)"},
+		{"bad signature is accepted", "satoshi@paymail.test", false, advertiseSenderValidation, "", "", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " pass",
+			paymail.BRFCBasicAddressResolution + " skip",
+			paymail.BRFCSenderValidation + " fail",
+			paymail.BRFCP2PPaymentDestination + " pass",
+			paymail.BRFCVerifyPublicKeyOwner + " pass",
+			paymail.BRFCPublicProfile + " pass",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "a request with a bad signature was accepted"},
+		{"bad signature gets a 400", "satoshi@paymail.test", true, resolveStatus(http.StatusBadRequest), "", "", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " pass",
+			paymail.BRFCBasicAddressResolution + " skip",
+			paymail.BRFCSenderValidation + " pass",
+			paymail.BRFCP2PPaymentDestination + " pass",
+			paymail.BRFCVerifyPublicKeyOwner + " pass",
+			paymail.BRFCPublicProfile + " pass",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "a request with a bad signature was rejected (status code 400)"},
+		{"bad signature gets a 404", "satoshi@paymail.test", true, resolveStatus(http.StatusNotFound), "", "", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " pass",
+			paymail.BRFCBasicAddressResolution + " skip",
+			paymail.BRFCSenderValidation + " fail",
+			paymail.BRFCP2PPaymentDestination + " pass",
+			paymail.BRFCVerifyPublicKeyOwner + " pass",
+			paymail.BRFCPublicProfile + " pass",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "status code 404 is not a rejection of the signature"},
+		{"bad signature gets a 429", "satoshi@paymail.test", true, resolveStatus(http.StatusTooManyRequests), "", "", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " pass",
+			paymail.BRFCBasicAddressResolution + " skip",
+			paymail.BRFCSenderValidation + " fail",
+			paymail.BRFCP2PPaymentDestination + " pass",
+			paymail.BRFCVerifyPublicKeyOwner + " pass",
+			paymail.BRFCPublicProfile + " pass",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "status code 429 is not a rejection of the signature"},
+		{"bad signature gets a 500", "satoshi@paymail.test", true, resolveStatus(http.StatusInternalServerError), "", "", []string{
+			brfcServiceDiscovery + " pass",
+			paymail.BRFCPkiAlternate + " pass",
+			paymail.BRFCBasicAddressResolution + " skip",
+			paymail.BRFCSenderValidation + " fail",
+			paymail.BRFCP2PPaymentDestination + " pass",
+			paymail.BRFCVerifyPublicKeyOwner + " pass",
+			paymail.BRFCPublicProfile + " pass",
+			paymail.BRFCP2PTransactions + " skip",
+		}, "status code 500 is not a rejection"},
+	}
+
+	for _, test := range tests {
+		startMockProvider(t, test.senderValidation, test.override)
+		previousKey, previousHandle := conformanceKey, conformanceHandle
+		conformanceKey, conformanceHandle = test.senderKey, test.senderHandle
+
+		suite := &conformanceSuite{ctx: context.Background()}
+		if strings.Contains(test.target, "@") {
+			suite.alias, suite.domain, suite.address = paymail.SanitizePaymail(test.target)
+		} else {
+			suite.domain = test.target
+		}
+		suite.run()
+		conformanceKey, conformanceHandle = previousKey, previousHandle
+
+		output := make([]string, 0, len(suite.results))
+		detail := ""
+		for _, result := range suite.results {
+			output = append(output, result.BRFC+" "+result.Status)
+			if result.BRFC == paymail.BRFCSenderValidation {
+				detail = strings.Join(result.Details, "; ")
+			}
+		}
+		if strings.Join(output, ", ") != strings.Join(test.expected, ", ") {
+			t.Errorf("%s Failed: [%s] inputted and %q expected, received: %q", t.Name(), test.name, test.expected, output)
+		}
+		if !strings.Contains(detail, test.expectedDetail) {
+			t.Errorf("%s Failed: [%s] inputted and sender validation detail [%s] expected, received: [%s]", t.Name(), test.name, test.expectedDetail, detail)
+		}
+	}
+}
+
+// TestConformanceSuite_requestFailed will test the sender validation check when the request fails (no response)
+func TestConformanceSuite_requestFailed(t *testing.T) {
+	// Not parallel: points the requests at the mock provider (global flags)
+
+	startMockProvider(t, true, func(_ *mock.Server, w http.ResponseWriter, r *http.Request) bool {
+		if !strings.HasPrefix(r.URL.Path, "/v1/bsvalias/address/") {
+			return false
+		}
+		// Close the connection without a response
+		if conn, _, err := w.(http.Hijacker).Hijack(); err == nil {
+			_ = conn.Close()
+		}
+		return true
+	})
+
+	suite := &conformanceSuite{ctx: context.Background()}
+	suite.alias, suite.domain, suite.address = paymail.SanitizePaymail("satoshi@paymail.test")
+	suite.run()
+
+	for _, result := range suite.results {
+		if result.BRFC != paymail.BRFCSenderValidation {
+			continue
+		}
+		if result.Status != conformanceFail || !strings.Contains(strings.Join(result.Details, "; "), "the request failed, expected a rejection") {
+			t.Errorf("%s Failed: a failed request expected, received: [%s] %q", t.Name(), result.Status, result.Details)
+		}
+		return
+	}
+	t.Errorf("%s Failed: no sender validation result", t.Name())
+}
+
+// TestConformanceResult will test the methods pass(), fail() and skip()
+func TestConformanceResult(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		apply    func(result *ConformanceResult)
+		expected string
+		details  string
+	}{
+		{"pass", func(r *ConformanceResult) { r.pass("ok") }, conformancePass, "ok"},
+		{"fail is kept", func(r *ConformanceResult) { r.fail("bad"); r.pass("ok") }, conformanceFail, "bad; ok"},
+		{"skip", func(r *ConformanceResult) { r.skip("not advertised") }, conformanceSkip, "not advertised"},
+		{"one line", func(r *ConformanceResult) { r.pass("multi\n  line\tdetail ") }, conformancePass, "multi line detail"},
+	}
+
+	for _, test := range tests {
+		result := &ConformanceResult{Details: []string{}, Status: conformancePass}
+		test.apply(result)
+		if result.Status != test.expected || strings.Join(result.Details, "; ") != test.details {
+			t.Errorf("%s Failed: [%s] inputted and [%s] [%s] expected, received: [%s] %q", t.Name(), test.name, test.expected, test.details, result.Status, result.Details)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail/interfaces"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/spf13/cobra"
+)
+
+// ErrCanceled is returned when the command was canceled (CTRL+C)
+var ErrCanceled = errors.New("canceled")
+
+// Kinds of failures (the API answers with 400 or 404, see KindError)
+var (
+	ErrInvalidInput = errors.New("invalid input") // IE: a malformed domain, paymail address or pubkey
+	ErrNotFound     = errors.New("not found")     // IE: the provider does not know the paymail address
+)
+
+// KindError is an error with the kind of failure (the message is the original error)
+type KindError struct {
+	Err  error // Original error
+	Kind error // ErrInvalidInput or ErrNotFound
+}
+
+// Error returns the error message
+func (e *KindError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the kind and the original error
+func (e *KindError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// TimeoutError is returned when a request (or the whole command) ran out of time
+type TimeoutError struct {
+	Err     error         // Original error
+	Request string        // Request that timed out (IE: capabilities, pki, srv)
+	Timeout time.Duration // Time allowed for the command (if --timeout was set)
+}
+
+// Error returns the error message
+func (e *TimeoutError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("%s timed out (--timeout %s): %s", e.Request, e.Timeout, e.Err.Error())
+	}
+	return fmt.Sprintf("%s timed out: %s", e.Request, e.Err.Error())
+}
+
+// Unwrap returns the original error
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// isTimeout returns true if the error is a TimeoutError
+func isTimeout(err error) bool {
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// requestError will convert a canceled or timed out request into ErrCanceled or a TimeoutError
+func requestError(ctx context.Context, request string, err error) error {
+	if err == nil || errors.Is(err, ErrCanceled) || isTimeout(err) {
+		return err
+	}
+
+	// Canceled by the user (CTRL+C)
+	if errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, context.Canceled) {
+		return fmt.Errorf("%s: %w", request, ErrCanceled)
+	}
+
+	// The command (--timeout) or the request (--dns-timeout, --http-timeout) ran out of time
+	var netErr net.Error
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Err: err, Request: request, Timeout: commandTimeout}
+	} else if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &TimeoutError{Err: err, Request: request}
+	}
+	return err
+}
+
+// startCommandContext will set the context for the command (canceled on CTRL+C, or after --timeout)
+func startCommandContext(cmd *cobra.Command) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	if commandTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, commandTimeout)
+		stopSignal := stop
+		stop = func() {
+			cancel()
+			stopSignal()
+		}
+	}
+	stopCommandContext = stop
+	cmd.SetContext(ctx)
+}
+
+// stopCommandContext releases the command context (set when the command starts)
+var stopCommandContext = func() {}
+
+// logBufferKey is the context key for the log buffer of a request flow
+type logBufferKey struct{}
+
+// withLogBuffer returns a context where all logs (from the request helpers) are kept in the buffer
+func withLogBuffer(ctx context.Context, buffer *chalker.Buffer) context.Context {
+	return context.WithValue(ctx, logBufferKey{}, buffer)
+}
+
+// logWithContext will record the log in the report of the context and keep it in the buffer of the context (if any),
+// otherwise it's written right away
+func logWithContext(ctx context.Context, level, body string) {
+	reportFromContext(ctx).recordLog(level, body)
+	if buffer, ok := ctx.Value(logBufferKey{}).(*chalker.Buffer); ok && buffer != nil {
+		buffer.Log(level, body)
+		return
+	}
+	chalker.Write(level, body)
+}
+
+// reportKey is the context key for the report of a request flow
+type reportKey struct{}
+
+// withReport returns a context where the errors, warnings, traces and cache hits are kept in the report
+// (IE: one batch entry or one API request)
+func withReport(ctx context.Context, report *Report) context.Context {
+	return context.WithValue(ctx, reportKey{}, report)
+}
+
+// reportFromContext returns the report of the context (the report of the command if none is set)
+func reportFromContext(ctx context.Context) *Report {
+	if report, ok := ctx.Value(reportKey{}).(*Report); ok && report != nil {
+		return report
+	}
+	return runReport
+}
+
+// contextResolver runs all DNS lookups with the command context and the --dns-timeout
+// (go-paymail uses a background context for some lookups)
+type contextResolver struct {
+	ctx      context.Context
+	resolver interfaces.DNSResolver
+}
+
+// LookupHost will look up the addresses of a host
+func (r *contextResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(r.ctx, dnsTimeout)
+	defer cancel()
+	start := time.Now()
+	addresses, err := r.resolver.LookupHost(ctx, host)
+	timeStep("DNS "+host, start, false, err)
+	return addresses, err
+}
+
+// LookupIPAddr will look up the IP addresses of a host
+func (r *contextResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ctx, cancel := context.WithTimeout(r.ctx, dnsTimeout)
+	defer cancel()
+	start := time.Now()
+	addresses, err := r.resolver.LookupIPAddr(ctx, host)
+	timeStep("DNS "+host, start, false, err)
+	return addresses, err
+}
+
+// LookupSRV will look up the SRV records of a service
+func (r *contextResolver) LookupSRV(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	ctx, cancel := context.WithTimeout(r.ctx, dnsTimeout)
+	defer cancel()
+	start := time.Now()
+	cname, records, err := r.resolver.LookupSRV(ctx, service, proto, name)
+	timeStep(fmt.Sprintf("DNS SRV _%s._%s.%s", service, proto, name), start, false, err)
+	return cname, records, err
+}
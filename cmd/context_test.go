@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestRequestError will test the method requestError()
+func TestRequestError(t *testing.T) {
+	// Not parallel: sets the command timeout
+
+	previous := commandTimeout
+	commandTimeout = 5 * time.Second
+	t.Cleanup(func() {
+		commandTimeout = previous
+	})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	requestFailed := errors.New("request failed")
+	netTimeout := &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}
+	timedOut := &TimeoutError{Err: requestFailed, Request: "pki"}
+
+	var tests = []struct {
+		name            string
+		ctx             context.Context
+		err             error
+		expected        string
+		expectedTimeout time.Duration // -1 if not a TimeoutError
+	}{
+		{"no error", context.Background(), nil, "", -1},
+		{"other error", context.Background(), requestFailed, "request failed", -1},
+		{"already canceled", canceled, fmt.Errorf("pki: %w", ErrCanceled), "pki: canceled", -1},
+		{"already timed out", expired, timedOut, "pki timed out: request failed", 0},
+		{"canceled command", canceled, requestFailed, "capabilities: canceled", -1},
+		{"canceled request", context.Background(), fmt.Errorf("dial: %w", context.Canceled), "capabilities: canceled", -1},
+		{"command timeout", expired, requestFailed, "capabilities timed out (--timeout 5s): request failed", 5 * time.Second},
+		{"request deadline", context.Background(), fmt.Errorf("get: %w", context.DeadlineExceeded), "capabilities timed out: get: context deadline exceeded", 0},
+		{"network timeout", context.Background(), netTimeout, "capabilities timed out: lookup example.com: i/o timeout", 0},
+		{"network error", context.Background(), &net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}, "lookup example.com: no such host", -1},
+	}
+
+	for _, test := range tests {
+		output := requestError(test.ctx, "capabilities", test.err)
+		if test.err == nil {
+			if output != nil {
+				t.Errorf("%s Failed: [%s] inputted and no error expected, received: [%s]", t.Name(), test.name, output.Error())
+			}
+			continue
+		} else if output == nil {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: nil", t.Name(), test.name, test.expected)
+			continue
+		}
+		if output.Error() != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expected, output.Error())
+		}
+
+		// Canceled or timed out (the original error is kept)
+		var timeoutErr *TimeoutError
+		if isTimeout(output) != (test.expectedTimeout >= 0) {
+			t.Errorf("%s Failed: [%s] inputted and timeout %v expected, received: [%v]", t.Name(), test.name, test.expectedTimeout >= 0, output)
+		} else if errors.As(output, &timeoutErr) && timeoutErr.Timeout != test.expectedTimeout {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expectedTimeout, timeoutErr.Timeout)
+		}
+		if isTimeout(output) && !errors.Is(output, test.err) {
+			t.Errorf("%s Failed: [%s] inputted and the original error wrapped, received: [%v]", t.Name(), test.name, output)
+		}
+	}
+}
@@ -1,12 +1,11 @@
 package cmd
 
 import (
+	"time"
+
 	"github.com/bsv-blockchain/go-paymail"
 	"github.com/mrz1836/go-sanitize"
-	"github.com/mrz1836/paymail-inspector/integrations/baemail"
-	"github.com/mrz1836/paymail-inspector/integrations/bitpic"
-	"github.com/mrz1836/paymail-inspector/integrations/powping"
-	"github.com/mrz1836/paymail-inspector/integrations/roundesk"
+	"github.com/mrz1836/paymail-inspector/integrations"
 )
 
 // Version is set manually (also make:build overwrites this value from Github's latest tag)
@@ -14,34 +13,69 @@ var Version = "v0.4.2"
 
 // Default flag values for various commands
 var (
-	amount             uint64 // cmd: resolve
-	brfcAuthor         string // cmd: brfc
-	brfcTitle          string // cmd: brfc
-	brfcVersion        string // cmd: brfc
-	configFile         string // cmd: root
-	disableCache       bool   // cmd: root
-	flushCache         bool   // cmd: root
-	generateDocs       bool   // cmd: root
-	nameServer         string // cmd: validate
-	port               uint16 // cmd: validate
-	priority           uint16 // cmd: validate
-	protocol           string // cmd: validate
-	purpose            string // cmd: resolve
-	satoshis           uint64 // cmd: resolve
-	serviceName        string // cmd: validate
-	signature          string // cmd: resolve
-	skipBaemail        bool   // cmd: resolve
-	skipBitpic         bool   // cmd: resolve
-	skipBrfcValidation bool   // cmd: brfc
-	skipDNSCheck       bool   // cmd: validate
-	skipPki            bool   // cmd: resolve
-	skipPowPing        bool   // cmd: resolve
-	skipPublicProfile  bool   // cmd: resolve
-	skipRoundesk       bool   // cmd: resolve
-	skipSrvCheck       bool   // cmd: validate
-	skipSSLCheck       bool   // cmd: validate
-	skipTracing        bool   // cmd: root
-	weight             uint16 // cmd: validate
+	amount             uint64        // cmd: resolve
+	apiConcurrency     int           // cmd: api
+	apiListen          string        // cmd: api
+	apiTimeout         time.Duration // cmd: api
+	batchConcurrency   int           // cmd: resolve, validate, verify
+	brfcAuthor         string        // cmd: brfc
+	brfcTitle          string        // cmd: brfc
+	brfcVersion        string        // cmd: brfc
+	commandTimeout     time.Duration // cmd: root
+	configFile         string        // cmd: root
+	conformanceHandle  string        // cmd: conformance
+	conformanceKey     string        // cmd: conformance
+	disableCache       bool          // cmd: root
+	dnssecExpiry       time.Duration // cmd: validate
+	dnsTimeout         time.Duration // cmd: root
+	exporterInterval   time.Duration // cmd: exporter
+	exporterListen     string        // cmd: exporter
+	flushCache         bool          // cmd: root
+	generateDocs       bool          // cmd: root
+	harFile            string        // cmd: root
+	hostOverride       string        // cmd: root
+	httpTimeout        time.Duration // cmd: root
+	inputFile          string        // cmd: resolve, validate, verify
+	insecure           bool          // cmd: root
+	maxAge             time.Duration // cmd: root
+	maxAttempts        int           // cmd: root
+	nameServer         string        // cmd: dns, validate
+	outputFormat       string        // cmd: root
+	p2pNote            string        // cmd: p2p send
+	p2pPubKey          string        // cmd: p2p send
+	p2pReference       string        // cmd: p2p send
+	p2pSenderHandle    string        // cmd: p2p send
+	p2pSenderKey       string        // cmd: p2p send
+	p2pTx              string        // cmd: p2p send
+	p2pTxFile          string        // cmd: p2p send
+	port               uint16        // cmd: validate
+	priority           uint16        // cmd: validate
+	protocol           string        // cmd: dns, validate
+	purpose            string        // cmd: resolve
+	retryWait          time.Duration // cmd: root
+	satoshis           uint64        // cmd: resolve
+	serveCert          string        // cmd: serve
+	serveFile          string        // cmd: serve
+	serveKey           string        // cmd: serve
+	serveListen        string        // cmd: serve
+	serviceName        string        // cmd: dns, validate
+	showTiming         bool          // cmd: root
+	signature          string        // cmd: resolve, p2p send
+	skipBrfcValidation bool          // cmd: brfc
+	skipDNSCheck       bool          // cmd: validate
+	skipPki            bool          // cmd: resolve
+	skipPublicProfile  bool          // cmd: resolve
+	skipSrvCheck       bool          // cmd: validate
+	skipSSLCheck       bool          // cmd: validate
+	skipTracing        bool          // cmd: root
+	sslExpiryError     time.Duration // cmd: validate
+	sslExpiryWarning   time.Duration // cmd: validate
+	sslTimeout         time.Duration // cmd: root
+	watchCount         int           // cmd: watch
+	watchInterval      time.Duration // cmd: watch
+	weight             uint16        // cmd: validate
+	whoisConcurrency   int           // cmd: whois
+	whoisProviders     []string      // cmd: whois
 )
 
 // Application global variables
@@ -52,57 +86,58 @@ var (
 
 // Defaults for the application
 const (
-	applicationFullName = "paymail-inspector" // Full name of the application (long version)
-	applicationName     = "paymail"           // Application name (binary) (short version
-	configFileDefault   = "config"            // Config file name
-	defaultDomainName   = "moneybutton.com"   // Used in examples
-	defaultNameServer   = "8.8.8.8"           // Default DNS NameServer
-	docsLocation        = "docs/commands"     // Default location for command documentation
-	flagBsvAlias        = "bsvalias"          // Flag for a known, common key
-	flagSenderHandle    = "sender-handle"
-	flagSenderName      = "sender-name"
+	annotationLongRunning  = "long-running"      // Command annotation: runs until stopped (no --har or --timing)
+	annotationSkipDatabase = "skip-database"     // Command annotation: do not connect to the local database
+	applicationFullName    = "paymail-inspector" // Full name of the application (long version)
+	applicationName        = "paymail"           // Application name (binary) (short version
+	configFileDefault      = "config"            // Config file name
+	defaultCacheTTL        = 1 * time.Hour       // Default TTL for cached models (cache-ttl in the config)
+	defaultDomainName      = "moneybutton.com"   // Used in examples
+	defaultNameServer      = "8.8.8.8"           // Default DNS NameServer
+	docsLocation           = "docs/commands"     // Default location for command documentation
+	flagBsvAlias           = "bsvalias"          // Flag for a known, common key
+	flagCacheTTL           = "cache-ttl"         // Config key for the TTL per model type (IE: cache-ttl.pki: 10m)
+	flagSenderHandle       = "sender-handle"
+	flagSenderKey          = "sender-key"
+	flagSenderName         = "sender-name"
+	paymailDNSTimeout      = 5 * time.Second  // Timeout for DNS lookups (same as the go-paymail default)
+	paymailHTTPTimeout     = 20 * time.Second // Timeout for paymail requests (same as the go-paymail default)
+	paymailSSLTimeout      = 10 * time.Second // Timeout for the SSL check (same as the go-paymail default)
+	privateKeyHexLength    = 64               // Length of a hex encoded private key
 )
 
 // Provider is the paymail provider information
 type Provider struct {
-	Domain string `json:"domain"`
-	Link   string `json:"link"`
+	Domain string `json:"domain" yaml:"domain"`
+	Link   string `json:"link" yaml:"link,omitempty"`
+	Source string `json:"source,omitempty" yaml:"-"`
 }
 
-// providers is a list of providers that user's can obtain a paymail
+// providers is the built-in list of providers that user's can obtain a paymail (seed for the registry)
 var providers = []*Provider{
-	{"moneybutton.com", "https://tpow.app/4c58a26f"},
-	{"handcash.io", "https://tpow.app/742b1f09"},
-	{"relayx.io", "https://tpow.app/4897634e"},
-	{"centbee.com", "https://tpow.app/4350c72f"},
-	{"simply.cash", "https://tpow.app/1ce8f70f"},
-	{"dotwallet.com", "https://tpow.app/5745c80e"},
-	{"mypaymail.co", "https://tpow.app/ee243a15"},
-	{"volt.id", "https://tpow.app/e9ff2b0c"},
+	{"moneybutton.com", "https://tpow.app/4c58a26f", providerSourceBuiltIn},
+	{"handcash.io", "https://tpow.app/742b1f09", providerSourceBuiltIn},
+	{"relayx.io", "https://tpow.app/4897634e", providerSourceBuiltIn},
+	{"centbee.com", "https://tpow.app/4350c72f", providerSourceBuiltIn},
+	{"simply.cash", "https://tpow.app/1ce8f70f", providerSourceBuiltIn},
+	{"dotwallet.com", "https://tpow.app/5745c80e", providerSourceBuiltIn},
+	{"mypaymail.co", "https://tpow.app/ee243a15", providerSourceBuiltIn},
+	{"volt.id", "https://tpow.app/e9ff2b0c", providerSourceBuiltIn},
 }
 
 // getProvider will return a provider given the domain name
 func getProvider(domain string) *Provider {
 	domain, _ = sanitize.Domain(domain, false, true)
-	for _, provider := range providers {
-		if domain == provider.Domain {
-			return provider
-		}
-	}
-	return nil
+	return findProvider(getProviders(nil), domain)
 }
 
 // PaymailDetails is all the info about one paymail address
 type PaymailDetails struct {
-	Baemail       *baemail.Response              `json:"baemail"`
-	Bitpic        string                         `json:"bitpic"`
-	Bitpics       *bitpic.SearchResponse         `json:"bitpics"`
-	Dimely        string                         `json:"dimely"`
-	Handle        string                         `json:"handle"`
-	PKI           *paymail.PKIResponse           `json:"pki"`
-	PowPing       *powping.Response              `json:"powping"`
-	Provider      *Provider                      `json:"provider"`
-	PublicProfile *paymail.PublicProfileResponse `json:"public_profile"`
-	Resolution    *paymail.ResolutionResponse    `json:"resolution"`
-	Roundesk      *roundesk.Response             `json:"roundesk"`
+	Dimely        string                          `json:"dimely"`
+	Handle        string                          `json:"handle"`
+	Integrations  map[string]*integrations.Result `json:"integrations,omitempty"`
+	PKI           *paymail.PKIResponse            `json:"pki"`
+	Provider      *Provider                       `json:"provider"`
+	PublicProfile *paymail.PublicProfileResponse  `json:"public_profile"`
+	Resolution    *paymail.ResolutionResponse     `json:"resolution"`
 }
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/fatih/color"
+	"github.com/mrz1836/go-sanitize"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/dnsreport"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+)
+
+// dnsCmd represents the dns command
+var dnsCmd = &cobra.Command{
+	Use:   "dns",
+	Short: "Show all DNS records of a paymail domain and flag common misconfigurations",
+	Long: color.GreenString(`
+    .___
+  __| _/____   ______
+ / __ |/    \ /  ___/
+/ /_/ |   |  \\___ \
+\____ |___|  /____  >
+     \/    \/     \/`) + `
+` + color.YellowString(`
+This command will query the name server for all records of a paymail domain and its SRV targets
+(SRV, NS, A/AAAA, CNAME, TXT and CAA) and show each record with its TTL.
+
+Common misconfigurations are flagged: an SRV target that is a CNAME, a dangling CNAME, a target without
+an A/AAAA record, a low TTL (IE: left over from a migration) or a CAA record blocking the certificate.
+
+Low TTLs are only flagged if the name server is authoritative for the domain (a recursive resolver
+returns the time left in its cache, not the configured TTL).
+
+Read more at: `+color.CyanString("http://bsvalias.org/02-01-host-discovery.html")),
+	Example: applicationName + " dns " + defaultDomainName + `
+` + applicationName + " dns " + defaultDomainName + " --nameserver 1.1.1.1",
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return chalker.Error("dns requires either a domain or paymail address")
+		} else if len(args) > 1 {
+			return chalker.Error("dns only supports one domain or address at a time")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// Sanitize the domain
+		domain := args[0]
+		if strings.Contains(domain, "@") {
+			_, domain, _ = paymail.SanitizePaymail(domain)
+		}
+		domain, _ = sanitize.Domain(domain, false, true)
+		if err := paymail.ValidateDomain(domain); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Domain name %s is invalid: %s", domain, err.Error()))
+			return
+		}
+
+		// Query all the records
+		displayHeader(chalker.DEFAULT, fmt.Sprintf("Querying %s for %s...", color.CyanString(nameServer), color.CyanString(domain)))
+		client := &dnsreport.Client{NameServer: nameServer, Timeout: dnsTimeout}
+		start := time.Now()
+		report, err := client.Inspect(ctx, domain, serviceName, protocol)
+		timeStep("DNS report "+domain, start, false, err)
+		if err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", requestError(ctx, "dns", err).Error()))
+			return
+		}
+		runReport.DNS = report
+
+		// Rendering the results
+		displayDNSReport(report)
+	},
+}
+
+// displayDNSReport will show the records of each name and the issues found
+func displayDNSReport(report *dnsreport.Report) {
+	for _, section := range report.Sections {
+		displayHeader(chalker.BOLD, fmt.Sprintf("%s (%s)", color.CyanString(section.Name), section.Role))
+		if len(section.Records) == 0 {
+			chalker.Log(chalker.DIM, "No records found")
+			continue
+		}
+		output := []string{"Type | TTL | Name | Value"}
+		for _, record := range section.Records {
+			output = append(output, fmt.Sprintf("%s | %d | %s | %s", record.Type, record.TTL, record.Name, record.Value))
+		}
+		chalker.Log(chalker.DEFAULT, columnize.SimpleFormat(output))
+	}
+
+	displayHeader(chalker.BOLD, fmt.Sprintf("Issues found for %s...", color.CyanString(report.Domain)))
+	problems := 0
+	for _, issue := range report.Issues {
+		switch issue.Level {
+		case dnsreport.LevelError:
+			problems++
+			chalker.Log(chalker.ERROR, fmt.Sprintf("%s: %s", issue.Name, issue.Message))
+		case dnsreport.LevelWarn:
+			problems++
+			chalker.Log(chalker.WARN, fmt.Sprintf("%s: %s", issue.Name, issue.Message))
+		default:
+			chalker.Log(chalker.INFO, fmt.Sprintf("%s: %s", issue.Name, issue.Message))
+		}
+	}
+	if problems == 0 {
+		chalker.Log(chalker.SUCCESS, "No misconfigurations found")
+	}
+}
+
+// displayDNSSECChain will show the DS, DNSKEY and RRSIG records of each zone and the link that is broken
+func displayDNSSECChain(ctx context.Context, chain *dnsreport.Chain) {
+	for _, zone := range chain.Zones {
+		displayRequestHeader(ctx, chalker.BOLD, fmt.Sprintf("DNSSEC records of zone: %s", color.CyanString(zone.Name)))
+		output := []string{"Record | Key Tag | Algorithm | Details | Status"}
+		for _, ds := range zone.DS {
+			output = append(output, fmt.Sprintf("DS | %d | %s | %s | %s", ds.KeyTag, ds.Algorithm, ds.DigestType, dnssecStatus(ds.Matched, "matches a DNSKEY", "no matching DNSKEY")))
+		}
+		for _, key := range zone.DNSKEYs {
+			output = append(output, fmt.Sprintf("DNSKEY | %d | %s | %s (flags %d) | %s", key.KeyTag, key.Algorithm, key.Role, key.Flags, dnssecStatus(key.Trusted, "trusted (matches a DS)", "-")))
+		}
+		for _, signature := range zone.Signatures {
+			status := dnssecStatus(signature.Valid, "valid", "not valid")
+			if len(signature.Error) > 0 {
+				status = color.RedString(signature.Error)
+			}
+			output = append(output, fmt.Sprintf("RRSIG | %d | %s | %s by %s, %s to %s | %s",
+				signature.KeyTag, signature.Algorithm, signature.Covered, signature.Signer,
+				signature.Inception.Format(time.DateOnly), signature.Expiration.Format(time.DateOnly), status))
+		}
+		if len(output) == 1 {
+			logWithContext(ctx, chalker.DIM, "No DNSSEC records found")
+			continue
+		}
+		logWithContext(ctx, chalker.DEFAULT, columnize.SimpleFormat(output))
+	}
+
+	displayRequestHeader(ctx, chalker.BOLD, fmt.Sprintf("Chain of trust for %s...", color.CyanString(chain.Name)))
+	for _, issue := range chain.Issues {
+		switch issue.Level {
+		case dnsreport.LevelError:
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("%s: %s", issue.Name, issue.Message))
+		case dnsreport.LevelWarn:
+			logWithContext(ctx, chalker.WARN, fmt.Sprintf("%s: %s", issue.Name, issue.Message))
+		default:
+			logWithContext(ctx, chalker.INFO, fmt.Sprintf("%s: %s", issue.Name, issue.Message))
+		}
+	}
+	if chain.Secure {
+		logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("DNSSEC chain of trust is valid from the root to %s", chain.Name))
+	} else if len(chain.BrokenAt) > 0 {
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("DNSSEC chain of trust is broken at: %s", color.CyanString(chain.BrokenAt)))
+	}
+}
+
+// dnssecStatus returns the status of a record (green if ok)
+func dnssecStatus(ok bool, valid, invalid string) string {
+	if ok {
+		return color.GreenString(valid)
+	}
+	return color.YellowString(invalid)
+}
+
+func init() {
+	rootCmd.AddCommand(dnsCmd)
+
+	// Custom name server for DNS resolution
+	dnsCmd.Flags().StringVarP(&nameServer, "nameserver", "n", defaultNameServer, "DNS name server for resolving records")
+
+	// Custom service name for the SRV record
+	dnsCmd.Flags().StringVarP(&serviceName, "service", "s", paymail.DefaultServiceName, "Service name in the SRV record")
+
+	// Custom protocol for the SRV record
+	dnsCmd.Flags().StringVar(&protocol, "protocol", paymail.DefaultProtocol, "Protocol in the SRV record")
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/har"
+	"github.com/mrz1836/paymail-inspector/metrics"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Defaults for the exporter command
+const (
+	configExporterTargets   = "exporter-targets" // Config key for the targets to probe
+	defaultExporterInterval = 1 * time.Minute    // Time between each probe run
+	defaultExporterListen   = "localhost:9469"   // Default listen address for /metrics
+	metricPrefix            = "paymail_"         // Prefix for all metric names
+)
+
+// exporter probes the targets and keeps the metrics
+type exporter struct {
+	registry *metrics.Registry // All metrics
+}
+
+// exporterCmd represents the exporter command
+var exporterCmd = &cobra.Command{
+	Use:   "exporter",
+	Short: "Expose provider health and latency as Prometheus metrics",
+	Long: color.GreenString(`
+                                     __
+  ____ ___  _________   ____________/  |_  ___________
+_/ __ \\  \/  /\____ \ /  _ \_  __ \   __\/ __ \_  __ \
+\  ___/ >    < |  |_> >  <_> )  | \/|  | \  ___/|  | \/
+ \___  >__/\_ \|   __/ \____/|__|   |__|  \___  >__|
+     \/      \/|__|                           \/`) + `
+` + color.YellowString(`
+This command will probe a set of paymail addresses and domains on an interval and expose the results
+at /metrics in the Prometheus text format.
+
+Metrics include request latency histograms by trace phase, HTTP status codes, capability counts,
+SSL and DNSSEC checks (and checks that could not finish), and the time of the last successful PKI fetch (addresses).
+
+Targets are given as arguments, with --input, or in the config file (`+configExporterTargets+`).`),
+	Aliases:     []string{"metrics", "prometheus"},
+	Annotations: map[string]string{annotationLongRunning: "true", annotationSkipDatabase: "true"},
+	Example: applicationName + ` exporter mrz@` + defaultDomainName + ` ` + defaultDomainName + `
+` + applicationName + ` exporter --input paymails.txt --interval 5m --listen 0.0.0.0:9469`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// Load all the targets
+		targets, err := getWatchTargets(append(args, viper.GetStringSlice(configExporterTargets)...), inputFile)
+		if err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading targets: %s", err.Error()))
+			return
+		} else if len(targets) == 0 {
+			chalker.Log(chalker.ERROR, "exporter requires at least one paymail address or domain (arguments, --input or "+configExporterTargets+")")
+			return
+		} else if exporterInterval < minWatchInterval {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("exporter --interval must be at least %s", minWatchInterval))
+			return
+		}
+
+		e := &exporter{registry: metrics.NewRegistry()}
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", e.registry)
+		server := &http.Server{
+			Addr:              exporterListen,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		// Stop on CTRL+C (or after --timeout)
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+
+		// Probe all targets on the interval
+		go func() {
+			for {
+				for _, target := range targets {
+					if ctx.Err() != nil {
+						return
+					}
+					e.probe(ctx, target)
+				}
+				chalker.Log(chalker.DIM, fmt.Sprintf("Next probe in %s", exporterInterval))
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(exporterInterval):
+				}
+			}
+		}()
+
+		// Start the server
+		chalker.Log(chalker.SUCCESS, fmt.Sprintf("Serving metrics for %d target(s) on %s (press CTRL+C to stop)", len(targets), color.CyanString("http://"+exporterListen+"/metrics")))
+		if err = server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error starting server: %s", err.Error()))
+			return
+		}
+
+		chalker.Log(chalker.INFO, "Exporter stopped")
+	},
+}
+
+// probe will run all checks for one target and update the metrics
+//
+// The probe has its own report (logs) and recorder (every request attempt, with the status code and phases)
+func (e *exporter) probe(ctx context.Context, target string) {
+	recorder := har.NewRecorder(applicationFullName, Version, "probe "+target)
+	ctx = har.WithRecorder(withReport(ctx, newReport("probe", []string{target})), recorder)
+
+	displayRequestHeader(ctx, chalker.BOLD, fmt.Sprintf("Probing %s...", color.CyanString(target)))
+	start := time.Now()
+	snapshot := takeWatchSnapshot(ctx, target)
+	probeDuration := time.Since(start)
+
+	// Requests by status code (0 is a network error) and latency by phase (HAR milliseconds, -1 if not used)
+	for _, entry := range recorder.Archive().Log.Entries {
+		request, host := requestLabels(entry)
+		e.registry.Add(metricPrefix+"requests_total", "Requests made by status code", metrics.Labels{
+			"host": host, "request": request, "status_code": strconv.Itoa(entry.Response.Status), "target": target,
+		}, 1)
+		connect := entry.Timings.Connect
+		if connect > 0 && entry.Timings.SSL > 0 {
+			connect -= entry.Timings.SSL // HAR: connect includes ssl
+		}
+		for phase, milliseconds := range map[string]float64{
+			"dns_lookup":    entry.Timings.DNS,
+			"response":      entry.Timings.Receive,
+			"server":        entry.Timings.Wait,
+			"tcp_conn":      connect,
+			"tls_handshake": entry.Timings.SSL,
+			"total":         entry.Time,
+		} {
+			if milliseconds < 0 {
+				continue
+			}
+			e.registry.Observe(metricPrefix+"request_duration_seconds", "Request latency by trace phase", metrics.DefaultBuckets, metrics.Labels{
+				"host": host, "phase": phase, "request": request, "target": target,
+			}, milliseconds/1000)
+		}
+	}
+
+	// Results of the probe
+	labels := metrics.Labels{"target": target}
+	e.registry.Set(metricPrefix+"probe_success", "Whether the last probe succeeded (1) or failed (0)", labels, boolMetric(len(snapshot.Error) == 0))
+	e.registry.Set(metricPrefix+"probe_duration_seconds", "Duration of the last probe", labels, probeDuration.Seconds())
+	e.registry.Set(metricPrefix+"probe_timestamp_seconds", "Time of the last probe (unix)", labels, float64(snapshot.CheckedAt.Unix()))
+	if snapshot.Capabilities != nil {
+		e.registry.Set(metricPrefix+"capabilities", "Number of capabilities found", labels, float64(len(snapshot.Capabilities)))
+	}
+	e.setCheck("ssl", "Whether the SSL check passed (1) or failed (0)", target, snapshot.SSL)
+	e.setCheck("dnssec", "Whether the DNSSEC check passed (1) or failed (0)", target, snapshot.DNSSEC)
+	if len(snapshot.PubKey) > 0 {
+		e.registry.Set(metricPrefix+"pki_last_success_timestamp_seconds", "Time of the last successful PKI fetch (unix)", labels, float64(snapshot.CheckedAt.Unix()))
+	}
+}
+
+// setCheck will set the result of a check (skipped checks are not set)
+//
+// A check that could not finish (IE: a timeout) is only counted in check_error, the last result is removed
+func (e *exporter) setCheck(check, help, target, status string) {
+	if len(status) == 0 {
+		return
+	}
+	labels := metrics.Labels{"target": target}
+	e.registry.Set(metricPrefix+"check_error", "Whether the check could not finish (1) or finished (0)",
+		metrics.Labels{"check": check, "target": target}, boolMetric(status == watchStatusError))
+	if status == watchStatusError {
+		e.registry.Delete(metricPrefix+check+"_valid", labels)
+		return
+	}
+	e.registry.Set(metricPrefix+check+"_valid", help, labels, boolMetric(status == watchStatusValid))
+}
+
+// boolMetric returns 1 for true and 0 for false
+func boolMetric(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	rootCmd.AddCommand(exporterCmd)
+
+	// Set the input file
+	exporterCmd.Flags().StringVarP(&inputFile, "input", "i", "", "File of paymail addresses and domains to probe (one per line), use - for stdin")
+
+	// Set the listen address
+	exporterCmd.Flags().StringVar(&exporterListen, "listen", defaultExporterListen, "Address to serve /metrics on (host:port)")
+
+	// Set the interval
+	exporterCmd.Flags().DurationVar(&exporterInterval, "interval", defaultExporterInterval, "Time between each probe run")
+
+	// Run the DNSSEC check on the target domain
+	exporterCmd.Flags().BoolVarP(&skipDNSCheck, "skip-dnssec", "d", false, "Skip checking DNSSEC of the target domain")
+
+	// Run the SSL check on the target domain
+	exporterCmd.Flags().BoolVar(&skipSSLCheck, "skip-ssl", false, "Skip checking SSL of the target domain")
+}
+
+// requestLabels returns the name of the request (IE: capabilities) and the host it was sent to
+//
+// The full url is not used as a label, it changes per address (IE: the pki url)
+func requestLabels(entry *har.Entry) (request, host string) {
+	request = entry.Name
+	if len(request) == 0 {
+		request = "other"
+	}
+	if parsed, err := url.Parse(entry.Request.URL); err == nil {
+		host = parsed.Host
+	}
+	return request, host
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mrz1836/paymail-inspector/metrics"
+)
+
+// TestExporter_probe will test the method probe() (against the mock provider)
+func TestExporter_probe(t *testing.T) {
+	// Not parallel: points the requests at the mock provider (global flags)
+
+	startMockProvider(t, false, nil)
+	previousDNS := skipDNSCheck
+	skipDNSCheck = true
+	t.Cleanup(func() {
+		skipDNSCheck = previousDNS
+	})
+	mockHost := hostOverride
+
+	e := &exporter{registry: metrics.NewRegistry()}
+	var tests = []struct {
+		name     string
+		host     string
+		expected []string
+		missing  []string
+	}{
+		{"ssl check passed", mockHost, []string{
+			`paymail_probe_success{target="satoshi@paymail.test"} 1`,
+			`paymail_ssl_valid{target="satoshi@paymail.test"} 1`,
+			`paymail_check_error{check="ssl",target="satoshi@paymail.test"} 0`,
+			`paymail_pki_last_success_timestamp_seconds{target="satoshi@paymail.test"}`,
+			`paymail_requests_total{host="` + mockHost + `",request="capabilities",status_code="200",target="satoshi@paymail.test"} 1`,
+			`paymail_requests_total{host="` + mockHost + `",request="pki",status_code="200",target="satoshi@paymail.test"} 1`,
+			`paymail_request_duration_seconds_count{host="` + mockHost + `",phase="total",request="pki",target="satoshi@paymail.test"} 1`,
+		}, []string{
+			`url=`,
+			`paymail_pki_last_success_age_seconds`,
+			`paymail_dnssec_valid{`,
+			`paymail_check_error{check="dnssec"`,
+		}},
+		{"ssl check could not finish", "127.0.0.1:1", []string{
+			`paymail_probe_success{target="satoshi@paymail.test"} 0`,
+			`paymail_check_error{check="ssl",target="satoshi@paymail.test"} 1`,
+			`paymail_requests_total{host="127.0.0.1:1",request="capabilities",status_code="0",target="satoshi@paymail.test"} 1`,
+		}, []string{
+			`paymail_ssl_valid{target="satoshi@paymail.test"}`,
+		}},
+	}
+
+	for _, test := range tests {
+		hostOverride = test.host
+		e.probe(context.Background(), "satoshi@paymail.test")
+
+		output := new(bytes.Buffer)
+		if _, err := e.registry.WriteTo(output); err != nil {
+			t.Fatalf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.name, err.Error())
+		}
+		for _, expected := range test.expected {
+			if !strings.Contains(output.String(), expected) {
+				t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, expected, output.String())
+			}
+		}
+		for _, missing := range test.missing {
+			if strings.Contains(output.String(), missing) {
+				t.Errorf("%s Failed: [%s] inputted and no [%s] expected, received: [%s]", t.Name(), test.name, missing, output.String())
+			}
+		}
+	}
+}
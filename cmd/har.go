@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/har"
+	"github.com/spf13/cobra"
+)
+
+// harRecorder keeps all requests of the command (--har or --timing)
+var harRecorder *har.Recorder
+
+// startRecording will record all requests made with the command context (if --har or --timing is set)
+func startRecording(cmd *cobra.Command, args []string) {
+	commandStarted = time.Now()
+	if len(harFile) == 0 && !showTiming {
+		return
+	}
+	title := strings.TrimSpace(cmd.CommandPath() + " " + strings.Join(args, " "))
+	harRecorder = har.NewRecorder(applicationFullName, Version, title)
+	cmd.SetContext(har.WithRecorder(cmd.Context(), harRecorder))
+}
+
+// validateRecording will reject --har and --timing for long-running commands (all requests are kept until the command ends)
+func validateRecording(cmd *cobra.Command) error {
+	if cmd.Annotations[annotationLongRunning] == "true" && (len(harFile) > 0 || showTiming) {
+		return chalker.Error(fmt.Sprintf("--har and --timing are not supported by %s (it runs until stopped)", cmd.Name()))
+	}
+	return nil
+}
+
+// saveRecording will write the HAR file (if --har is set)
+func saveRecording() {
+	if harRecorder == nil || len(harFile) == 0 {
+		return
+	}
+	if err := harRecorder.Save(harFile); err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error saving HAR file: %s", err.Error()))
+		return
+	}
+	chalker.Log(chalker.SUCCESS, fmt.Sprintf("Saved %d request(s) to: %s", harRecorder.Len(), color.CyanString(harFile)))
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/database"
+	"github.com/mrz1836/paymail-inspector/integrations"
+	"github.com/spf13/viper"
+
+	// Built-in integrations (each package registers itself)
+	_ "github.com/mrz1836/paymail-inspector/integrations/baemail"
+	_ "github.com/mrz1836/paymail-inspector/integrations/bitpic"
+	_ "github.com/mrz1836/paymail-inspector/integrations/powping"
+	_ "github.com/mrz1836/paymail-inspector/integrations/roundesk"
+)
+
+// Integration settings (config: integrations.<name>.<setting>, env: INTEGRATIONS_<NAME>_<SETTING>)
+const (
+	configIntegrations   = "integrations" // Config key for all integration settings
+	integrationKeyPrefix = "app-"         // Cache key prefix for all integrations (app-<name>-<paymail>)
+)
+
+// skipIntegrations are the --skip-<name> flags by integration name
+var skipIntegrations = make(map[string]*bool)
+
+// integrationEnabled returns true if the integration is turned on and not skipped
+func integrationEnabled(integration integrations.Integration) bool {
+	skip, ok := skipIntegrations[integration.Name()]
+	return integration.Enabled() && (!ok || !*skip)
+}
+
+// configureIntegrations will apply the settings from the config file and environment to all integrations
+func configureIntegrations() {
+	for _, integration := range integrations.All() {
+		settings := integration.Settings()
+		key := configIntegrations + "." + integration.Name() + "."
+
+		if viper.IsSet(key + "enabled") {
+			settings.Enabled = viper.GetBool(key + "enabled")
+		}
+		if network := viper.GetString(key + "network"); len(network) > 0 {
+			settings.Network = network
+		}
+		if userAgent := viper.GetString(key + "user-agent"); len(userAgent) > 0 {
+			settings.UserAgent = userAgent
+		}
+		if viper.IsSet(key + "retries") {
+			if retries := viper.GetInt(key + "retries"); retries >= 0 {
+				settings.Retries = retries
+			} else {
+				chalker.Log(chalker.WARN, fmt.Sprintf("Invalid %sretries in config: %d (using %d)", key, retries, settings.Retries))
+			}
+		}
+		if value := viper.GetString(key + "timeout"); len(value) > 0 {
+			if timeout, err := time.ParseDuration(value); err == nil && timeout > 0 {
+				settings.Timeout = timeout
+			} else {
+				chalker.Log(chalker.WARN, fmt.Sprintf("Invalid %stimeout in config: %s (using %s)", key, value, settings.Timeout))
+			}
+		}
+
+		integration.Configure(settings)
+	}
+}
+
+// getIntegration will check an integration for a paymail address (logging, caching and tracing)
+func getIntegration(ctx context.Context, integration integrations.Integration, alias, domain string,
+	allowCache bool,
+) (result *integrations.Result, err error) {
+	// Start the request
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Checking %s for %s...", integration.Title(), color.CyanString(alias+"@"+domain)))
+
+	// Cache key
+	keyName := integrationKeyPrefix + integration.Name() + "-" + alias + "@" + domain
+
+	// Do we have caching and db?
+	if !disableCache && databaseEnabled && allowCache {
+		var item *database.Item
+		if item, err = getCache(ctx, keyName); err != nil {
+			return result, err
+		}
+		if item != nil {
+			if err = json.Unmarshal([]byte(item.Value), &result); err != nil {
+				return result, err
+			}
+			logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("%s was found %s", integration.Title(), fromCache(item)))
+			return result, err
+		}
+	}
+
+	// Check the integration
+	result, err = integration.Lookup(ctx, alias, domain, !skipTracing)
+
+	// Display the tracing results
+	if result != nil && !skipTracing {
+		for _, request := range result.Requests {
+			displayTracingResults(ctx, request.Name, alias+"@"+domain, request.Tracing, request.StatusCode)
+		}
+	}
+	if err != nil {
+		return result, requestError(ctx, integration.Name(), err)
+	}
+
+	// Success or failure
+	if result != nil && result.Found {
+		logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("%s was found", integration.Title()))
+
+		// Store in db?
+		if databaseEnabled {
+			var jsonStr []byte
+			if jsonStr, err = json.Marshal(result); err != nil {
+				return result, err
+			}
+			if err = setCache(ctx, keyName, string(jsonStr)); err != nil {
+				return result, err
+			}
+		}
+	} else {
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("%s was not found", integration.Title()))
+	}
+
+	return result, err
+}
+
+// displayIntegration will show the lines of a found integration result
+func displayIntegration(ctx context.Context, result *integrations.Result) {
+	if result == nil || !result.Found {
+		return
+	}
+	for _, field := range result.Fields {
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("%-13s: %s", field.Label, color.CyanString(field.Value)))
+	}
+}
+
+func init() {
+	// Add the cache models for all integrations
+	for _, integration := range integrations.All() {
+		cacheModels = append(cacheModels, integrationKeyPrefix+integration.Name()+"-")
+	}
+}
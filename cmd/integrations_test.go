@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/integrations"
+	"github.com/spf13/viper"
+)
+
+// TestConfigureIntegrations will test the method configureIntegrations()
+func TestConfigureIntegrations(t *testing.T) {
+	// Not parallel: sets the config, environment and the integration settings
+
+	// Restore the settings, config and logs after the test
+	previous := make(map[string]*integrations.Settings)
+	for _, integration := range integrations.All() {
+		previous[integration.Name()] = integration.Settings()
+	}
+	logs := new(bytes.Buffer)
+	previousOutput := color.Output
+	color.Output = logs
+	t.Cleanup(func() {
+		for _, integration := range integrations.All() {
+			integration.Configure(previous[integration.Name()])
+		}
+		viper.Set(configIntegrations, nil)
+		color.Output = previousOutput
+	})
+
+	// Same as initConfig()
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
+	viper.AutomaticEnv()
+
+	// Config file values
+	viper.Set(configIntegrations+".bitpic.enabled", false)
+	viper.Set(configIntegrations+".bitpic.network", "bitpic.example.com")
+	viper.Set(configIntegrations+".bitpic.user-agent", "custom-agent")
+	viper.Set(configIntegrations+".bitpic.retries", 0)
+	viper.Set(configIntegrations+".bitpic.timeout", "3s")
+	viper.Set(configIntegrations+".baemail.retries", -1)
+	viper.Set(configIntegrations+".baemail.timeout", "soon")
+	viper.Set(configIntegrations+".powping.timeout", "-5s")
+
+	// Environment values
+	t.Setenv("INTEGRATIONS_ROUNDESK_NETWORK", "https://roundesk.example.com")
+	t.Setenv("INTEGRATIONS_ROUNDESK_USER_AGENT", "env-agent")
+	t.Setenv("INTEGRATIONS_ROUNDESK_RETRIES", "5")
+	t.Setenv("INTEGRATIONS_ROUNDESK_TIMEOUT", "1m")
+
+	configureIntegrations()
+
+	// Invalid values keep the current settings
+	var tests = []struct {
+		name     string
+		expected integrations.Settings
+	}{
+		{"bitpic", integrations.Settings{
+			Enabled: false, Network: "bitpic.example.com", Retries: 0, Timeout: 3 * time.Second, UserAgent: "custom-agent",
+		}},
+		{"baemail", *previous["baemail"]},
+		{"powping", *previous["powping"]},
+		{"roundesk", integrations.Settings{
+			Enabled:   previous["roundesk"].Enabled,
+			Network:   "https://roundesk.example.com",
+			Retries:   5,
+			Timeout:   time.Minute,
+			UserAgent: "env-agent",
+		}},
+	}
+
+	for _, test := range tests {
+		integration := integrations.Get(test.name)
+		if integration == nil {
+			t.Fatalf("%s Failed: [%s] inputted and a registered integration expected", t.Name(), test.name)
+		}
+		if output := integration.Settings(); *output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%+v] expected, received: [%+v]", t.Name(), test.name, test.expected, *output)
+		}
+	}
+
+	// The invalid values are logged
+	for _, expected := range []string{
+		"Invalid integrations.baemail.retries in config: -1",
+		"Invalid integrations.baemail.timeout in config: soon",
+		"Invalid integrations.powping.timeout in config: -5s",
+	} {
+		if !strings.Contains(logs.String(), expected) {
+			t.Errorf("%s Failed: [%s] expected in the logs, received: [%s]", t.Name(), expected, logs.String())
+		}
+	}
+}
+
+// TestIntegrationsOrder will test the order of integrations.All()
+func TestIntegrationsOrder(t *testing.T) {
+	t.Parallel()
+
+	expected := "bitpic, baemail, roundesk, powping"
+	var names []string
+	for _, integration := range integrations.All() {
+		names = append(names, integration.Name())
+	}
+	if output := strings.Join(names, ", "); output != expected {
+		t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), "All()", expected, output)
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mrz1836/paymail-inspector/chalker"
+)
+
+// runJobs will run the job for every index (0 to count-1) with at most workers running at the same time
+//
+// The logs of each job are kept in its own buffer (see: logWithContext), finished is called once per job
+// (one call at a time) with the buffer to write: in the order the jobs finish, or in the order of the index (inOrder)
+func runJobs(ctx context.Context, count, workers int, inOrder bool,
+	job func(ctx context.Context, index int), finished func(index int, buffer *chalker.Buffer),
+) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Finished jobs waiting for an earlier index (inOrder)
+	var mu sync.Mutex
+	next := 0
+	waiting := make(map[int]*chalker.Buffer)
+	finish := func(index int, buffer *chalker.Buffer) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !inOrder {
+			finished(index, buffer)
+			return
+		}
+		waiting[index] = buffer
+		for buffer, ok := waiting[next]; ok; buffer, ok = waiting[next] {
+			delete(waiting, next)
+			finished(next, buffer)
+			next++
+		}
+	}
+
+	// Start the workers
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range jobs {
+				buffer := new(chalker.Buffer)
+				job(withLogBuffer(ctx, buffer), index)
+				finish(index, buffer)
+			}
+		}()
+	}
+
+	// Send all the jobs and wait for them to finish
+	for index := 0; index < count; index++ {
+		jobs <- index
+	}
+	close(jobs)
+	wg.Wait()
+}
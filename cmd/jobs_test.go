@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mrz1836/paymail-inspector/chalker"
+)
+
+// TestRunJobs will test the method runJobs()
+func TestRunJobs(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name            string
+		count           int
+		workers         int
+		inOrder         bool
+		expectedWorkers int32 // Jobs running at the same time (at most)
+	}{
+		{"no jobs", 0, 4, true, 0},
+		{"one worker", 5, 1, false, 1},
+		{"workers are clamped", 5, 0, true, 1},
+		{"negative workers", 3, -2, false, 1},
+		{"in order", 8, 4, true, 4},
+		{"as they finish", 8, 4, false, 4},
+		{"more workers than jobs", 3, 10, true, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var running, most int32
+			var mu sync.Mutex
+			buffers := make(map[int]*chalker.Buffer)
+			var finished []int
+
+			// Later jobs finish first (the first jobs sleep the longest)
+			runJobs(context.Background(), test.count, test.workers, test.inOrder,
+				func(ctx context.Context, index int) {
+					now := atomic.AddInt32(&running, 1)
+					defer atomic.AddInt32(&running, -1)
+					for {
+						if previous := atomic.LoadInt32(&most); now <= previous || atomic.CompareAndSwapInt32(&most, previous, now) {
+							break
+						}
+					}
+					time.Sleep(time.Duration(test.count-index) * 5 * time.Millisecond)
+
+					buffer, _ := ctx.Value(logBufferKey{}).(*chalker.Buffer)
+					mu.Lock()
+					buffers[index] = buffer
+					mu.Unlock()
+				},
+				func(index int, buffer *chalker.Buffer) {
+					mu.Lock()
+					defer mu.Unlock()
+					if buffer == nil || buffers[index] != buffer {
+						t.Errorf("%s Failed: [%d] inputted and the buffer of the job expected", t.Name(), index)
+					}
+					finished = append(finished, index)
+				},
+			)
+
+			// Every job finished once (in the order of the index if requested)
+			sorted := slices.Sorted(slices.Values(finished))
+			if len(finished) != test.count || (len(sorted) > 0 && (sorted[0] != 0 || sorted[len(sorted)-1] != test.count-1)) {
+				t.Errorf("%s Failed: [%d] jobs expected, received: %v", t.Name(), test.count, finished)
+			} else if test.inOrder && !slices.Equal(finished, sorted) {
+				t.Errorf("%s Failed: the jobs in order expected, received: %v", t.Name(), finished)
+			} else if !test.inOrder && test.expectedWorkers > 1 && slices.Equal(finished, sorted) {
+				t.Errorf("%s Failed: the jobs in the order they finished expected, received: %v", t.Name(), finished)
+			}
+			if most > test.expectedWorkers {
+				t.Errorf("%s Failed: at most [%d] workers expected, received: [%d]", t.Name(), test.expectedWorkers, most)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/go-resty/resty/v2"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/dnsreport"
+	"github.com/mrz1836/paymail-inspector/tlsreport"
+	"go.yaml.in/yaml/v3"
+)
+
+// Output formats for the --output flag
+const (
+	outputJSON   = "json"   // One indented JSON document
+	outputNDJSON = "ndjson" // One compact JSON document per line
+	outputText   = "text"   // Default human-readable (colored) output
+	outputYAML   = "yaml"   // One YAML document
+)
+
+// ansiEscapes is used to strip color codes from log messages stored in the report
+var ansiEscapes = regexp.MustCompile("\u001B\\[[0-9;]*m")
+
+// runReport is the structured document for the current command (rendered if --output is set)
+var runReport = newReport("", nil)
+
+// Report is the structured document emitted by every command when using --output
+type Report struct {
+	Arguments         []string                           `json:"arguments"`
+	BRFCs             []*paymail.BRFCSpec                `json:"brfcs,omitempty"`
+	Cache             []*CacheEntry                      `json:"cache,omitempty"`
+	CacheHits         []*CacheEntry                      `json:"cache_hits,omitempty"`
+	CacheStats        *CacheStats                        `json:"cache_stats,omitempty"`
+	Capabilities      *paymail.CapabilitiesPayload       `json:"capabilities,omitempty"`
+	CapabilitiesDiff  *CapabilitiesDiff                  `json:"capabilities_diff,omitempty"`
+	CapabilityDetails []*CapabilityDetail                `json:"capability_details,omitempty"`
+	Command           string                             `json:"command"`
+	Conformance       []*ConformanceResult               `json:"conformance,omitempty"`
+	DNS               *dnsreport.Report                  `json:"dns,omitempty"`
+	Entries           []*BatchEntry                      `json:"entries,omitempty"`
+	Errors            []string                           `json:"errors"`
+	P2P               *paymail.PaymentDestinationPayload `json:"p2p,omitempty"`
+	P2PSubmission     *P2PSubmission                     `json:"p2p_submission,omitempty"`
+	Paymail           *PaymailDetails                    `json:"paymail,omitempty"`
+	Paymails          []*PaymailDetails                  `json:"paymails,omitempty"`
+	Providers         []*Provider                        `json:"providers,omitempty"`
+	Timing            []*TimingStep                      `json:"timing,omitempty"`
+	Traces            []*TraceRecord                     `json:"traces"`
+	Validation        *ValidationResult                  `json:"validation,omitempty"`
+	Verification      *paymail.VerificationPayload       `json:"verification,omitempty"`
+	WatchEvents       []*WatchEvent                      `json:"watch_events,omitempty"`
+	Warnings          []string                           `json:"warnings"`
+
+	mu sync.Mutex // Protects the report from concurrent commands (whois)
+}
+
+// TraceRecord is the tracing information for one request (durations are in nanoseconds)
+type TraceRecord struct {
+	ConnIdleTime  time.Duration `json:"conn_idle_time"`
+	ConnTime      time.Duration `json:"conn_time"`
+	DNSLookup     time.Duration `json:"dns_lookup"`
+	IsConnReused  bool          `json:"is_conn_reused"`
+	IsConnWasIdle bool          `json:"is_conn_was_idle"`
+	Request       string        `json:"request"`
+	ResponseTime  time.Duration `json:"response_time"`
+	ServerTime    time.Duration `json:"server_time"`
+	StatusCode    int           `json:"status_code"`
+	Target        string        `json:"target"`
+	TCPConnTime   time.Duration `json:"tcp_conn_time"`
+	TLSHandshake  time.Duration `json:"tls_handshake"`
+	TotalTime     time.Duration `json:"total_time"`
+}
+
+// ValidationResult is the result of the validate command
+type ValidationResult struct {
+	Domain               string            `json:"domain"`
+	DNSSEC               *dnsreport.Chain  `json:"dnssec,omitempty"`
+	Paymail              string            `json:"paymail,omitempty"`
+	PubKey               string            `json:"pubkey,omitempty"`
+	RequiredCapabilities bool              `json:"required_capabilities"`
+	SRV                  *net.SRV          `json:"srv,omitempty"`
+	SRVTargets           []*SRVTarget      `json:"srv_targets,omitempty"`
+	SSL                  *bool             `json:"ssl,omitempty"`
+	Target               string            `json:"target"`
+	TLS                  *tlsreport.Report `json:"tls,omitempty"`
+}
+
+// newReport will start a new report for a command
+func newReport(command string, args []string) *Report {
+	return &Report{
+		Arguments: args,
+		Command:   command,
+		Errors:    []string{},
+		Traces:    []*TraceRecord{},
+		Warnings:  []string{},
+	}
+}
+
+// addTrace will add the tracing information for a request
+func (r *Report) addTrace(request, target string, tracing resty.TraceInfo, statusCode int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Traces = append(r.Traces, &TraceRecord{
+		ConnIdleTime:  tracing.ConnIdleTime,
+		ConnTime:      tracing.ConnTime,
+		DNSLookup:     tracing.DNSLookup,
+		IsConnReused:  tracing.IsConnReused,
+		IsConnWasIdle: tracing.IsConnWasIdle,
+		Request:       request,
+		ResponseTime:  tracing.ResponseTime,
+		ServerTime:    tracing.ServerTime,
+		StatusCode:    statusCode,
+		Target:        target,
+		TCPConnTime:   tracing.TCPConnTime,
+		TLSHandshake:  tracing.TLSHandshake,
+		TotalTime:     tracing.TotalTime,
+	})
+}
+
+// addTimingStep will add a step to the timing waterfall
+func (r *Report) addTimingStep(step *TimingStep) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Timing = append(r.Timing, step)
+}
+
+// addCacheHit will add a model that was loaded from the local database (with the time it was fetched)
+func (r *Report) addCacheHit(entry *CacheEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.CacheHits = append(r.CacheHits, entry)
+}
+
+// recordLog is the chalker hook that collects errors and warnings into the report
+func (r *Report) recordLog(level, body string) {
+	if level != chalker.ERROR && level != chalker.WARN {
+		return
+	}
+	body = strings.TrimSpace(ansiEscapes.ReplaceAllString(body, ""))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if level == chalker.ERROR {
+		r.Errors = append(r.Errors, body)
+	} else {
+		r.Warnings = append(r.Warnings, body)
+	}
+}
+
+// initOutput will route the human logs to stderr if a structured output was requested
+func initOutput() {
+	chalker.Hook = func(level, body string) {
+		runReport.recordLog(level, body)
+	}
+	if isStructuredOutput() {
+		chalker.SetOutput(os.Stderr)
+	}
+}
+
+// isTerminal returns true if the file is a terminal (not piped or redirected)
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// isStructuredOutput returns true if the user requested a machine-readable output format
+func isStructuredOutput() bool {
+	return outputFormat != outputText
+}
+
+// validateOutputFormat will check the --output flag value
+func validateOutputFormat() error {
+	switch outputFormat {
+	case outputText, outputJSON, outputNDJSON, outputYAML:
+		return nil
+	}
+	return chalker.Error(fmt.Sprintf("unknown output format: %s (use: %s, %s, %s or %s)", outputFormat, outputText, outputJSON, outputNDJSON, outputYAML))
+}
+
+// writeDocument will write any document to stdout in the requested format
+func writeDocument(document interface{}) (err error) {
+	var data []byte
+	switch outputFormat {
+	case outputJSON:
+		data, err = json.MarshalIndent(document, "", "  ")
+	case outputNDJSON:
+		data, err = json.Marshal(document)
+	case outputYAML:
+		// Go through JSON first so the keys match the json tags of all models
+		var jsonData []byte
+		if jsonData, err = json.Marshal(document); err != nil {
+			return err
+		}
+		var generic interface{}
+		if err = json.Unmarshal(jsonData, &generic); err != nil {
+			return err
+		}
+		data, err = yaml.Marshal(generic)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if outputFormat == outputYAML {
+		_, err = os.Stdout.Write(data)
+	} else {
+		_, err = fmt.Fprintln(os.Stdout, string(data))
+	}
+	return err
+}
+
+// renderReport will write the report for the current command (if a structured output was requested)
+func renderReport() {
+	if !isStructuredOutput() {
+		return
+	}
+	runReport.mu.Lock()
+	err := writeDocument(runReport)
+	runReport.mu.Unlock()
+	if err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error rendering output: %s", err.Error()))
+	}
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/mrz1836/paymail-inspector/chalker"
+)
+
+// captureStdout will return everything written to stdout while running the method
+func captureStdout(t *testing.T, method func()) string {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("%s Failed: error creating a pipe: %s", t.Name(), err.Error())
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	method()
+	_ = writer.Close()
+	var data []byte
+	if data, err = io.ReadAll(reader); err != nil {
+		t.Fatalf("%s Failed: error reading stdout: %s", t.Name(), err.Error())
+	}
+	return string(data)
+}
+
+// useOutputFormat will set the output format for the test (restored after the test)
+func useOutputFormat(t *testing.T, format string) {
+	previous := outputFormat
+	outputFormat = format
+	t.Cleanup(func() {
+		outputFormat = previous
+	})
+}
+
+// TestWriteDocument will test the method writeDocument()
+func TestWriteDocument(t *testing.T) {
+	// Not parallel: swaps stdout and the output format
+
+	type document struct {
+		Name    string `json:"name"`
+		Skipped string `json:"skipped,omitempty"`
+		Values  []int  `json:"values"`
+	}
+
+	var tests = []struct {
+		format        string
+		document      interface{}
+		expected      string
+		expectedError bool
+	}{
+		{outputText, &document{Name: "a"}, "", false},
+		{outputJSON, &document{Name: "a", Values: []int{1, 2}}, "{\n  \"name\": \"a\",\n  \"values\": [\n    1,\n    2\n  ]\n}\n", false},
+		{outputNDJSON, &document{Name: "a", Values: []int{1, 2}}, "{\"name\":\"a\",\"values\":[1,2]}\n", false},
+		{outputYAML, &document{Name: "a", Values: []int{1, 2}}, "name: a\nvalues:\n    - 1\n    - 2\n", false},
+		{outputYAML, &document{Name: "a"}, "name: a\nvalues: null\n", false},
+		{outputJSON, make(chan int), "", true},
+		{outputNDJSON, make(chan int), "", true},
+		{outputYAML, make(chan int), "", true},
+	}
+
+	for _, test := range tests {
+		useOutputFormat(t, test.format)
+		var err error
+		output := captureStdout(t, func() {
+			err = writeDocument(test.document)
+		})
+		if (err != nil) != test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted and error %v expected, received: [%v]", t.Name(), test.format, test.expectedError, err)
+		} else if output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.format, test.expected, output)
+		}
+	}
+}
+
+// TestValidateOutputFormat will test the method validateOutputFormat()
+func TestValidateOutputFormat(t *testing.T) {
+	// Not parallel: swaps the output format
+
+	var tests = []struct {
+		format        string
+		expectedError bool
+	}{
+		{outputText, false},
+		{outputJSON, false},
+		{outputNDJSON, false},
+		{outputYAML, false},
+		{"xml", true},
+		{"", true},
+	}
+
+	for _, test := range tests {
+		useOutputFormat(t, test.format)
+		if err := validateOutputFormat(); (err != nil) != test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted and error %v expected, received: [%v]", t.Name(), test.format, test.expectedError, err)
+		}
+	}
+}
+
+// TestReport_recordLog will test the method recordLog()
+func TestReport_recordLog(t *testing.T) {
+	t.Parallel()
+
+	report := newReport("test", nil)
+	var tests = []struct {
+		level string
+		body  string
+	}{
+		{chalker.ERROR, "\u001B[1;31mfailed\u001B[0m\n"},
+		{chalker.WARN, "  slow  "},
+		{chalker.INFO, "ignored"},
+		{chalker.SUCCESS, "ignored"},
+		{chalker.ERROR, "failed again"},
+	}
+
+	for _, test := range tests {
+		report.recordLog(test.level, test.body)
+	}
+
+	if !slices.Equal(report.Errors, []string{"failed", "failed again"}) {
+		t.Errorf("%s Failed: [failed, failed again] expected, received: %q", t.Name(), report.Errors)
+	}
+	if !slices.Equal(report.Warnings, []string{"slow"}) {
+		t.Errorf("%s Failed: [slow] expected, received: %q", t.Name(), report.Warnings)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/bsv-blockchain/go-paymail"
 	"github.com/fatih/color"
 	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/integrations"
 	"github.com/spf13/cobra"
 )
 
@@ -46,7 +47,9 @@ Read more at: `+color.CyanString("https://docs.moneybutton.com/docs/paymail-07-p
 		}
 		return nil
 	},
-	Run: func(_ *cobra.Command, args []string) {
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
 		// Set the domain and paymail
 		_, domain, paymailAddress := paymail.SanitizePaymail(paymail.ConvertHandle(args[0], false))
 
@@ -57,21 +60,23 @@ Read more at: `+color.CyanString("https://docs.moneybutton.com/docs/paymail-07-p
 		}
 
 		// Validate the paymail address and domain (error already shown)
-		if ok := validatePaymailAndDomain(paymailAddress, domain); !ok {
+		if ok := validatePaymailAndDomain(ctx, paymailAddress, domain); !ok {
 			return
 		}
 
 		// Get the capabilities
-		capabilities, err := getCapabilities(domain, true)
+		capabilities, err := getCapabilities(ctx, domain, true)
 		if err != nil {
-			if strings.Contains(err.Error(), "context deadline exceeded") {
-				chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s", domain))
+			if isTimeout(err) {
+				chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s (%s)", domain, err.Error()))
 			} else {
 				chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
 			}
 			return
 		}
 
+		runReport.Capabilities = &capabilities.CapabilitiesPayload
+
 		// Set the URL - Does the paymail provider have the capability?
 		destinationURL := capabilities.GetString(paymail.BRFCP2PPaymentDestination, "")
 		if len(destinationURL) == 0 {
@@ -89,28 +94,39 @@ Read more at: `+color.CyanString("https://docs.moneybutton.com/docs/paymail-07-p
 
 		// Fire the P2P request
 		var p2pResponse *paymail.PaymentDestinationResponse
-		if p2pResponse, err = getP2PPaymentDestination(destinationURL, parts[0], domain, satoshis); err != nil {
+		if p2pResponse, err = getP2PPaymentDestination(ctx, destinationURL, parts[0], domain, satoshis); err != nil {
 			chalker.Log(chalker.ERROR, fmt.Sprintf("P2P payment destination request failed: %s", err.Error()))
 			return
 		}
+		runReport.P2P = &p2pResponse.PaymentDestinationPayload
 
 		// Attempt to get a public profile if the capability is found
 		profileURL := capabilities.GetString(paymail.BRFCPublicProfile, "")
 		var profile *paymail.PublicProfileResponse
 		if len(profileURL) > 0 && !skipPublicProfile {
-			if profile, err = getPublicProfile(profileURL, parts[0], domain, true); err != nil {
+			if profile, err = getPublicProfile(ctx, profileURL, parts[0], domain, true); err != nil {
 				chalker.Log(chalker.ERROR, fmt.Sprintf("Get public profile failed: %s", err.Error()))
 			}
 		}
 
 		// Attempt to get a bitpic (if enabled)
-		var bitPicURL string
-		if !skipBitpic {
-			if bitPicURL, err = getBitPic(parts[0], domain, true); err != nil {
+		var bitpic *integrations.Result
+		if integration := integrations.Get("bitpic"); integration != nil && integrationEnabled(integration) {
+			if bitpic, err = getIntegration(ctx, integration, parts[0], domain, true); err != nil {
 				chalker.Log(chalker.ERROR, fmt.Sprintf("Checking for bitpic failed: %s", err.Error()))
 			}
 		}
 
+		// Add the profile details to the report
+		runReport.Paymail = &PaymailDetails{
+			Handle:        parts[0],
+			Provider:      &Provider{Domain: domain, Link: "https://" + domain},
+			PublicProfile: profile,
+		}
+		if bitpic != nil {
+			runReport.Paymail.Integrations = map[string]*integrations.Result{"bitpic": bitpic}
+		}
+
 		// Rendering profile information
 		displayHeader(chalker.BOLD, fmt.Sprintf("P2P information for %s", color.CyanString(paymailAddress)))
 
@@ -125,9 +141,7 @@ Read more at: `+color.CyanString("https://docs.moneybutton.com/docs/paymail-07-p
 		}
 
 		// Display bitpic if found
-		if len(bitPicURL) > 0 {
-			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Bitpic    : %s", color.CyanString(bitPicURL)))
-		}
+		displayIntegration(ctx, bitpic)
 
 		// If there is a reference
 		if len(p2pResponse.Reference) > 0 {
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/database"
+	"github.com/spf13/cobra"
+)
+
+// P2PSubmission is the link between a P2P reference and the submitted transaction (kept in the local database)
+type P2PSubmission struct {
+	Note        string    `json:"note"`
+	Paymail     string    `json:"paymail"`
+	Reference   string    `json:"reference"`
+	Sender      string    `json:"sender,omitempty"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	TxID        string    `json:"txid"`
+}
+
+// p2pSendCmd represents the p2p send command
+var p2pSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Submits a P2P transaction using a reference from a prior P2P request",
+	Long: color.YellowString(`
+This command will submit a raw transaction to the receiver's P2P transaction capability (5f1323cddf31).
+
+Use the reference from a prior P2P payment destination request (paymail p2p <address>).
+The transaction is given as hex (--tx) or read from a file (--tx-file, use - for stdin).
+
+If a --sender-key is set, the txid is signed and the pubkey is added to the metadata (unless --signature is given).
+
+The link between the reference and the submission is kept in the local database.
+
+Read more at: ` + color.CyanString("https://docs.moneybutton.com/docs/paymail-06-p2p-transactions.html")),
+	Example: applicationName + " p2p send mrz@" + defaultDomainName + ` --reference 1234 --tx 0100000001...
+` + applicationName + " p2p send mrz@" + defaultDomainName + ` --reference 1234 --tx-file tx.hex --note "Thanks!"`,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return chalker.Error("p2p send requires a paymail address")
+		} else if len(args) > 1 {
+			return chalker.Error("p2p send only supports one address at a time")
+		} else if len(p2pReference) == 0 {
+			return chalker.Error("p2p send requires a --reference (from a prior p2p request)")
+		} else if len(p2pTx) == 0 && len(p2pTxFile) == 0 {
+			return chalker.Error("p2p send requires either --tx or --tx-file")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// Set the domain and paymail
+		alias, domain, paymailAddress := paymail.SanitizePaymail(paymail.ConvertHandle(args[0], false))
+
+		// Did we get a paymail address?
+		if len(paymailAddress) == 0 {
+			chalker.Log(chalker.ERROR, "Paymail address not found or invalid")
+			return
+		}
+
+		// Validate the paymail address and domain (error already shown)
+		if ok := validatePaymailAndDomain(ctx, paymailAddress, domain); !ok {
+			return
+		}
+
+		// Load and parse the transaction
+		txHex, err := loadTransactionHex(p2pTx, p2pTxFile)
+		if err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading transaction: %s", err.Error()))
+			return
+		}
+		var tx *transaction.Transaction
+		if tx, err = transaction.NewTransactionFromHex(txHex); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Invalid transaction hex: %s", err.Error()))
+			return
+		}
+		txID := tx.TxID().String()
+
+		// Start the metadata
+		metaData := &paymail.P2PMetaData{
+			Note:      p2pNote,
+			PublicKey: p2pPubKey,
+			Sender:    flagOrConfig(p2pSenderHandle, flagSenderHandle),
+			Signature: signature,
+		}
+
+		// Sign the txid (if a sender key is set and no signature is given)
+		if senderKeyValue := flagOrConfig(p2pSenderKey, flagSenderKey); len(senderKeyValue) > 0 && len(metaData.Signature) == 0 {
+			var senderKey *ec.PrivateKey
+			if senderKey, err = loadSenderKey(senderKeyValue); err != nil {
+				chalker.Log(chalker.ERROR, fmt.Sprintf("Invalid --%s (expected WIF or hex): %s", flagSenderKey, err.Error()))
+				return
+			}
+			var txSignature []byte
+			if txSignature, err = bsm.SignMessage(senderKey, []byte(txID)); err != nil {
+				chalker.Log(chalker.ERROR, fmt.Sprintf("Error signing the txid: %s", err.Error()))
+				return
+			}
+			metaData.Signature = paymail.EncodeSignature(txSignature)
+			metaData.PublicKey = hex.EncodeToString(senderKey.PubKey().Compressed())
+			chalker.Log(chalker.SUCCESS, fmt.Sprintf("Signed the txid using --%s", flagSenderKey))
+		}
+
+		// Get the capabilities
+		var capabilities *paymail.CapabilitiesResponse
+		if capabilities, err = getCapabilities(ctx, domain, true); err != nil {
+			if isTimeout(err) {
+				chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s (%s)", domain, err.Error()))
+			} else {
+				chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+			}
+			return
+		}
+
+		runReport.Capabilities = &capabilities.CapabilitiesPayload
+
+		// Set the URL - Does the paymail provider have the capability?
+		receiveURL := capabilities.GetString(paymail.BRFCP2PTransactions, "")
+		if len(receiveURL) == 0 {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("The provider %s is missing a required capability: %s", domain, paymail.BRFCP2PTransactions))
+			return
+		}
+
+		// Submit the transaction
+		var response *paymail.P2PTransactionResponse
+		if response, err = sendP2PTransaction(ctx, receiveURL, alias, domain, &paymail.P2PTransaction{
+			Hex:       txHex,
+			MetaData:  metaData,
+			Reference: p2pReference,
+		}); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("P2P transaction request failed: %s", err.Error()))
+			return
+		}
+
+		// Keep the link between the reference and the submission
+		submission := &P2PSubmission{
+			Note:        response.Note,
+			Paymail:     paymailAddress,
+			Reference:   p2pReference,
+			Sender:      metaData.Sender,
+			SubmittedAt: time.Now().UTC(),
+			TxID:        response.TxID,
+		}
+		runReport.P2PSubmission = submission
+		if databaseEnabled {
+			if err = storeP2PSubmission(submission); err != nil {
+				chalker.Log(chalker.ERROR, fmt.Sprintf("Error storing the P2P submission: %s", err.Error()))
+			}
+		}
+
+		// Rendering the results
+		displayHeader(chalker.BOLD, fmt.Sprintf("P2P transaction for %s", color.CyanString(paymailAddress)))
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Reference : %s", color.CyanString(submission.Reference)))
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("TxID      : %s", color.CyanString(submission.TxID)))
+		if len(submission.Note) > 0 {
+			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Note      : %s", color.CyanString(submission.Note)))
+		}
+		if submission.TxID != txID {
+			chalker.Log(chalker.WARN, fmt.Sprintf("The returned txid does not match the submitted transaction: %s", txID))
+		}
+	},
+}
+
+// loadTransactionHex will load the transaction hex from the flag or a file (- for stdin)
+func loadTransactionHex(txHex, path string) (string, error) {
+	if len(txHex) > 0 {
+		return strings.TrimSpace(txHex), nil
+	}
+
+	var data []byte
+	var err error
+	if path == stdinInput {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path) //nolint:gosec // G304 - user supplied file
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// p2pReferencePrefix is the key prefix of a stored submission (p2p-reference-<alias@domain>-<reference>)
+const p2pReferencePrefix = "p2p-reference-"
+
+// storeP2PSubmission will keep the link between the reference and the submission (no expiration)
+//
+// The paymail is part of the key so the submission is purged with the address or domain
+func storeP2PSubmission(submission *P2PSubmission) error {
+	jsonStr, err := json.Marshal(submission)
+	if err != nil {
+		return err
+	}
+	return database.Set(p2pReferencePrefix+submission.Paymail+"-"+submission.Reference, string(jsonStr), 0)
+}
+
+func init() {
+	p2pCmd.AddCommand(p2pSendCmd)
+
+	// Set the raw transaction
+	p2pSendCmd.Flags().StringVar(&p2pTx, "tx", "", "Raw transaction (hex)")
+
+	// Set the raw transaction file
+	p2pSendCmd.Flags().StringVar(&p2pTxFile, "tx-file", "", "File with the raw transaction (hex), use - for stdin")
+
+	// Set the reference
+	p2pSendCmd.Flags().StringVar(&p2pReference, "reference", "", "Reference from a prior P2P payment destination request")
+
+	// Set the metadata
+	p2pSendCmd.Flags().StringVar(&p2pNote, "note", "", "A human-readable note about the payment")
+	p2pSendCmd.Flags().StringVar(&p2pSenderHandle, flagSenderHandle, "", "Sender's paymail handle (default: sender-handle from the config)")
+	p2pSendCmd.Flags().StringVar(&p2pSenderKey, flagSenderKey, "", "Sender's private key (WIF or hex) to sign the txid (default: sender-key from the config)")
+	p2pSendCmd.Flags().StringVar(&p2pPubKey, "pubkey", "", "Sender's pubkey to validate the --signature")
+	p2pSendCmd.Flags().StringVarP(&signature, "signature", "s", "", "Signature of the txid (if not using --"+flagSenderKey+")")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/database"
+)
+
+// TestStoreP2PSubmission will test the method storeP2PSubmission() (and purging it with purgeCache())
+func TestStoreP2PSubmission(t *testing.T) {
+	// Not parallel: sets the home directory, the database connection and the logs
+
+	t.Setenv("HOME", t.TempDir())
+	previousOutput := color.Output
+	color.Output = io.Discard
+	if err := database.Connect(applicationName, "test"); err != nil {
+		t.Fatalf("%s Failed: error connecting to the database: %s", t.Name(), err.Error())
+	}
+	t.Cleanup(func() {
+		_ = database.Disconnect()
+		color.Output = previousOutput
+	})
+
+	for _, submission := range []*P2PSubmission{
+		{Paymail: "mrz@moneybutton.com", Reference: "abc-123"},
+		{Paymail: "other@moneybutton.com", Reference: "def-456"},
+		{Paymail: "mrz@handcash.io", Reference: "ghi-789"},
+	} {
+		if err := storeP2PSubmission(submission); err != nil {
+			t.Fatalf("%s Failed: error storing %s: %s", t.Name(), submission.Reference, err.Error())
+		}
+	}
+
+	// Each purge removes the submissions of the address or domain
+	var tests = []struct {
+		target   string
+		expected []string
+	}{
+		{"mrz@moneybutton.com", []string{
+			"p2p-reference-mrz@handcash.io-ghi-789",
+			"p2p-reference-other@moneybutton.com-def-456",
+		}},
+		{"moneybutton.com", []string{
+			"p2p-reference-mrz@handcash.io-ghi-789",
+		}},
+		{"mrz@handcash.io", nil},
+	}
+
+	for _, test := range tests {
+		purgeCache(test.target)
+		items, err := database.List(p2pReferencePrefix)
+		if err != nil {
+			t.Fatalf("%s Failed: error listing the submissions: %s", t.Name(), err.Error())
+		}
+		var keys []string
+		for _, item := range items {
+			keys = append(keys, item.Key)
+		}
+		if !slices.Equal(keys, test.expected) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.target, test.expected, keys)
+		}
+	}
+}
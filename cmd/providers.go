@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/go-sanitize"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
+)
+
+// Sources for a provider (where the provider was loaded from)
+const (
+	providerSourceBuiltIn  = "built-in" // Default list (seed)
+	providerSourceConfig   = "config"   // providers in the config file
+	providerSourceFlag     = "flag"     // --provider flag (one run)
+	providerSourceRegistry = "registry" // providers.yaml (managed by the providers command)
+	providersFileDefault   = "providers.yaml"
+)
+
+// providerRegistry is the user-managed provider registry file
+type providerRegistry struct {
+	Providers []*Provider `yaml:"providers"`
+}
+
+// providersCmd represents the providers command
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "List, add or remove the paymail providers used by whois",
+	Long: color.GreenString(`
+                         .__   .___
+_____________  _______  _|__|__| _/ ___________  ______
+\____ \_  __ \/  _ \  \/ /  / __ |_/ __ \_  __ \/  ___/
+|  |_> >  | \(  <_> )   /|  / /_/ \  ___/|  | \/\___ \
+|   __/|__|   \____/ \_/ |__\____ |\___  >__|  /____  >
+|__|                             \/    \/           \/`) + `
+` + color.YellowString(`
+Use the [list] argument to show all providers searched by whois, with their signup links and source.
+
+Use the [add] argument to add (or update) a provider domain with an optional signup link.
+
+Use the [remove] argument to remove a provider domain.
+
+Providers are kept in a registry file (`+providersFileDefault+` in the application directory),
+which starts with the built-in list. Additional providers can be set in the config file (providers),
+or added for one run using the whois --provider flag.`),
+	Aliases:     []string{"provider"},
+	Annotations: map[string]string{annotationSkipDatabase: "true"},
+	Example: applicationName + ` providers list
+` + applicationName + ` providers add example.com https://example.com/signup
+` + applicationName + ` providers remove example.com`,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return chalker.Error("providers requires either [list], [add] or [remove]")
+		}
+		switch args[0] {
+		case "list":
+			return nil
+		case "add", "remove":
+			if len(args) < 2 {
+				return chalker.Error(fmt.Sprintf("providers %s requires a domain", args[0]))
+			}
+			return nil
+		}
+		return chalker.Error("providers requires either [list], [add] or [remove]")
+	},
+	Run: func(_ *cobra.Command, args []string) {
+		switch args[0] {
+		case "list":
+			listProviders()
+		case "add":
+			var link string
+			if len(args) > 2 {
+				link = strings.TrimSpace(args[2])
+			}
+			addProvider(args[1], link)
+		case "remove":
+			removeProvider(args[1])
+		}
+	},
+}
+
+// listProviders will display all providers (registry, config and built-in)
+func listProviders() {
+	list := getProviders(nil)
+	runReport.Providers = list
+
+	displayHeader(chalker.BOLD, fmt.Sprintf("Listing %d provider(s)...", len(list)))
+	output := []string{"Domain | Link | Source"}
+	for _, provider := range list {
+		link := provider.Link
+		if len(link) == 0 {
+			link = "-"
+		}
+		output = append(output, fmt.Sprintf("%s | %s | %s", provider.Domain, link, provider.Source))
+	}
+	chalker.Log(chalker.DEFAULT, columnize.SimpleFormat(output))
+}
+
+// addProvider will add (or update) a provider in the registry file
+func addProvider(domain, link string) {
+	if domain, _ = sanitize.Domain(domain, false, true); len(domain) == 0 {
+		chalker.Log(chalker.ERROR, "Provider domain is invalid")
+		return
+	}
+
+	list, err := loadRegistry()
+	if err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading provider registry: %s", err.Error()))
+		return
+	}
+
+	// Update an existing provider or add a new one
+	provider := findProvider(list, domain)
+	if provider != nil {
+		provider.Link = link
+	} else {
+		provider = &Provider{Domain: domain, Link: link, Source: providerSourceRegistry}
+		list = append(list, provider)
+	}
+	if err = saveRegistry(list); err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error saving provider registry: %s", err.Error()))
+		return
+	}
+	runReport.Providers = []*Provider{provider}
+
+	chalker.Log(chalker.SUCCESS, fmt.Sprintf("Saved provider: %s", color.CyanString(domain)))
+}
+
+// removeProvider will remove a provider from the registry file
+func removeProvider(domain string) {
+	domain, _ = sanitize.Domain(domain, false, true)
+
+	list, err := loadRegistry()
+	if err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading provider registry: %s", err.Error()))
+		return
+	}
+
+	// Find the provider
+	var kept []*Provider
+	for _, provider := range list {
+		if provider.Domain != domain {
+			kept = append(kept, provider)
+		}
+	}
+	if len(kept) == len(list) {
+		if findProvider(configProviders(), domain) != nil {
+			chalker.Log(chalker.WARN, fmt.Sprintf("Provider %s is set in the config file (%s)", domain, viper.ConfigFileUsed()))
+		} else {
+			chalker.Log(chalker.WARN, fmt.Sprintf("Provider not found: %s", domain))
+		}
+		return
+	}
+	if err = saveRegistry(kept); err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error saving provider registry: %s", err.Error()))
+		return
+	}
+
+	chalker.Log(chalker.SUCCESS, fmt.Sprintf("Removed provider: %s", color.CyanString(domain)))
+}
+
+// getProviders will return all providers: the registry file (or built-in list), the config file and any extra domains
+func getProviders(extraDomains []string) []*Provider {
+	list, err := loadRegistry()
+	if err != nil {
+		chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading provider registry: %s", err.Error()))
+	}
+
+	// Add the config and flag providers (skip duplicates)
+	for _, provider := range configProviders() {
+		if findProvider(list, provider.Domain) == nil {
+			list = append(list, provider)
+		}
+	}
+	for _, domain := range extraDomains {
+		if domain, _ = sanitize.Domain(domain, false, true); len(domain) > 0 && findProvider(list, domain) == nil {
+			list = append(list, &Provider{Domain: domain, Source: providerSourceFlag})
+		}
+	}
+	return list
+}
+
+// configProviders will return the providers set in the config file
+func configProviders() (list []*Provider) {
+	var configured []*Provider
+	if err := viper.UnmarshalKey("providers", &configured); err != nil {
+		chalker.Log(chalker.WARN, fmt.Sprintf("Invalid providers in config: %s", err.Error()))
+		return
+	}
+	for _, provider := range configured {
+		if provider == nil {
+			continue
+		}
+		if provider.Domain, _ = sanitize.Domain(provider.Domain, false, true); len(provider.Domain) > 0 {
+			provider.Source = providerSourceConfig
+			list = append(list, provider)
+		}
+	}
+	return
+}
+
+// findProvider will return the provider for the domain (nil if not found)
+func findProvider(list []*Provider, domain string) *Provider {
+	for _, provider := range list {
+		if provider.Domain == domain {
+			return provider
+		}
+	}
+	return nil
+}
+
+// loadRegistry will load the registry file (the built-in list is used if the file does not exist)
+func loadRegistry() ([]*Provider, error) {
+	data, err := os.ReadFile(filepath.Join(applicationDirectory, providersFileDefault))
+	if errors.Is(err, os.ErrNotExist) {
+		list := make([]*Provider, 0, len(providers))
+		for _, provider := range providers {
+			list = append(list, &Provider{Domain: provider.Domain, Link: provider.Link, Source: provider.Source})
+		}
+		return list, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	registry := new(providerRegistry)
+	if err = yaml.Unmarshal(data, registry); err != nil {
+		return nil, err
+	}
+	list := make([]*Provider, 0, len(registry.Providers))
+	for _, provider := range registry.Providers {
+		if provider != nil && len(provider.Domain) > 0 {
+			provider.Source = providerSourceRegistry
+			list = append(list, provider)
+		}
+	}
+	return list, nil
+}
+
+// saveRegistry will write the registry file
+func saveRegistry(list []*Provider) error {
+	data, err := yaml.Marshal(&providerRegistry{Providers: list})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(applicationDirectory, providersFileDefault), data, 0o600)
+}
+
+func init() {
+	rootCmd.AddCommand(providersCmd)
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/spf13/viper"
+)
+
+// useApplicationDirectory will use a new application directory for the test (and discard the logs)
+func useApplicationDirectory(t *testing.T) string {
+	previous, previousOutput := applicationDirectory, color.Output
+	applicationDirectory, color.Output = t.TempDir(), io.Discard
+	t.Cleanup(func() {
+		applicationDirectory, color.Output = previous, previousOutput
+	})
+	return applicationDirectory
+}
+
+// providerStrings returns the providers as "domain link source"
+func providerStrings(list []*Provider) []string {
+	output := make([]string, 0, len(list))
+	for _, provider := range list {
+		output = append(output, provider.Domain+" "+provider.Link+" "+provider.Source)
+	}
+	return output
+}
+
+// TestLoadRegistry will test the methods loadRegistry() and saveRegistry()
+func TestLoadRegistry(t *testing.T) {
+	// Not parallel: uses a new application directory
+
+	var tests = []struct {
+		name          string
+		contents      string // Registry file (not created if empty)
+		expected      []string
+		expectedError bool
+	}{
+		{"built-in list", "", nil, false},
+		{"registry file", "providers:\n  - domain: example.com\n    link: https://example.com/signup\n  - domain: other.com\n", []string{
+			"example.com https://example.com/signup registry",
+			"other.com  registry",
+		}, false},
+		{"empty entries are skipped", "providers:\n  - domain: \"\"\n  - null\n  - domain: example.com\n", []string{
+			"example.com  registry",
+		}, false},
+		{"empty registry", "providers: []\n", []string{}, false},
+		{"invalid registry", "providers: [", nil, true},
+	}
+
+	for _, test := range tests {
+		directory := useApplicationDirectory(t)
+		if len(test.contents) > 0 {
+			if err := os.WriteFile(filepath.Join(directory, providersFileDefault), []byte(test.contents), 0o600); err != nil {
+				t.Fatalf("%s Failed: error writing the registry: %s", t.Name(), err.Error())
+			}
+		}
+
+		list, err := loadRegistry()
+		if (err != nil) != test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted and error %v expected, received: [%v]", t.Name(), test.name, test.expectedError, err)
+			continue
+		} else if test.expectedError {
+			continue
+		}
+
+		// The built-in list is a copy
+		expected := test.expected
+		if len(test.contents) == 0 {
+			expected = providerStrings(providers)
+			if len(list) > 0 && list[0] == providers[0] {
+				t.Errorf("%s Failed: [%s] inputted and a copy of the built-in list expected", t.Name(), test.name)
+			}
+		}
+		if output := providerStrings(list); !slices.Equal(output, expected) {
+			t.Errorf("%s Failed: [%s] inputted and %q expected, received: %q", t.Name(), test.name, expected, output)
+		}
+	}
+
+	// Save and load again (the source is not saved)
+	useApplicationDirectory(t)
+	if err := saveRegistry([]*Provider{{Domain: "example.com", Link: "https://example.com", Source: providerSourceConfig}, {Domain: "other.com"}}); err != nil {
+		t.Fatalf("%s Failed: received an error saving: %s", t.Name(), err.Error())
+	}
+	list, err := loadRegistry()
+	if err != nil {
+		t.Fatalf("%s Failed: received an error loading: %s", t.Name(), err.Error())
+	}
+	if output := providerStrings(list); len(output) != 2 || output[0] != "example.com https://example.com registry" || output[1] != "other.com  registry" {
+		t.Errorf("%s Failed: the saved providers expected, received: %q", t.Name(), output)
+	}
+}
+
+// TestAddRemoveProvider will test the methods addProvider() and removeProvider()
+func TestAddRemoveProvider(t *testing.T) {
+	// Not parallel: uses a new application directory
+
+	useApplicationDirectory(t)
+	if err := saveRegistry([]*Provider{{Domain: "example.com"}}); err != nil {
+		t.Fatalf("%s Failed: received an error saving: %s", t.Name(), err.Error())
+	}
+
+	var tests = []struct {
+		name     string
+		apply    func()
+		expected []string
+	}{
+		{"add", func() { addProvider("WWW.Other.com", "https://other.com/signup") }, []string{
+			"example.com  registry", "other.com https://other.com/signup registry",
+		}},
+		{"update the link", func() { addProvider("example.com", "https://example.com/new") }, []string{
+			"example.com https://example.com/new registry", "other.com https://other.com/signup registry",
+		}},
+		{"invalid domain", func() { addProvider("", "https://example.com") }, []string{
+			"example.com https://example.com/new registry", "other.com https://other.com/signup registry",
+		}},
+		{"remove", func() { removeProvider("www.example.com") }, []string{
+			"other.com https://other.com/signup registry",
+		}},
+		{"remove a missing provider", func() { removeProvider("missing.com") }, []string{
+			"other.com https://other.com/signup registry",
+		}},
+	}
+
+	for _, test := range tests {
+		test.apply()
+		list, err := loadRegistry()
+		if err != nil {
+			t.Fatalf("%s Failed: [%s] inputted, received an error loading: %s", t.Name(), test.name, err.Error())
+		}
+		if output := providerStrings(list); !slices.Equal(output, test.expected) {
+			t.Errorf("%s Failed: [%s] inputted and %q expected, received: %q", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
+
+// TestGetProviders will test the method getProviders() (registry, config and flag providers)
+func TestGetProviders(t *testing.T) {
+	// Not parallel: uses a new application directory and sets the config
+
+	useApplicationDirectory(t)
+	if err := saveRegistry([]*Provider{{Domain: "example.com", Link: "https://example.com/signup"}}); err != nil {
+		t.Fatalf("%s Failed: received an error saving: %s", t.Name(), err.Error())
+	}
+	viper.Set("providers", []map[string]string{
+		{"domain": "example.com", "link": "https://duplicate.com"},
+		{"domain": "WWW.Config.com", "link": "https://config.com/signup"},
+		{"domain": ""},
+	})
+	t.Cleanup(func() {
+		viper.Set("providers", nil)
+	})
+
+	list := getProviders([]string{"https://flag.com", "config.com", "", "flag.com"})
+	expected := []string{
+		"example.com https://example.com/signup registry",
+		"config.com https://config.com/signup config",
+		"flag.com  flag",
+	}
+	if output := providerStrings(list); !slices.Equal(output, expected) {
+		t.Errorf("%s Failed: %q expected, received: %q", t.Name(), expected, output)
+	}
+}
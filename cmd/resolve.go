@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/bsv-blockchain/go-paymail"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/fatih/color"
 	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/integrations"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -36,156 +40,213 @@ Read more at: `+color.CyanString("http://bsvalias.org/04-01-basic-address-resolu
 ` + applicationName + " r mrz@" + defaultDomainName + `
 ` + applicationName + " r 1mrz",
 	Args: func(_ *cobra.Command, args []string) error {
-		if len(args) < 1 {
-			return chalker.Error("resolve requires either a paymail address")
+		if len(args) < 1 && len(inputFile) == 0 {
+			return chalker.Error("resolve requires either a paymail address or --input")
 		} else if len(args) > 1 {
-			return chalker.Error("resolve only supports one address at a time")
+			return chalker.Error("resolve only supports one address at a time (use --input for many)")
 		}
 		return nil
 	},
-	Run: func(_ *cobra.Command, args []string) {
-		// Extract sender parts
-		senderAlias, senderDomain, senderAddress := paymail.SanitizePaymail(viper.GetString(flagSenderHandle))
-
-		// Extract paymail parts
-		handle, domain, paymailAddress := paymail.SanitizePaymail(paymail.ConvertHandle(args[0], false))
-
-		// Did we get a paymail address?
-		if len(paymailAddress) == 0 {
-			chalker.Log(chalker.ERROR, "Paymail address not found or invalid")
-			return
-		}
-
-		// Validate the paymail address and domain (error already shown)
-		if ok := validatePaymailAndDomain(paymailAddress, domain); !ok {
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// Batch mode (file or stdin)
+		if len(inputFile) > 0 {
+			runBatch(ctx, inputFile, 1, func(ctx context.Context, fields []string, report *Report) error {
+				return resolvePaymail(ctx, fields[0], report)
+			})
 			return
 		}
 
-		// No sender handle given? (default: set to the receiver's paymail address)
-		if len(senderAddress) == 0 {
-			chalker.Log(chalker.WARN, fmt.Sprintf("The flag --%s is not set, using default: %s", flagSenderHandle, paymailAddress))
-			senderAddress = paymailAddress
-			senderAlias, senderDomain, senderAddress = paymail.SanitizePaymail(senderAddress)
-		} else { // Sender handle is set (basic validation)
-
-			// Validate the paymail address and domain (error already shown)
-			if ok := validatePaymailAndDomain(senderAddress, senderDomain); !ok {
-				return
-			}
-		}
-
-		// Get the capabilities
-		capabilities, err := getCapabilities(domain, true)
-		if err != nil {
-			if strings.Contains(err.Error(), "context deadline exceeded") {
-				chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s", domain))
-			} else {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
-			}
-			return
-		}
+		// Resolve the single address (errors are already shown)
+		_ = resolvePaymail(ctx, args[0], runReport)
+	},
+}
 
-		// Set the URL - Does the paymail provider have the capability?
-		pkiURL := capabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
-		if len(pkiURL) == 0 {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("The provider %s is missing a required capability: %s", domain, paymail.BRFCPki))
-			return
-		}
+// resolvePaymail will run the full resolution flow for one paymail address
+func resolvePaymail(ctx context.Context, address string, report *Report) (err error) {
+	// Extract sender parts
+	senderAlias, senderDomain, senderAddress := paymail.SanitizePaymail(viper.GetString(flagSenderHandle))
 
-		// Set the URL - Does the paymail provider have the capability?
-		resolveURL := capabilities.GetString(paymail.BRFCPaymentDestination, paymail.BRFCBasicAddressResolution)
-		if len(resolveURL) == 0 {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("The provider %s is missing a required capability: %s", domain, paymail.BRFCPaymentDestination))
-			return
-		}
+	// Extract paymail parts
+	handle, domain, paymailAddress := paymail.SanitizePaymail(paymail.ConvertHandle(address, false))
 
-		// Does this provider require sender validation?
-		// https://bsvalias.org/04-02-sender-validation.html
-		if capabilities.GetBool(paymail.BRFCSenderValidation, "") {
-			chalker.Log(chalker.WARN, "Sender validation is ENFORCED")
+	// Did we get a paymail address?
+	if len(paymailAddress) == 0 {
+		return inputError(ctx, "Paymail address not found or invalid")
+	}
 
-			// Start the request
-			displayHeader(chalker.DEFAULT, fmt.Sprintf("Running sender validations for %s...", color.CyanString(senderAddress)))
+	// Validate the paymail address and domain (error already shown)
+	if ok := validatePaymailAndDomain(ctx, paymailAddress, domain); !ok {
+		return &KindError{Err: fmt.Errorf("invalid paymail address: %s", paymailAddress), Kind: ErrInvalidInput}
+	}
 
-			// Required if flag is enforced
-			if len(signature) == 0 {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Missing required flag: %s - see the help section: -h", "--signature"))
+	// No sender handle given? (default: set to the receiver's paymail address)
+	if len(senderAddress) == 0 {
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("The flag --%s is not set, using default: %s", flagSenderHandle, paymailAddress))
+		senderAddress = paymailAddress
+		senderAlias, senderDomain, senderAddress = paymail.SanitizePaymail(senderAddress)
+	} else { // Sender handle is set (basic validation)
 
-				// todo: generate a real signature if possible
-				chalker.Log(chalker.WARN, fmt.Sprintf("Attempting to fake a signature for: %s...", senderAddress))
-				signature, _ = RandomHex(64)
+		// Validate the paymail address and domain (error already shown)
+		if ok := validatePaymailAndDomain(ctx, senderAddress, senderDomain); !ok {
+			return fmt.Errorf("invalid sender paymail address: %s", senderAddress)
+		}
+	}
+
+	// Get the capabilities
+	var capabilities *paymail.CapabilitiesResponse
+	if capabilities, err = getCapabilities(ctx, domain, true); err != nil {
+		if isTimeout(err) {
+			logWithContext(ctx, chalker.WARN, fmt.Sprintf("No capabilities found for: %s (%s)", domain, err.Error()))
+			return fmt.Errorf("no capabilities found for: %s: %w", domain, err)
+		}
+		return logFailure(ctx, "Error", err)
+	}
+
+	report.Capabilities = &capabilities.CapabilitiesPayload
+
+	// Set the URL - Does the paymail provider have the capability?
+	pkiURL := capabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
+	if len(pkiURL) == 0 {
+		return logError(ctx, fmt.Sprintf("The provider %s is missing a required capability: %s", domain, paymail.BRFCPki))
+	}
+
+	// Set the URL - Does the paymail provider have the capability?
+	resolveURL := capabilities.GetString(paymail.BRFCPaymentDestination, paymail.BRFCBasicAddressResolution)
+	if len(resolveURL) == 0 {
+		return logError(ctx, fmt.Sprintf("The provider %s is missing a required capability: %s", domain, paymail.BRFCPaymentDestination))
+	}
+
+	// Start the sender request (signed below if the provider enforces sender validation)
+	senderRequest := &paymail.SenderRequest{
+		Amount:       amount,
+		Dt:           time.Now().UTC().Format(time.RFC3339), // UTC is assumed
+		Purpose:      purpose,
+		SenderHandle: senderAddress,
+		SenderName:   viper.GetString(flagSenderName),
+		Signature:    signature,
+	}
+
+	// Does this provider require sender validation?
+	// https://bsvalias.org/04-02-sender-validation.html
+	if capabilities.GetBool(paymail.BRFCSenderValidation, "") {
+		logWithContext(ctx, chalker.WARN, "Sender validation is ENFORCED")
+
+		// Start the request
+		displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Running sender validations for %s...", color.CyanString(senderAddress)))
+
+		// Load the sender's signing key (if set)
+		var senderKey *ec.PrivateKey
+		if senderKeyValue := viper.GetString(flagSenderKey); len(senderKeyValue) > 0 {
+			if senderKey, err = loadSenderKey(senderKeyValue); err != nil {
+				return logError(ctx, fmt.Sprintf("Invalid --%s (expected WIF or hex): %s", flagSenderKey, err.Error()))
 			}
+		}
 
-			// Only if it's not the same (set from above ^^)
-			if senderAddress != paymailAddress {
-
-				// Get the capabilities
-				senderCapabilities, getErr := getCapabilities(senderDomain, true)
-				if getErr != nil {
-					if strings.Contains(getErr.Error(), "context deadline exceeded") {
-						chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s", domain))
-					} else {
-						chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", getErr.Error()))
-					}
-					return
+		// Sign the request if there's no signature given
+		if len(senderRequest.Signature) == 0 {
+			if senderKey != nil {
+				if err = signSenderRequest(senderRequest, senderKey); err != nil {
+					return logError(ctx, fmt.Sprintf("Error signing the sender request: %s", err.Error()))
 				}
+				logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Signed the sender request using --%s", flagSenderKey))
+			} else {
+				logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Missing required flag: %s or %s - see the help section: -h", "--signature", "--"+flagSenderKey))
+				logWithContext(ctx, chalker.WARN, fmt.Sprintf("Attempting to fake a signature for: %s...", senderAddress))
+				senderRequest.Signature, _ = RandomHex(64)
+			}
+		}
 
-				// Set the URL - Does the paymail provider have the capability?
-				senderPkiURL := senderCapabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
-				if len(senderPkiURL) == 0 {
-					chalker.Log(chalker.ERROR, fmt.Sprintf("The provider %s is missing a required capability: %s", senderDomain, paymail.BRFCPki))
-					return
-				}
+		// Get the PKI for the sender
+		var senderPki *paymail.PKIResponse
+		if senderAddress != paymailAddress {
 
-				// Get the PKI for the given address
-				var senderPki *paymail.PKIResponse
-				if senderPki, err = getPki(senderPkiURL, senderAlias, senderDomain, true); err != nil {
-					chalker.Log(chalker.ERROR, fmt.Sprintf("Find PKI Failed: %s", err.Error()))
-					return
-				} else if senderPki != nil {
-					chalker.Log(chalker.INFO, fmt.Sprintf("Found --%s %s@%s's pubkey: %s", flagSenderHandle, senderAlias, senderDomain, color.CyanString(senderPki.PubKey)))
+			// Get the capabilities
+			senderCapabilities, getErr := getCapabilities(ctx, senderDomain, true)
+			if getErr != nil {
+				if isTimeout(getErr) {
+					logWithContext(ctx, chalker.WARN, fmt.Sprintf("No capabilities found for: %s (%s)", senderDomain, getErr.Error()))
+					return fmt.Errorf("no capabilities found for: %s: %w", senderDomain, getErr)
 				}
+				return logError(ctx, fmt.Sprintf("Error: %s", getErr.Error()))
 			}
 
-			// once completed, the full sender validation will be complete
-			chalker.Log(chalker.SUCCESS, `Sender pre-validation: Passed ¯\_(ツ)_/¯`)
-		}
-
-		// Set the provider (known vs new provider)
-		provider := getProvider(domain)
-		if provider == nil {
-			provider = &Provider{Domain: domain, Link: "https://" + domain}
-		}
+			// Set the URL - Does the paymail provider have the capability?
+			senderPkiURL := senderCapabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
+			if len(senderPkiURL) == 0 {
+				return logError(ctx, fmt.Sprintf("The provider %s is missing a required capability: %s", senderDomain, paymail.BRFCPki))
+			}
 
-		// Create result
-		result := &PaymailDetails{Handle: handle, Provider: provider}
+			// Get the PKI for the given address
+			if senderPki, err = getPki(ctx, senderPkiURL, senderAlias, senderDomain, true); err != nil {
+				return logError(ctx, fmt.Sprintf("Find PKI Failed: %s", err.Error()))
+			} else if senderPki != nil {
+				logWithContext(ctx, chalker.INFO, fmt.Sprintf("Found --%s %s@%s's pubkey: %s", flagSenderHandle, senderAlias, senderDomain, color.CyanString(senderPki.PubKey)))
+			}
+		} else if senderKey != nil {
 
-		// Get the PKI for the given address
-		if result.PKI, err = getPki(pkiURL, handle, domain, true); err != nil {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Find PKI Failed: %s", err.Error()))
-			return
+			// Sender is the receiver (PKI is cached for the request below)
+			if senderPki, err = getPki(ctx, pkiURL, handle, domain, true); err != nil {
+				return logFailure(ctx, "Find PKI Failed", err)
+			}
 		}
 
-		// Attempt to resolve the address
-		if result.Resolution, err = resolveAddress(resolveURL, handle, domain, senderAddress, signature, purpose, amount); err != nil {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Address resolution failed: %s", err.Error()))
-			return
+		// The signing key must match the sender's PKI
+		if senderKey != nil && senderPki != nil {
+			senderPubKey := hex.EncodeToString(senderKey.PubKey().Compressed())
+			if senderPubKey != senderPki.PubKey {
+				return logError(ctx, fmt.Sprintf("The --%s pubkey %s does not match the PKI for %s: %s", flagSenderKey, senderPubKey, senderAddress, senderPki.PubKey))
+			}
+			logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("The --%s matches the PKI for %s", flagSenderKey, senderAddress))
 		}
 
-		// Get all the public info
-		if err = result.GetPublicInfo(capabilities); err != nil {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
-			// return
+		// Validation is only complete once the provider accepts the signature
+		if senderKey != nil || len(signature) > 0 {
+			logWithContext(ctx, chalker.SUCCESS, "Sender pre-validation: Passed")
+		} else {
+			logWithContext(ctx, chalker.SUCCESS, `Sender pre-validation: Passed ¯\_(ツ)_/¯`)
 		}
-
-		// Show the results
-		result.Display()
-	},
+	}
+
+	// Set the provider (known vs new provider)
+	provider := getProvider(domain)
+	if provider == nil {
+		provider = &Provider{Domain: domain, Link: "https://" + domain}
+	}
+
+	// Create result
+	result := &PaymailDetails{Handle: handle, Provider: provider}
+	report.Paymail = result
+
+	// Get the PKI for the given address
+	if result.PKI, err = getPki(ctx, pkiURL, handle, domain, true); err != nil {
+		return logFailure(ctx, "Find PKI Failed", err)
+	}
+
+	// Attempt to resolve the address
+	if result.Resolution, err = resolveAddress(ctx, resolveURL, handle, domain, senderRequest); err != nil {
+		return logFailure(ctx, "Address resolution failed", err)
+	}
+
+	// Get all the public info
+	if err = result.GetPublicInfo(ctx, capabilities); err != nil {
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+		// return
+	}
+
+	// Show the results
+	result.Display(ctx)
+
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(resolveCmd)
 
+	// Batch mode (file or stdin)
+	addBatchFlags(resolveCmd)
+
 	// Set the amount for the sender request
 	resolveCmd.Flags().Uint64VarP(&amount, "amount", "a", 0, "Amount in satoshis for the payment request")
 
@@ -203,21 +264,20 @@ func init() {
 	// Set the signature of the entire request
 	resolveCmd.Flags().StringVarP(&signature, "signature", "s", "", "The signature of the entire request")
 
+	// Set the sender's private key for signing the request (sender validation)
+	resolveCmd.Flags().String(flagSenderKey, "", "Sender's private key (WIF or hex) used to sign the request if sender validation is enforced")
+	er(viper.BindPFlag(flagSenderKey, resolveCmd.Flags().Lookup(flagSenderKey)))
+
 	// Skip getting the PubKey
 	resolveCmd.Flags().BoolVar(&skipPki, "skip-pki", false, "Skip the pki request")
 
 	// Skip getting public profile
 	resolveCmd.Flags().BoolVar(&skipPublicProfile, "skip-public-profile", false, "Skip the public profile request")
 
-	// Skip getting Bitpic avatar
-	resolveCmd.Flags().BoolVar(&skipBitpic, "skip-bitpic", false, "Skip trying to get an associated Bitpic")
-
-	// Skip getting Roundesk profile
-	resolveCmd.Flags().BoolVar(&skipRoundesk, "skip-roundesk", false, "Skip trying to get an associated Roundesk profile")
-
-	// Skip getting Baemail account
-	resolveCmd.Flags().BoolVar(&skipBaemail, "skip-baemail", false, "Skip trying to get an associated Baemail account")
-
-	// Skip getting PowPing account
-	resolveCmd.Flags().BoolVar(&skipPowPing, "skip-powping", false, "Skip trying to get an associated PowPing account")
+	// Skip checking each integration (IE: --skip-bitpic)
+	for _, integration := range integrations.All() {
+		skip := new(bool)
+		skipIntegrations[integration.Name()] = skip
+		resolveCmd.Flags().BoolVar(skip, "skip-"+integration.Name(), false, "Skip trying to get an associated "+integration.Title())
+	}
 }
@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/fatih/color"
 	"github.com/mrz1836/paymail-inspector/chalker"
@@ -32,33 +33,66 @@ This CLI app is used for interacting with paymail service providers.
 Help contribute via Github!
 `,
 	Version: Version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+
+		// Long-running commands would keep every request in memory
+		if err := validateRecording(cmd); err != nil {
+			return err
+		}
+
+		// Start the report for this command
+		runReport.Command = cmd.Name()
+		runReport.Arguments = args
+
+		// Cancel all requests on CTRL+C (or after --timeout)
+		startCommandContext(cmd)
+
+		// Record all requests (--har or --timing)
+		startRecording(cmd, args)
+		return nil
+	},
+	PersistentPostRun: func(_ *cobra.Command, _ []string) {
+		finishTiming()
+		renderReport()
+		saveRecording()
+	},
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	// Create a database connection (Don't require DB for now)
-	if err := database.Connect(applicationName, "db_"+applicationName); err != nil {
-		chalker.Log(chalker.ERROR, fmt.Sprintf("Error connecting to database: %s", err.Error()))
-	} else {
-		// Set this flag for caching detection
-		databaseEnabled = true
-
-		// Defer the database disconnection
-		defer func() {
-			dbErr := database.GarbageCollection()
-			if dbErr != nil {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Error in database GarbageCollection: %s", dbErr.Error()))
-			}
-
-			if dbErr = database.Disconnect(); dbErr != nil {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Error in database Disconnect: %s", dbErr.Error()))
-			}
-		}()
+	// Long-running commands (serve) do not lock the database (other commands can still use the cache)
+	if !skipDatabase() {
+		// Create a database connection (Don't require DB for now)
+		if err := database.Connect(applicationName, "db_"+applicationName); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error connecting to database: %s", err.Error()))
+		} else {
+			// Set this flag for caching detection
+			databaseEnabled = true
+
+			// Defer the database disconnection
+			defer func() {
+				dbErr := database.GarbageCollection()
+				if dbErr != nil {
+					chalker.Log(chalker.ERROR, fmt.Sprintf("Error in database GarbageCollection: %s", dbErr.Error()))
+				}
+
+				if dbErr = database.Disconnect(); dbErr != nil {
+					chalker.Log(chalker.ERROR, fmt.Sprintf("Error in database Disconnect: %s", dbErr.Error()))
+				}
+			}()
+		}
 	}
 
 	// Run root command
-	er(rootCmd.Execute())
+	defer stopCommandContext()
+	if err := rootCmd.Execute(); err != nil {
+		saveRecording() // The post run is skipped when the command fails
+		er(err)
+	}
 
 	// Generate documentation from all commands
 	if generateDocs {
@@ -74,3 +108,9 @@ func Execute() {
 		}
 	}
 }
+
+// skipDatabase returns true if the command being run does not use the local database
+func skipDatabase() bool {
+	command, _, err := rootCmd.Find(os.Args[1:])
+	return err == nil && command.Annotations[annotationSkipDatabase] == "true"
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/mock"
+	"github.com/spf13/cobra"
+)
+
+// Defaults for the serve command
+const (
+	defaultServeFile     = "serve.yaml"     // Mock provider config file (in the application directory)
+	defaultServeListen   = "localhost:3443" // Default listen address for the mock provider
+	serveShutdownTimeout = 5 * time.Second  // Time allowed for open requests on shutdown
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Start a local mock paymail provider",
+	Long: color.GreenString(`
+
+  ______ ______________  __ ____
+ /  ___// __ \_  __ \  \/ // __ \
+ \___ \\  ___/|  | \/\   /\  ___/
+/____  >\___  >__|    \_/  \___  >
+     \/     \/                 \/`) + `
+` + color.YellowString(`
+This command will start a local bsvalias (paymail) provider for offline development and testing.
+
+The provider serves the capabilities document (.well-known/bsvalias), PKI, public profile,
+address resolution, P2P payment destinations and verify-pubkey for all aliases in a YAML file.
+
+Point any other command at the provider using the global flags: `+color.CyanString("--host localhost:3443 --insecure")+`
+
+A self-signed certificate is generated for every session unless --cert and --key are set.`),
+	Aliases:     []string{"mock"},
+	Annotations: map[string]string{annotationLongRunning: "true", annotationSkipDatabase: "true"},
+	Example: applicationName + ` serve
+` + applicationName + ` serve --file serve-example.yaml
+` + applicationName + ` serve --listen 0.0.0.0:3443 --cert cert.pem --key key.pem`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, _ []string) {
+		// Load the mock provider config
+		config, err := mock.LoadConfig(serveFile)
+		if err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading serve file %s: %s", serveFile, err.Error()))
+			return
+		}
+
+		// Get the public base url from the listen address
+		var host, listenPort string
+		if host, listenPort, err = net.SplitHostPort(serveListen); err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Invalid listen address %s: %s", serveListen, err.Error()))
+			return
+		}
+		if len(host) == 0 || host == "0.0.0.0" || host == "::" {
+			host = "localhost"
+		}
+		hostPort := net.JoinHostPort(host, listenPort)
+
+		// Load the certificate (or make a self-signed one)
+		var certificate tls.Certificate
+		if len(serveCert) > 0 || len(serveKey) > 0 {
+			certificate, err = tls.LoadX509KeyPair(serveCert, serveKey)
+		} else {
+			certificate, err = mock.SelfSignedCertificate([]string{host, "localhost", "127.0.0.1"})
+		}
+		if err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading certificate: %s", err.Error()))
+			return
+		}
+
+		// Create the server
+		provider := mock.NewServer(config, "https://"+hostPort)
+		server := &http.Server{
+			Addr:              serveListen,
+			Handler:           logServeRequests(provider),
+			ReadHeaderTimeout: 10 * time.Second,
+			TLSConfig:         &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12},
+		}
+
+		// Display the aliases
+		displayHeader(chalker.DEFAULT, fmt.Sprintf("Serving %d alias(es) for %s...", len(config.Aliases), color.CyanString(config.Domain)))
+		for _, alias := range config.Aliases {
+			chalker.Log(chalker.INFO, fmt.Sprintf("%s: %s %s: %s", color.WhiteString("Paymail"), color.CyanString(alias.Alias+"@"+config.Domain), color.WhiteString("PubKey"), color.YellowString(alias.PubKeyHex())))
+		}
+		if config.SenderValidation {
+			chalker.Log(chalker.INFO, "Sender validation is enforced (only local aliases can sign requests)")
+		}
+
+		// Display example commands
+		displayHeader(chalker.DEFAULT, "Try it out...")
+		example := config.Aliases[0].Alias + "@" + config.Domain
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("%s capabilities %s --host %s --insecure", applicationName, config.Domain, hostPort))
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("%s resolve %s --host %s --insecure", applicationName, example, hostPort))
+
+		// Stop on CTRL+C (or after --timeout)
+		go func() {
+			<-cmd.Context().Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+			defer cancel()
+			_ = server.Shutdown(shutdownCtx)
+		}()
+
+		// Start the server
+		chalker.Log(chalker.SUCCESS, fmt.Sprintf("Listening on %s (press CTRL+C to stop)", color.CyanString("https://"+hostPort)))
+		if err = server.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error starting server: %s", err.Error()))
+			return
+		}
+
+		chalker.Log(chalker.INFO, "Server stopped")
+	},
+}
+
+// logServeRequests will log every request made to the server (written only, the report of the command does not keep them)
+func logServeRequests(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(recorder, r)
+
+		level := chalker.INFO
+		if recorder.status >= http.StatusBadRequest {
+			level = chalker.WARN
+		}
+		chalker.Write(level, fmt.Sprintf("%s %s %d %s", r.Method, r.URL.Path, recorder.status, time.Since(start).Round(time.Microsecond)))
+	})
+}
+
+// statusRecorder keeps the status code of a response (for logging)
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader will keep the status code
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	// Set the config file
+	serveCmd.Flags().StringVarP(&serveFile, "file", "f", filepath.Join(applicationDirectory, defaultServeFile), "YAML file of aliases and keys")
+
+	// Set the listen address
+	serveCmd.Flags().StringVar(&serveListen, "listen", defaultServeListen, "Address to listen on (host:port)")
+
+	// Set a custom certificate
+	serveCmd.Flags().StringVar(&serveCert, "cert", "", "TLS certificate file (PEM), default is a self-signed certificate")
+
+	// Set a custom certificate key
+	serveCmd.Flags().StringVar(&serveKey, "key", "", "TLS key file (PEM), required with --cert")
+}
@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/database"
+	"github.com/ryanuber/columnize"
+)
+
+// ErrSRVUnavailable is returned when the domain publishes a "." SRV target (RFC 2782: the service is not available)
+var ErrSRVUnavailable = errors.New("srv target is \".\", the service is decidedly not available")
+
+// SRVTarget is the health of one SRV target (validate)
+type SRVTarget struct {
+	Capabilities bool   `json:"capabilities"`
+	Error        string `json:"error,omitempty"`
+	Healthy      bool   `json:"healthy"`
+	Port         uint16 `json:"port"`
+	Priority     uint16 `json:"priority"`
+	Target       string `json:"target"`
+	Valid        bool   `json:"valid"`
+	Weight       uint16 `json:"weight"`
+}
+
+// getSrvRecords will return all SRV records for the domain (sorted by priority and weight)
+//
+// If no record is found, the paymail default (domain:443) is returned (see: http://bsvalias.org/02-01-host-discovery.html)
+func getSrvRecords(ctx context.Context, domain string, allowCache bool) (records []*net.SRV, err error) {
+	// Start the request
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Retrieving SRV records for %s...", color.CyanString(domain)))
+
+	// Cache key
+	keyName := "model-srv-" + domain
+
+	// Do we have cache and db? (a single record from an older version is a miss)
+	if !disableCache && databaseEnabled && allowCache {
+		var item *database.Item
+		if item, err = getCache(ctx, keyName); err != nil {
+			return records, err
+		}
+		if item != nil && json.Unmarshal([]byte(item.Value), &records) == nil && len(records) > 0 {
+			logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Found [%d] SRV record(s) %s", len(records), fromCache(item)))
+			displaySrvRecords(ctx, records)
+			return records, err
+		}
+		records = nil
+	}
+
+	// New Client
+	var client paymail.ClientInterface
+	if client, err = newPaymailClient(ctx, false, nameServer); err != nil {
+		return records, err
+	}
+
+	// Use the defaults from the paymail specs
+	service, proto := serviceName, protocol
+	if len(service) == 0 {
+		service = paymail.DefaultServiceName
+	}
+	if len(proto) == 0 {
+		proto = paymail.DefaultProtocol
+	}
+	proto = strings.TrimSpace(strings.ToLower(proto))
+
+	// Look up all the records
+	cname, found, lookupErr := client.GetResolver().LookupSRV(ctx, service, proto, domain)
+	if lookupErr != nil || len(found) == 0 {
+		if ctx.Err() != nil {
+			return records, requestError(ctx, "srv", lookupErr)
+		}
+		logWithContext(ctx, chalker.DIM, fmt.Sprintf("No SRV record found, using the default: %s:%d", domain, paymail.DefaultPort))
+		records = []*net.SRV{{
+			Port:     uint16(paymail.DefaultPort),
+			Priority: uint16(paymail.DefaultPriority),
+			Target:   domain,
+			Weight:   uint16(paymail.DefaultWeight),
+		}}
+		return records, err
+	}
+
+	// Basic CNAME check (sanity check!)
+	if cnameCheck := fmt.Sprintf("_%s._%s.%s.", service, proto, domain); cname != cnameCheck {
+		err = fmt.Errorf("using: %s and expected: %s: %w", cnameCheck, cname, paymail.ErrSRVInvalidCNAME)
+		return records, err
+	}
+
+	// A single "." target means the service is not available (RFC 2782)
+	if len(found) == 1 && found[0].Target == "." {
+		return records, ErrSRVUnavailable
+	}
+
+	// Remove any period on the end
+	for _, record := range found {
+		record.Target = strings.TrimSuffix(record.Target, ".")
+		records = append(records, record)
+	}
+	sortSrvRecords(records)
+
+	logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Found [%d] SRV record(s)", len(records)))
+	displaySrvRecords(ctx, records)
+
+	// Store in db?
+	if databaseEnabled {
+		var jsonStr []byte
+		if jsonStr, err = json.Marshal(records); err != nil {
+			return records, err
+		}
+		if err = setCache(ctx, keyName, string(jsonStr)); err != nil {
+			return records, err
+		}
+	}
+
+	return records, err
+}
+
+// displaySrvRecords will show all SRV records in a table
+func displaySrvRecords(ctx context.Context, records []*net.SRV) {
+	output := []string{"Priority | Weight | Port | Target"}
+	for _, record := range records {
+		output = append(output, fmt.Sprintf("%d | %d | %d | %s", record.Priority, record.Weight, record.Port, record.Target))
+	}
+	logWithContext(ctx, chalker.DEFAULT, columnize.SimpleFormat(output))
+}
+
+// sortSrvRecords will sort the records by priority (lowest first), weight (highest first) and target
+func sortSrvRecords(records []*net.SRV) {
+	sort.SliceStable(records, func(i, j int) bool {
+		if records[i].Priority != records[j].Priority {
+			return records[i].Priority < records[j].Priority
+		} else if records[i].Weight != records[j].Weight {
+			return records[i].Weight > records[j].Weight
+		}
+		return records[i].Target < records[j].Target
+	})
+}
+
+// selectSrvTargets returns the order to try the targets in (RFC 2782)
+//
+// The lowest priority is tried first, targets with the same priority are picked by weighted random selection
+func selectSrvTargets(records []*net.SRV) []*net.SRV {
+	sorted := make([]*net.SRV, len(records))
+	copy(sorted, records)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+
+	ordered := make([]*net.SRV, 0, len(sorted))
+	for start := 0; start < len(sorted); {
+		end := start
+		for end < len(sorted) && sorted[end].Priority == sorted[start].Priority {
+			end++
+		}
+		ordered = append(ordered, weightedSrvOrder(sorted[start:end])...)
+		start = end
+	}
+	return ordered
+}
+
+// srvRandom returns a random number from 0 to n-1 for the weighted selection (tests use a seeded source)
+var srvRandom = rand.IntN //nolint:gosec // G404 - not used for security
+
+// weightedSrvOrder will order the records (same priority) by weighted random selection (RFC 2782)
+func weightedSrvOrder(records []*net.SRV) []*net.SRV {
+	// Records with a weight of zero are placed first (a very small chance of being picked first)
+	remaining := make([]*net.SRV, 0, len(records))
+	for _, record := range records {
+		if record.Weight == 0 {
+			remaining = append(remaining, record)
+		}
+	}
+	for _, record := range records {
+		if record.Weight > 0 {
+			remaining = append(remaining, record)
+		}
+	}
+
+	// Pick the first record where the running sum of the weights reaches a random number (0 to the total weight)
+	ordered := make([]*net.SRV, 0, len(remaining))
+	for len(remaining) > 0 {
+		total := 0
+		for _, record := range remaining {
+			total += int(record.Weight)
+		}
+		pick, running := srvRandom(total+1), 0
+		for index, record := range remaining {
+			if running += int(record.Weight); running >= pick {
+				ordered = append(ordered, record)
+				remaining = append(remaining[:index], remaining[index+1:]...)
+				break
+			}
+		}
+	}
+	return ordered
+}
+
+// srvTargetsString returns the targets as host:port (in order)
+func srvTargetsString(records []*net.SRV) string {
+	targets := make([]string, 0, len(records))
+	for _, record := range records {
+		targets = append(targets, fmt.Sprintf("%s:%d", record.Target, record.Port))
+	}
+	return strings.Join(targets, ", ")
+}
+
+// healthyTargetsFirst returns the checked targets with the healthy targets first (each keeps its RFC 2782 order)
+//
+// The first target is the one used for the next checks, the rest are the failover order
+func healthyTargetsFirst(targets []*SRVTarget) []*net.SRV {
+	ordered := make([]*net.SRV, 0, len(targets))
+	for _, healthy := range []bool{true, false} {
+		for _, target := range targets {
+			if target.Healthy == healthy {
+				ordered = append(ordered, &net.SRV{Port: target.Port, Priority: target.Priority, Target: target.Target, Weight: target.Weight})
+			}
+		}
+	}
+	return ordered
+}
+
+// checkSrvTargets will check the health of each target in RFC 2782 order (valid record, resolves, serves capabilities)
+//
+// The --port, --priority and --weight flags apply to a single record, or to all records if they are not the defaults
+func checkSrvTargets(ctx context.Context, client paymail.ClientInterface, records []*net.SRV) (targets []*SRVTarget) {
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Checking %d SRV target(s)...", len(records)))
+
+	for _, record := range selectSrvTargets(records) {
+		target := &SRVTarget{
+			Port:     record.Port,
+			Priority: record.Priority,
+			Target:   record.Target,
+			Weight:   record.Weight,
+		}
+		targets = append(targets, target)
+		name := fmt.Sprintf("%s:%d", record.Target, record.Port)
+
+		// Validate the record (port, priority, weight and resolving the target)
+		expectPort, expectPriority, expectWeight := record.Port, record.Priority, record.Weight
+		if len(records) == 1 || port != uint16(paymail.DefaultPort) {
+			expectPort = port
+		}
+		if len(records) == 1 || priority != uint16(paymail.DefaultPriority) {
+			expectPriority = priority
+		}
+		if len(records) == 1 || weight != uint16(paymail.DefaultWeight) {
+			expectWeight = weight
+		}
+		var problems []string
+		if err := client.ValidateSRVRecord(ctx, record, expectPort, expectPriority, expectWeight); err != nil {
+			problems = append(problems, requestError(ctx, "srv", err).Error())
+		} else {
+			target.Valid = true
+		}
+
+		// Serves the capabilities?
+		capabilities, err := client.GetCapabilities(record.Target, int(record.Port))
+		if capabilities != nil && !skipTracing && capabilities.StatusCode > 0 {
+			displayTracingResults(ctx, "srv-target", name, capabilities.Tracing, capabilities.StatusCode)
+		}
+		if err != nil {
+			problems = append(problems, "capabilities: "+requestError(ctx, "capabilities", err).Error())
+		} else {
+			target.Capabilities = true
+		}
+
+		// Show the result of the target
+		target.Healthy = target.Valid && target.Capabilities
+		if target.Healthy {
+			logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Target %s (priority %d, weight %d) is healthy", name, record.Priority, record.Weight))
+		} else {
+			target.Error = strings.Join(problems, "; ")
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Target %s (priority %d, weight %d) is unhealthy: %s", name, record.Priority, record.Weight, target.Error))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return targets
+}
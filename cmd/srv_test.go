@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"math/rand/v2"
+	"net"
+	"slices"
+	"testing"
+)
+
+// srvTargets returns the targets of the records (in order)
+func srvTargets(records []*net.SRV) (targets []string) {
+	for _, record := range records {
+		targets = append(targets, record.Target)
+	}
+	return targets
+}
+
+// useSrvRandom will replace the random source for the test
+func useSrvRandom(t *testing.T, random func(n int) int) {
+	original := srvRandom
+	srvRandom = random
+	t.Cleanup(func() {
+		srvRandom = original
+	})
+}
+
+// TestWeightedSrvOrder will test the method weightedSrvOrder()
+func TestWeightedSrvOrder(t *testing.T) {
+	records := []*net.SRV{
+		{Target: "heavy", Weight: 30},
+		{Target: "zero-a", Weight: 0},
+		{Target: "light", Weight: 10},
+		{Target: "zero-b", Weight: 0},
+	}
+
+	var tests = []struct {
+		name     string
+		random   func(n int) int
+		expected []string
+	}{
+		{"lowest pick", func(int) int { return 0 }, []string{"zero-a", "zero-b", "heavy", "light"}},
+		{"highest pick", func(n int) int { return n - 1 }, []string{"light", "heavy", "zero-a", "zero-b"}},
+		{"first weighted pick", func(n int) int { return min(1, n-1) }, []string{"heavy", "light", "zero-a", "zero-b"}},
+	}
+
+	for _, test := range tests {
+		useSrvRandom(t, test.random)
+		output := srvTargets(weightedSrvOrder(records))
+		if !slices.Equal(output, test.expected) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.name, test.expected, output)
+		}
+	}
+
+	// The input is not changed
+	if output := srvTargets(records); !slices.Equal(output, []string{"heavy", "zero-a", "light", "zero-b"}) {
+		t.Errorf("%s Failed: the records were changed: [%v]", t.Name(), output)
+	}
+}
+
+// TestWeightedSrvOrderDistribution will test the method weightedSrvOrder() (seeded, heavier targets are picked first more often)
+func TestWeightedSrvOrderDistribution(t *testing.T) {
+	useSrvRandom(t, rand.New(rand.NewPCG(1, 2)).IntN) //nolint:gosec // G404 - seeded for the test
+
+	records := []*net.SRV{
+		{Target: "light", Weight: 10},
+		{Target: "heavy", Weight: 30},
+		{Target: "zero", Weight: 0},
+	}
+
+	const runs = 10000
+	first := make(map[string]int)
+	for i := 0; i < runs; i++ {
+		ordered := weightedSrvOrder(records)
+		if len(ordered) != len(records) {
+			t.Fatalf("%s Failed: expected %d records, received: %d", t.Name(), len(records), len(ordered))
+		}
+		first[ordered[0].Target]++
+	}
+
+	// Expected: heavy 30/41, light 10/41 and zero 1/41 (the pick is from 0 to the total weight)
+	var tests = []struct {
+		target   string
+		min, max int
+	}{
+		{"heavy", 7000, 7600},
+		{"light", 2200, 2700},
+		{"zero", 100, 400},
+	}
+	for _, test := range tests {
+		if first[test.target] < test.min || first[test.target] > test.max {
+			t.Errorf("%s Failed: [%s] expected first %d to %d times, received: %d", t.Name(), test.target, test.min, test.max, first[test.target])
+		}
+	}
+}
+
+// TestSelectSrvTargets will test the method selectSrvTargets()
+func TestSelectSrvTargets(t *testing.T) {
+	useSrvRandom(t, func(int) int { return 0 })
+
+	var tests = []struct {
+		name     string
+		records  []*net.SRV
+		expected []string
+	}{
+		{"empty", []*net.SRV{}, nil},
+		{"one record", []*net.SRV{{Target: "a", Priority: 10, Weight: 10}}, []string{"a"}},
+		{"lowest priority first", []*net.SRV{
+			{Target: "backup", Priority: 20, Weight: 10},
+			{Target: "main", Priority: 10, Weight: 10},
+		}, []string{"main", "backup"}},
+		{"same priority keeps zero weight first", []*net.SRV{
+			{Target: "weighted", Priority: 10, Weight: 5},
+			{Target: "backup", Priority: 30, Weight: 0},
+			{Target: "zero", Priority: 10, Weight: 0},
+		}, []string{"zero", "weighted", "backup"}},
+		{"groups by priority", []*net.SRV{
+			{Target: "c", Priority: 2, Weight: 1},
+			{Target: "a", Priority: 1, Weight: 1},
+			{Target: "d", Priority: 2, Weight: 1},
+			{Target: "b", Priority: 1, Weight: 1},
+		}, []string{"a", "b", "c", "d"}},
+	}
+
+	for _, test := range tests {
+		if output := srvTargets(selectSrvTargets(test.records)); !slices.Equal(output, test.expected) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
+
+// TestHealthyTargetsFirst will test the method healthyTargetsFirst()
+func TestHealthyTargetsFirst(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		targets  []*SRVTarget
+		expected []string
+	}{
+		{"empty", []*SRVTarget{}, nil},
+		{"all healthy keep the order", []*SRVTarget{
+			{Target: "a", Healthy: true}, {Target: "b", Healthy: true},
+		}, []string{"a", "b"}},
+		{"healthy first", []*SRVTarget{
+			{Target: "a"}, {Target: "b", Healthy: true}, {Target: "c"}, {Target: "d", Healthy: true},
+		}, []string{"b", "d", "a", "c"}},
+		{"none healthy keep the order", []*SRVTarget{
+			{Target: "a"}, {Target: "b"},
+		}, []string{"a", "b"}},
+	}
+
+	for _, test := range tests {
+		if output := srvTargets(healthyTargetsFirst(test.targets)); !slices.Equal(output, test.expected) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.name, test.expected, output)
+		}
+	}
+
+	// The record is kept (port, priority and weight)
+	output := healthyTargetsFirst([]*SRVTarget{{Healthy: true, Port: 8443, Priority: 10, Target: "a", Weight: 5}})
+	if output[0].Port != 8443 || output[0].Priority != 10 || output[0].Weight != 5 {
+		t.Errorf("%s Failed: the port, priority and weight expected, received: [%+v]", t.Name(), output[0])
+	}
+}
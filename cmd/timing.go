@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/har"
+	"github.com/ryanuber/columnize"
+)
+
+// Waterfall layout
+const (
+	timingNameWidth = 48 // Longer step names are cut
+	timingWidth     = 50 // Characters for the whole command
+)
+
+// commandStarted is when the command started (the waterfall starts here)
+var commandStarted time.Time
+
+// TimingStep is one step of the command in the timing waterfall (durations are in nanoseconds)
+type TimingStep struct {
+	Cached   bool          `json:"cached,omitempty"` // Loaded from the local database
+	Connect  time.Duration `json:"connect"`
+	DNS      time.Duration `json:"dns"`
+	Error    string        `json:"error,omitempty"`
+	Local    bool          `json:"local,omitempty"` // Not an HTTP request (IE: a DNS lookup or a cache read)
+	Name     string        `json:"name"`
+	Start    time.Duration `json:"start"` // Since the command started
+	Status   int           `json:"status,omitempty"`
+	TLS      time.Duration `json:"tls"`
+	Total    time.Duration `json:"total"`
+	Transfer time.Duration `json:"transfer"`
+	TTFB     time.Duration `json:"ttfb"`
+}
+
+// timeStep will add a step that is not an HTTP request (if --timing is set)
+func timeStep(name string, start time.Time, cached bool, err error) {
+	if !showTiming {
+		return
+	}
+	step := &TimingStep{Cached: cached, Local: true, Name: name, Start: start.Sub(commandStarted), Total: time.Since(start)}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	runReport.addTimingStep(step)
+}
+
+// finishTiming will add the HTTP requests to the steps and show the waterfall (if --timing is set)
+func finishTiming() {
+	if !showTiming || harRecorder == nil {
+		return
+	}
+	for _, entry := range harRecorder.Archive().Log.Entries {
+		runReport.addTimingStep(newTimingStep(entry))
+	}
+
+	runReport.mu.Lock()
+	sort.SliceStable(runReport.Timing, func(i, j int) bool {
+		return runReport.Timing[i].Start < runReport.Timing[j].Start
+	})
+	steps := runReport.Timing
+	runReport.mu.Unlock()
+
+	displayTiming(steps, time.Since(commandStarted))
+}
+
+// newTimingStep will convert a recorded request (HAR timings are in milliseconds, -1 if not used)
+func newTimingStep(entry *har.Entry) *TimingStep {
+	name := entry.Request.Method + " " + entry.Request.URL
+	if parsed, err := url.Parse(entry.Request.URL); err == nil {
+		name = entry.Request.Method + " " + parsed.Host + parsed.Path
+	}
+	if entry.Attempt > 1 {
+		name += fmt.Sprintf(" (attempt %d)", entry.Attempt)
+	}
+	ssl := harDuration(entry.Timings.SSL)
+	return &TimingStep{
+		Connect:  harDuration(entry.Timings.Connect) - ssl, // HAR: connect includes ssl
+		DNS:      harDuration(entry.Timings.DNS),
+		Error:    entry.Error,
+		Name:     name,
+		Start:    entry.StartedDateTime.Sub(commandStarted),
+		Status:   entry.Response.Status,
+		TLS:      ssl,
+		Total:    harDuration(entry.Time),
+		Transfer: harDuration(entry.Timings.Receive),
+		TTFB:     harDuration(entry.Timings.Wait),
+	}
+}
+
+// harDuration returns the HAR milliseconds as a duration (not used is zero)
+func harDuration(milliseconds float64) time.Duration {
+	if milliseconds <= 0 {
+		return 0
+	}
+	return time.Duration(milliseconds * float64(time.Millisecond))
+}
+
+// displayTiming will show each step on a timeline (with the phases of each request) and where the time went
+func displayTiming(steps []*TimingStep, elapsed time.Duration) {
+	displayHeader(chalker.BOLD, fmt.Sprintf("Timing for %d step(s) in %s...", len(steps), elapsed.Round(time.Millisecond)))
+	if len(steps) == 0 {
+		chalker.Log(chalker.DIM, "No requests were made")
+		return
+	}
+
+	// The slowest step (and the end of the timeline)
+	slowest := steps[0]
+	end := elapsed
+	for _, step := range steps {
+		if step.Total > slowest.Total {
+			slowest = step
+		}
+		if finish := step.Start + step.Total; finish > end {
+			end = finish
+		}
+	}
+
+	// The waterfall
+	output := []string{"# | Step | Start | Total | Waterfall"}
+	for index, step := range steps {
+		name := step.Name
+		if len(name) > timingNameWidth {
+			name = name[:timingNameWidth-3] + "..."
+		}
+		bar := timingBar(step, end)
+		if step == slowest {
+			bar += color.RedString(" <- slowest")
+		}
+		output = append(output, fmt.Sprintf("%d | %s | %s | %s | %s", index+1, name, timingDuration(step.Start), timingDuration(step.Total), bar))
+	}
+	chalker.Log(chalker.DEFAULT, columnize.SimpleFormat(output))
+	chalker.Log(chalker.DIM, fmt.Sprintf("Legend: %s DNS, %s connect, %s TLS, %s TTFB (server), %s transfer, %s local (DNS lookup or check), %s cache hit, %s failed",
+		color.CyanString("d"), color.YellowString("c"), color.MagentaString("t"), color.GreenString("w"), color.BlueString("r"), "=", "*", color.RedString("x")))
+
+	// Where the time went (all steps, IE: requests made at the same time are counted twice)
+	var dns, connect, tls, ttfb, transfer, local, total time.Duration
+	cached := 0
+	for _, step := range steps {
+		total += step.Total
+		if step.Cached {
+			cached++
+		}
+		if step.Local {
+			local += step.Total
+			continue
+		}
+		dns += step.DNS
+		connect += step.Connect
+		tls += step.TLS
+		ttfb += step.TTFB
+		transfer += step.Transfer
+	}
+	summary := []string{"Phase | Time | Share"}
+	for _, phase := range []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"DNS", dns},
+		{"Connect", connect},
+		{"TLS", tls},
+		{"TTFB (server)", ttfb},
+		{"Transfer", transfer},
+		{"Local", local},
+	} {
+		summary = append(summary, fmt.Sprintf("%s | %s | %s", phase.name, timingDuration(phase.duration), timingShare(phase.duration, total)))
+	}
+	chalker.Log(chalker.DEFAULT, "\n"+columnize.SimpleFormat(summary))
+
+	// The slowest step (server time is the provider, the rest is the network or this machine)
+	detail := ""
+	if !slowest.Local {
+		detail = fmt.Sprintf(" (TTFB %s, DNS/connect/TLS %s)", timingDuration(slowest.TTFB), timingDuration(slowest.DNS+slowest.Connect+slowest.TLS))
+	}
+	chalker.Log(chalker.INFO, fmt.Sprintf("Slowest step: %s in %s%s", color.CyanString(slowest.Name), timingDuration(slowest.Total), detail))
+	if cached > 0 {
+		chalker.Log(chalker.INFO, fmt.Sprintf("Cache hits: %d of %d step(s)", cached, len(steps)))
+	}
+}
+
+// timingBar returns the step on the timeline (one character per phase at least, if the phase was used)
+func timingBar(step *TimingStep, end time.Duration) string {
+	scale := float64(timingWidth) / float64(end)
+	width := func(duration time.Duration) int {
+		if duration <= 0 {
+			return 0
+		}
+		if chars := int(float64(duration)*scale + 0.5); chars > 0 {
+			return chars
+		}
+		return 1
+	}
+
+	// The phases of the step
+	segment, used := "", 0
+	switch {
+	case len(step.Error) > 0 && step.Status == 0:
+		used = max(width(step.Total), 1)
+		segment = color.RedString(strings.Repeat("x", used))
+	case step.Cached:
+		used = max(width(step.Total), 1)
+		segment = strings.Repeat("*", used)
+	case step.Local:
+		used = max(width(step.Total), 1)
+		segment = strings.Repeat("=", used)
+	default:
+		for _, phase := range []struct {
+			char     string
+			duration time.Duration
+			paint    func(format string, a ...interface{}) string
+		}{
+			{"d", step.DNS, color.CyanString},
+			{"c", step.Connect, color.YellowString},
+			{"t", step.TLS, color.MagentaString},
+			{"w", step.TTFB, color.GreenString},
+			{"r", step.Transfer, color.BlueString},
+		} {
+			if chars := width(phase.duration); chars > 0 {
+				used += chars
+				segment += phase.paint(strings.Repeat(phase.char, chars))
+			}
+		}
+		if used == 0 {
+			used = 1
+			segment = color.GreenString("w")
+		}
+	}
+
+	// Placed on the timeline (moved left if the rounding would run past the end)
+	offset := min(int(float64(step.Start)*scale), max(timingWidth-used, 0))
+	return strings.Repeat(".", offset) + segment + strings.Repeat(".", max(timingWidth-offset-used, 0))
+}
+
+// timingDuration returns a short duration (IE: 12ms, 1.2s)
+func timingDuration(duration time.Duration) string {
+	switch {
+	case duration <= 0:
+		return "0s"
+	case duration < time.Millisecond:
+		return duration.Round(time.Microsecond).String()
+	case duration < time.Second:
+		return duration.Round(time.Millisecond).String()
+	}
+	return duration.Round(10 * time.Millisecond).String()
+}
+
+// timingShare returns the share of the total (IE: 42%)
+func timingShare(duration, total time.Duration) string {
+	if total <= 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.0f%%", float64(duration)/float64(total)*100)
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// TestTimingBar will test the method timingBar()
+func TestTimingBar(t *testing.T) {
+	// Not parallel: turns off the colors
+
+	previous := color.NoColor
+	color.NoColor = true
+	t.Cleanup(func() {
+		color.NoColor = previous
+	})
+
+	// The timeline is 100ms (two milliseconds per character)
+	end := 100 * time.Millisecond
+	var tests = []struct {
+		name     string
+		step     *TimingStep
+		expected string
+	}{
+		{"local step", &TimingStep{Local: true, Total: 10 * time.Millisecond},
+			"=====" + strings.Repeat(".", 45)},
+		{"cache hit (at least one character)", &TimingStep{Cached: true, Local: true, Start: 20 * time.Millisecond, Total: time.Microsecond},
+			strings.Repeat(".", 10) + "*" + strings.Repeat(".", 39)},
+		{"failed request", &TimingStep{Error: "connection refused", Start: 50 * time.Millisecond, Total: 20 * time.Millisecond},
+			strings.Repeat(".", 25) + "xxxxxxxxxx" + strings.Repeat(".", 15)},
+		{"phases", &TimingStep{DNS: 10 * time.Millisecond, Connect: 4 * time.Millisecond, TLS: time.Millisecond, TTFB: 40 * time.Millisecond},
+			"dddddcctwwwwwwwwwwwwwwwwwwww" + strings.Repeat(".", 22)},
+		{"short phases are one character", &TimingStep{DNS: 100 * time.Microsecond, Start: 10 * time.Millisecond, TTFB: 4 * time.Millisecond, Transfer: 2 * time.Millisecond},
+			strings.Repeat(".", 5) + "dwwr" + strings.Repeat(".", 41)},
+		{"error with a status", &TimingStep{Error: "server error", Status: 500, Start: 80 * time.Millisecond, TTFB: 10 * time.Millisecond},
+			strings.Repeat(".", 40) + "wwwww" + strings.Repeat(".", 5)},
+		{"no phases", &TimingStep{Start: end},
+			strings.Repeat(".", 49) + "w"},
+		{"runs past the end", &TimingStep{Start: 90 * time.Millisecond, TTFB: 40 * time.Millisecond},
+			strings.Repeat(".", 30) + strings.Repeat("w", 20)},
+	}
+
+	for _, test := range tests {
+		if output := timingBar(test.step, end); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expected, output)
+		} else if len(output) != timingWidth {
+			t.Errorf("%s Failed: [%s] inputted and [%d] characters expected, received: [%d]", t.Name(), test.name, timingWidth, len(output))
+		}
+	}
+}
+
+// TestDisplayTiming will test the method displayTiming()
+func TestDisplayTiming(t *testing.T) {
+	// Not parallel: captures the logs
+
+	logs := new(bytes.Buffer)
+	previous, previousNoColor := color.Output, color.NoColor
+	color.Output, color.NoColor = logs, true
+	t.Cleanup(func() {
+		color.Output, color.NoColor = previous, previousNoColor
+	})
+
+	steps := []*TimingStep{
+		{Local: true, Name: "srv lookup", Total: 10 * time.Millisecond},
+		{Cached: true, Local: true, Name: "capabilities (cache)", Start: 10 * time.Millisecond, Total: time.Millisecond},
+		{
+			Connect: 10 * time.Millisecond, DNS: 5 * time.Millisecond, Name: "GET example.com/.well-known/bsvalias",
+			Start: 11 * time.Millisecond, Status: 200, TLS: 15 * time.Millisecond, Total: 80 * time.Millisecond,
+			Transfer: 10 * time.Millisecond, TTFB: 40 * time.Millisecond,
+		},
+		{Name: strings.Repeat("a", 60), Start: 20 * time.Millisecond, Status: 200, Total: 9 * time.Millisecond, TTFB: 9 * time.Millisecond},
+	}
+
+	var tests = []struct {
+		name     string
+		steps    []*TimingStep
+		expected []string
+		missing  []string
+	}{
+		{"no steps", nil, []string{
+			"Timing for 0 step(s) in 50ms...",
+			"No requests were made",
+		}, []string{"Waterfall", "Slowest step"}},
+		{"steps", steps, []string{
+			"Timing for 4 step(s) in 100ms...",
+			"# ", "Waterfall",
+			"srv lookup", "capabilities (cache)",
+			strings.Repeat("a", 45) + "...",
+			"80ms", " <- slowest",
+			"Legend: d DNS",
+			"DNS            5ms",
+			"TLS            15ms",
+			"TTFB (server)  49ms",
+			"Local          11ms",
+			"Slowest step: GET example.com/.well-known/bsvalias in 80ms (TTFB 40ms, DNS/connect/TLS 30ms)",
+			"Cache hits: 1 of 4 step(s)",
+		}, []string{strings.Repeat("a", 46)}},
+		{"local slowest and no cache hits", steps[:1], []string{
+			"Slowest step: srv lookup in 10ms\n",
+			"Local          10ms  100%",
+		}, []string{"Cache hits", "(TTFB"}},
+	}
+
+	for _, test := range tests {
+		logs.Reset()
+		elapsed := 50 * time.Millisecond
+		if len(test.steps) > 0 {
+			elapsed = 100 * time.Millisecond
+		}
+		displayTiming(test.steps, elapsed)
+		for _, expected := range test.expected {
+			if !strings.Contains(logs.String(), expected) {
+				t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, expected, logs.String())
+			}
+		}
+		for _, missing := range test.missing {
+			if strings.Contains(logs.String(), missing) {
+				t.Errorf("%s Failed: [%s] inputted and no [%s] expected, received: [%s]", t.Name(), test.name, missing, logs.String())
+			}
+		}
+	}
+}
+
+// TestTimingDuration will test the method timingDuration()
+func TestTimingDuration(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		duration time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{-time.Second, "0s"},
+		{1234 * time.Nanosecond, "1µs"},
+		{12345 * time.Microsecond, "12ms"},
+		{1234 * time.Millisecond, "1.23s"},
+		{90 * time.Second, "1m30s"},
+	}
+
+	for _, test := range tests {
+		if output := timingDuration(test.duration); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.duration, test.expected, output)
+		}
+	}
+}
+
+// TestTimingShare will test the method timingShare()
+func TestTimingShare(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		duration time.Duration
+		total    time.Duration
+		expected string
+	}{
+		{time.Second, 0, "0%"},
+		{0, time.Second, "0%"},
+		{250 * time.Millisecond, time.Second, "25%"},
+		{time.Second, 3 * time.Second, "33%"},
+		{time.Second, time.Second, "100%"},
+	}
+
+	for _, test := range tests {
+		if output := timingShare(test.duration, test.total); output != test.expected {
+			t.Errorf("%s Failed: [%s] of [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.duration, test.total, test.expected, output)
+		}
+	}
+}
+
+// TestHarDuration will test the method harDuration()
+func TestHarDuration(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		milliseconds float64
+		expected     time.Duration
+	}{
+		{-1, 0},
+		{0, 0},
+		{0.5, 500 * time.Microsecond},
+		{12, 12 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		if output := harDuration(test.milliseconds); output != test.expected {
+			t.Errorf("%s Failed: [%f] inputted and [%s] expected, received: [%s]", t.Name(), test.milliseconds, test.expected, output)
+		}
+	}
+}
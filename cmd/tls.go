@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/tlsreport"
+	"github.com/ryanuber/columnize"
+)
+
+// displayTLSReport will show the connection, the certificate chain and the issues found
+func displayTLSReport(ctx context.Context, report *tlsreport.Report) {
+	if len(report.Version) > 0 {
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Negotiated: %s with %s", color.CyanString(report.Version), color.CyanString(report.CipherSuite)))
+	}
+	if len(report.Versions) > 0 {
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Accepted versions: %s", strings.Join(report.Versions, ", ")))
+	}
+	if report.OCSP != nil && report.OCSP.Stapled {
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("OCSP stapled: %s", report.OCSP.Status))
+	}
+	if len(report.HSTS) > 0 {
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("HSTS: %s", report.HSTS))
+	}
+
+	// The certificate chain (as sent by the host)
+	for index, certificate := range report.Certificates {
+		displayRequestHeader(ctx, chalker.BOLD, fmt.Sprintf("Certificate #%d: %s", index+1, color.CyanString(certificate.Subject)))
+		output := []string{
+			"Issuer: | " + certificate.Issuer,
+			fmt.Sprintf("Valid: | %s to %s (%s)", certificate.NotBefore.Format(time.DateOnly), certificate.NotAfter.Format(time.DateOnly), daysLeftString(certificate.DaysLeft)),
+			"Serial: | " + certificate.SerialNumber,
+			"Signature: | " + certificate.SignatureAlgorithm,
+		}
+		if names := append(append([]string{}, certificate.DNSNames...), certificate.IPAddresses...); len(names) > 0 {
+			output = append(output, "SANs: | "+strings.Join(names, ", "))
+		}
+		logWithContext(ctx, chalker.DEFAULT, columnize.SimpleFormat(output))
+	}
+
+	// The issues found
+	for _, issue := range report.Issues {
+		switch issue.Level {
+		case tlsreport.LevelError:
+			logWithContext(ctx, chalker.ERROR, issue.Message)
+		case tlsreport.LevelWarn:
+			logWithContext(ctx, chalker.WARN, issue.Message)
+		default:
+			logWithContext(ctx, chalker.INFO, issue.Message)
+		}
+	}
+}
+
+// daysLeftString returns the days left until a certificate expires
+func daysLeftString(days int) string {
+	if days < 0 {
+		return color.RedString("expired %d day(s) ago", -days)
+	}
+	return fmt.Sprintf("%d day(s) left", days)
+}
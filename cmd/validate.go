@@ -1,14 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/bsv-blockchain/go-paymail"
 	"github.com/fatih/color"
 	"github.com/mrz1836/go-sanitize"
 	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/dnsreport"
+	"github.com/mrz1836/paymail-inspector/tlsreport"
 	"github.com/spf13/cobra"
 )
 
@@ -28,6 +32,12 @@ Validate a specific paymail address (user@domain.tld) or validate a domain for r
 
 By default, this will check for a SRV record, DNSSEC and SSL for the domain. 
 
+The DNSSEC check walks the chain of trust from the root to the domain (DS, DNSKEY and RRSIG records at each zone)
+and shows the exact link that is broken. Signatures that expire soon (--dnssec-expiry) are flagged.
+
+The SSL check inspects the TLS connection of the target: the certificate chain, issuer, SANs (covering the target),
+days until expiry (--ssl-expiry-warn and --ssl-expiry-error), TLS versions and cipher, OCSP stapling and HSTS.
+
 This will also check for required capabilities that all paymail services are required to support.
 
 All these validations are suggestions/requirements from bsvalias spec.
@@ -38,144 +48,206 @@ Read more at: `+color.CyanString("http://bsvalias.org/index.html")),
 	Aliases:    []string{"val", "v"},
 	SuggestFor: []string{"valid"},
 	Args: func(_ *cobra.Command, args []string) error {
-		if len(args) < 1 {
-			return chalker.Error("validate requires either a domain or paymail address")
+		if len(args) < 1 && len(inputFile) == 0 {
+			return chalker.Error("validate requires either a domain, paymail address or --input")
 		} else if len(args) > 1 {
-			return chalker.Error("validate only supports one domain or address at a time")
+			return chalker.Error("validate only supports one domain or address at a time (use --input for many)")
 		}
 		return nil
 	},
-	Run: func(_ *cobra.Command, args []string) {
-		var alias, domain, paymailAddress string
-
-		// Extract the parts given
-		if strings.Contains(args[0], "@") {
-			alias, domain, paymailAddress = paymail.SanitizePaymail(args[0])
-		} else {
-			domain, _ = sanitize.Domain(args[0], false, true)
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// Batch mode (file or stdin)
+		if len(inputFile) > 0 {
+			runBatch(ctx, inputFile, 1, func(ctx context.Context, fields []string, report *Report) error {
+				return validateTarget(ctx, fields[0], report)
+			})
+			return
 		}
 
-		var err error
-
-		// Are we an address?
-		displayHeader(chalker.DEFAULT, "Detecting validation type...")
-		if len(paymailAddress) > 0 {
-			chalker.Log(chalker.DEFAULT, fmt.Sprintf("Paymail detected: %s", color.CyanString(paymailAddress)))
-
-			// Validate the paymail address and domain (error already shown)
-			if ok := validatePaymailAndDomain(paymailAddress, domain); !ok {
-				return
-			}
-
-		} else {
-			chalker.Log(chalker.DIM, fmt.Sprintf("Domain detected: %s", color.CyanString(domain)))
+		// Validate the single domain or address (errors are already shown)
+		_ = validateTarget(ctx, args[0], runReport)
+	},
+}
 
-			// Validate the domain
-			if err = paymail.ValidateDomain(domain); err != nil {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Domain name %s is invalid: %s", domain, err.Error()))
-				return
-			}
+// validateTarget will run all validations for one domain or paymail address
+func validateTarget(ctx context.Context, target string, report *Report) (err error) {
+	var alias, domain, paymailAddress string
+
+	// Extract the parts given
+	if strings.Contains(target, "@") {
+		alias, domain, paymailAddress = paymail.SanitizePaymail(target)
+	} else {
+		domain, _ = sanitize.Domain(target, false, true)
+	}
+
+	// Are we an address?
+	displayRequestHeader(ctx, chalker.DEFAULT, "Detecting validation type...")
+	if len(paymailAddress) > 0 {
+		logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Paymail detected: %s", color.CyanString(paymailAddress)))
+
+		// Validate the paymail address and domain (error already shown)
+		if ok := validatePaymailAndDomain(ctx, paymailAddress, domain); !ok {
+			return &KindError{Err: fmt.Errorf("invalid paymail address: %s", paymailAddress), Kind: ErrInvalidInput}
 		}
 
-		// Used for future checks
-		checkDomain := domain
+	} else {
+		logWithContext(ctx, chalker.DIM, fmt.Sprintf("Domain detected: %s", color.CyanString(domain)))
 
-		// New Client
-		var client paymail.ClientInterface
-		if client, err = newPaymailClient(true, nameServer); err != nil {
-			return
+		// Validate the domain
+		if err = paymail.ValidateDomain(domain); err != nil {
+			return inputError(ctx, fmt.Sprintf("Domain name %s is invalid: %s", domain, err.Error()))
 		}
-
-		// Get the SRV record
-		if !skipSrvCheck {
-
-			// Get & Validate srv record
-			var srv *net.SRV
-			if srv, err = getSrvRecord(domain, true, false); err != nil {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Error getting SRV record: %s", err.Error()))
-			}
-			if srv != nil && len(srv.Target) > 0 {
-				checkDomain = srv.Target
-			}
-		} else {
-			chalker.Log(chalker.WARN, fmt.Sprintf("Skipping SRV record check for: %s", color.CyanString(checkDomain)))
+	}
+
+	// Used for future checks
+	checkDomain := domain
+
+	// Start the validation result
+	validation := &ValidationResult{Domain: domain, Paymail: paymailAddress, Target: checkDomain}
+	report.Validation = validation
+
+	// New Client
+	var client paymail.ClientInterface
+	if client, err = newPaymailClient(ctx, true, nameServer); err != nil {
+		return logError(ctx, fmt.Sprintf("Error: %s", err.Error()))
+	}
+
+	// Get the SRV record (the checked targets are used for the capabilities)
+	var capabilityTargets []*net.SRV
+	var srvErr error
+	if !skipSrvCheck {
+
+		// Get all srv records and check each target (the first healthy target is used for the next checks)
+		var records []*net.SRV
+		if records, err = getSrvRecords(ctx, domain, false); err != nil {
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error getting SRV record: %s", err.Error()))
+			srvErr = err
+		} else if validation.SRVTargets = checkSrvTargets(ctx, client, records); len(validation.SRVTargets) > 0 {
+			capabilityTargets = healthyTargetsFirst(validation.SRVTargets)
+			checkDomain = capabilityTargets[0].Target
+			validation.SRV = capabilityTargets[0]
+			validation.Target = checkDomain
 		}
-
-		// Validate the DNSSEC if the flag is true
-		displayHeader(chalker.DEFAULT, fmt.Sprintf("Checking %s for DNSSEC validation...", color.CyanString(checkDomain)))
-		if !skipDNSCheck {
-			// Fire the check request
-			if result := client.CheckDNSSEC(checkDomain); result.DNSSEC {
-				chalker.Log(chalker.SUCCESS, fmt.Sprintf("DNSSEC found and valid and found %d DS record(s)", result.Answer.DSRecordCount))
-			} else {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("DNSSEC possibly not found or invalid for %s, check manually: dnsviz.net/d/domain.com/dnssec/", result.Domain))
-				if len(result.ErrorMessage) > 0 {
-					chalker.Log(chalker.ERROR, fmt.Sprintf("Error checking DNSSEC: %s", result.ErrorMessage))
-				}
-			}
-		} else {
-			chalker.Log(chalker.WARN, fmt.Sprintf("Skipping DNSSEC check for: %s", color.CyanString(checkDomain)))
+	} else {
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("Skipping SRV record check for: %s", color.CyanString(checkDomain)))
+	}
+
+	// Validate the DNSSEC if the flag is true
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Checking %s for DNSSEC validation...", color.CyanString(checkDomain)))
+	if !skipDNSCheck {
+		// Walk the chain of trust from the root
+		resolver := &dnsreport.Client{NameServer: nameServer, Timeout: dnsTimeout}
+		var chain *dnsreport.Chain
+		start := time.Now()
+		chain, err = resolver.Chain(ctx, checkDomain, dnssecExpiry, start)
+		timeStep("DNSSEC "+checkDomain, start, false, err)
+		if err != nil {
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error checking DNSSEC: %s", requestError(ctx, "dnssec", err).Error()))
 		}
-
-		// Validate that there is SSL on the target
-		displayHeader(chalker.DEFAULT, fmt.Sprintf("Checking %s for SSL validation...", color.CyanString(checkDomain)))
-		if !skipSSLCheck {
-
-			var valid bool
-			if valid, err = client.CheckSSL(checkDomain); err != nil {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Error checking SSL: %s", err.Error()))
-			} else if !valid {
-				chalker.Log(chalker.ERROR, "Zero SSL certificates found (or timed out)")
-			}
-			chalker.Log(chalker.SUCCESS, fmt.Sprintf("SSL found and valid for: %s", checkDomain))
-		} else {
-			chalker.Log(chalker.WARN, fmt.Sprintf("Skipping SSL check for: %s", color.CyanString(checkDomain)))
+		validation.DNSSEC = chain
+		displayDNSSECChain(ctx, chain)
+	} else {
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("Skipping DNSSEC check for: %s", color.CyanString(checkDomain)))
+	}
+
+	// Validate that there is SSL on the target (the custom host, or the selected SRV target)
+	sslHost, sslPort := checkDomain, paymail.DefaultPort
+	if len(hostOverride) > 0 {
+		if sslHost, sslPort, err = splitHostOverride(hostOverride); err != nil {
+			return logError(ctx, fmt.Sprintf("Error: %s", err.Error()))
 		}
-
-		// Get the capabilities
-		var capabilities *paymail.CapabilitiesResponse
-		if capabilities, err = getCapabilities(domain, false); err != nil {
-			if strings.Contains(err.Error(), "context deadline exceeded") {
-				chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s", domain))
+	} else if validation.SRV != nil {
+		sslPort = int(validation.SRV.Port)
+	}
+	displayRequestHeader(ctx, chalker.DEFAULT, fmt.Sprintf("Checking %s for SSL validation...", color.CyanString(fmt.Sprintf("%s:%d", sslHost, sslPort))))
+	if !skipSSLCheck {
+
+		// Connect and inspect the certificate chain
+		inspector := &tlsreport.Client{Insecure: insecure, Timeout: sslTimeout}
+		var tlsReport *tlsreport.Report
+		start := time.Now()
+		tlsReport, err = inspector.Inspect(ctx, sslHost, sslPort, sslExpiryWarning, sslExpiryError, start)
+		timeStep(fmt.Sprintf("TLS %s:%d", sslHost, sslPort), start, false, err)
+		if err != nil {
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error checking SSL: %s", requestError(ctx, "ssl", err).Error()))
+		} else {
+			// Only a full inspection sets the result
+			displayTLSReport(ctx, tlsReport)
+			validation.TLS = tlsReport
+			valid := tlsReport.Valid
+			validation.SSL = &valid
+			if valid {
+				logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("SSL found and valid for: %s", sslHost))
 			} else {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+				logWithContext(ctx, chalker.ERROR, fmt.Sprintf("SSL is not valid for: %s", sslHost))
 			}
-			return
 		}
-
-		// Missing required capabilities?
-		pkiURL := capabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
-		resolveURL := capabilities.GetString(paymail.BRFCPaymentDestination, paymail.BRFCBasicAddressResolution)
-		if len(pkiURL) == 0 {
-			chalker.Log(chalker.WARN, fmt.Sprintf("Missing required capability: %s", paymail.BRFCPki))
-		} else if len(resolveURL) == 0 {
-			chalker.Log(chalker.WARN, fmt.Sprintf("Missing required capability: %s", paymail.BRFCPaymentDestination))
-		} else if len(pkiURL) > 0 && len(resolveURL) > 0 {
-			chalker.Log(chalker.SUCCESS, fmt.Sprintf("Found required capabilities: [%s] [%s]", paymail.BRFCPki, paymail.BRFCPaymentDestination))
+	} else {
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("Skipping SSL check for: %s", color.CyanString(sslHost)))
+	}
+
+	// Get the capabilities (from the custom host, or the selected SRV target first without a second SRV lookup)
+	var capabilities *paymail.CapabilitiesResponse
+	switch {
+	case len(hostOverride) > 0 || (srvErr == nil && len(capabilityTargets) == 0):
+		capabilities, err = getCapabilities(ctx, domain, false)
+	case srvErr != nil:
+		if capabilityTargets, err = srvFallback(ctx, domain, srvErr); err == nil {
+			capabilities, err = getFailoverCapabilities(ctx, domain, capabilityTargets, false)
 		}
-
-		// Only if we have an address (basic validation that the address exists)
-		if len(paymailAddress) > 0 && len(pkiURL) > 0 {
-
-			// Get the PKI for the given address
-			var pki *paymail.PKIResponse
-			if pki, err = getPki(pkiURL, alias, domain, false); err != nil {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
-				return
-			} else if pki != nil {
-
-				// Rendering profile information
-				displayHeader(chalker.BOLD, fmt.Sprintf("Rendering paymail information for %s...", color.CyanString(paymailAddress)))
-
-				chalker.Log(chalker.DEFAULT, fmt.Sprintf("PubKey: %s", color.CyanString(pki.PubKey)))
-			}
+	default:
+		capabilities, err = getFailoverCapabilities(ctx, domain, capabilityTargets, false)
+	}
+	if err != nil {
+		if isTimeout(err) {
+			logWithContext(ctx, chalker.WARN, fmt.Sprintf("No capabilities found for: %s (%s)", domain, err.Error()))
+			return fmt.Errorf("no capabilities found for: %s: %w", domain, err)
 		}
-	},
+		return logFailure(ctx, "Error", err)
+	}
+
+	report.Capabilities = &capabilities.CapabilitiesPayload
+
+	// Missing required capabilities?
+	pkiURL := capabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
+	resolveURL := capabilities.GetString(paymail.BRFCPaymentDestination, paymail.BRFCBasicAddressResolution)
+	if len(pkiURL) == 0 {
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("Missing required capability: %s", paymail.BRFCPki))
+	} else if len(resolveURL) == 0 {
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("Missing required capability: %s", paymail.BRFCPaymentDestination))
+	} else if len(pkiURL) > 0 && len(resolveURL) > 0 {
+		logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("Found required capabilities: [%s] [%s]", paymail.BRFCPki, paymail.BRFCPaymentDestination))
+		validation.RequiredCapabilities = true
+	}
+
+	// Only if we have an address (basic validation that the address exists)
+	if len(paymailAddress) > 0 && len(pkiURL) > 0 {
+
+		// Get the PKI for the given address
+		var pki *paymail.PKIResponse
+		if pki, err = getPki(ctx, pkiURL, alias, domain, false); err != nil {
+			return logFailure(ctx, "Error", err)
+		} else if pki != nil {
+
+			// Rendering profile information
+			displayRequestHeader(ctx, chalker.BOLD, fmt.Sprintf("Rendering paymail information for %s...", color.CyanString(paymailAddress)))
+
+			logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("PubKey: %s", color.CyanString(pki.PubKey)))
+			validation.PubKey = pki.PubKey
+		}
+	}
+
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(validateCmd)
 
+	// Batch mode (file or stdin)
+	addBatchFlags(validateCmd)
+
 	// Custom name server for DNS resolution (looking for the SRV record)
 	validateCmd.Flags().StringVarP(&nameServer, "nameserver", "n", defaultNameServer, "DNS name server for resolving records")
 
@@ -200,6 +272,15 @@ func init() {
 	// Run the DNSSEC check on the target domain
 	validateCmd.Flags().BoolVarP(&skipDNSCheck, "skip-dnssec", "d", false, "Skip checking DNSSEC of the target domain")
 
+	// Flag DNSSEC signatures that expire soon
+	validateCmd.Flags().DurationVar(&dnssecExpiry, "dnssec-expiry", dnsreport.DefaultExpiryWarning, "Flag DNSSEC signatures that expire within this duration")
+
 	// Run the SSL check on the target domain
 	validateCmd.Flags().BoolVar(&skipSSLCheck, "skip-ssl", false, "Skip checking SSL of the target domain")
+
+	// Flag certificates that expire soon (warning)
+	validateCmd.Flags().DurationVar(&sslExpiryWarning, "ssl-expiry-warn", tlsreport.DefaultExpiryWarning, "Warn when the certificate expires within this duration")
+
+	// Flag certificates that expire soon (error)
+	validateCmd.Flags().DurationVar(&sslExpiryError, "ssl-expiry-error", tlsreport.DefaultExpiryError, "Fail when the certificate expires within this duration")
 }
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"encoding/hex"
 	"fmt"
 	"strings"
 
@@ -36,95 +38,114 @@ Read more at: `+color.CyanString("http://bsvalias.org/05-verify-public-key-owner
 	Example: applicationName + " verify mrz@" + defaultDomainName + " 02ead23149a1e33df17325ec7a7ba9e0b20c674c57c630f527d69b866aa9b65b10" +
 		"\n" + applicationName + " verify 1mrz 0352530c305378fd9dfd99f8c8c44e9092efa7c1674b61d4e9be65f92aa7a77bbe",
 	Args: func(_ *cobra.Command, args []string) error {
-		if len(args) < 2 {
-			return chalker.Error("verify requires a paymail address AND pubkey")
+		if len(args) < 2 && len(inputFile) == 0 {
+			return chalker.Error("verify requires a paymail address AND pubkey (or --input)")
 		} else if len(args) > 2 {
-			return chalker.Error("verify only supports one address and one pubkey at a time")
+			return chalker.Error("verify only supports one address and one pubkey at a time (use --input for many)")
 		}
 		return nil
 	},
-	Run: func(_ *cobra.Command, args []string) {
-		var paymailAddress, alias, domain, pubKey string
-
-		// Convert handle if detected
-		if len(args[0]) < 25 {
-			args[0] = paymail.ConvertHandle(args[0], false)
-		} else if len(args[1]) < 25 {
-			args[1] = paymail.ConvertHandle(args[1], false)
-		}
-
-		// Check for paymail in both args
-		if strings.Contains(args[0], "@") {
-			alias, domain, paymailAddress = paymail.SanitizePaymail(args[0])
-			pubKey = args[1]
-		} else if strings.Contains(args[1], "@") {
-			pubKey = args[0]
-			alias, domain, paymailAddress = paymail.SanitizePaymail(args[1])
-		}
-
-		// Require a paymail address
-		if len(paymailAddress) == 0 {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("One argument must be a paymail address [%s] [%s]", args[0], args[1]))
-			return
-		}
-
-		// Require a pubkey
-		if len(pubKey) == 0 {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("One argument must be a pubkey [%s] [%s]", args[0], args[1]))
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// Batch mode (file or stdin) requires two columns: paymail and pubkey
+		if len(inputFile) > 0 {
+			runBatch(ctx, inputFile, 2, func(ctx context.Context, fields []string, report *Report) error {
+				return verifyPaymail(ctx, fields[:2], report)
+			})
 			return
 		}
 
-		// Validate the paymail address and domain (error already shown)
-		if ok := validatePaymailAndDomain(paymailAddress, domain); !ok {
-			return
-		}
-
-		// Validate pubkey
-		if len(pubKey) != paymail.PubKeyLength {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("PubKey is an invalid length, expected: %d but got: %d", paymail.PubKeyLength, len(pubKey)))
-			return
-		}
-
-		// Get the capabilities
-		capabilities, err := getCapabilities(domain, true)
-		if err != nil {
-			if strings.Contains(err.Error(), "context deadline exceeded") {
-				chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s", domain))
-			} else {
-				chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
-			}
-			return
-		}
-
-		// Set the URL - Does the paymail provider have the capability?
-		verifyURL := capabilities.GetString(paymail.BRFCVerifyPublicKeyOwner, "")
-		if len(verifyURL) == 0 {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("The provider %s is missing a required capability: %s", domain, paymail.BRFCVerifyPublicKeyOwner))
-			return
-		}
-
-		// Fire the verify request
-		var verify *paymail.VerificationResponse
-		if verify, err = verifyPubKey(verifyURL, alias, domain, pubKey); err != nil {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("verify pubkey request failed: %s", err.Error()))
-			return
-		}
-
-		// Rendering profile information
-		displayHeader(chalker.BOLD, fmt.Sprintf("Rendering verify response for %s...", color.CyanString(paymailAddress)))
-
-		// Show the results
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Paymail: %s", color.CyanString(paymailAddress)))
-		chalker.Log(chalker.DEFAULT, fmt.Sprintf("PubKey : %s", color.CyanString(pubKey)))
+		// Verify the single address and pubkey (errors are already shown)
+		_ = verifyPaymail(ctx, args, runReport)
+	},
+}
 
-		if verify.Match {
-			chalker.Log(chalker.SUCCESS, "Paymail & PubKey Match! (service responded: match=true)")
-		} else {
-			chalker.Log(chalker.ERROR, "DO NOT MATCH! (service responded: match=false)")
+// verifyPaymail will verify a pubkey against a paymail address (args can be in either order)
+func verifyPaymail(ctx context.Context, args []string, report *Report) (err error) {
+	var paymailAddress, alias, domain, pubKey string
+
+	// Convert handle if detected
+	if len(args[0]) < 25 {
+		args[0] = paymail.ConvertHandle(args[0], false)
+	} else if len(args[1]) < 25 {
+		args[1] = paymail.ConvertHandle(args[1], false)
+	}
+
+	// Check for paymail in both args
+	if strings.Contains(args[0], "@") {
+		alias, domain, paymailAddress = paymail.SanitizePaymail(args[0])
+		pubKey = args[1]
+	} else if strings.Contains(args[1], "@") {
+		pubKey = args[0]
+		alias, domain, paymailAddress = paymail.SanitizePaymail(args[1])
+	}
+
+	// Require a paymail address
+	if len(paymailAddress) == 0 {
+		return inputError(ctx, fmt.Sprintf("One argument must be a paymail address [%s] [%s]", args[0], args[1]))
+	}
+
+	// Require a pubkey
+	if len(pubKey) == 0 {
+		return inputError(ctx, fmt.Sprintf("One argument must be a pubkey [%s] [%s]", args[0], args[1]))
+	}
+
+	// Validate the paymail address and domain (error already shown)
+	if ok := validatePaymailAndDomain(ctx, paymailAddress, domain); !ok {
+		return &KindError{Err: fmt.Errorf("invalid paymail address: %s", paymailAddress), Kind: ErrInvalidInput}
+	}
+
+	// Validate pubkey
+	if len(pubKey) != paymail.PubKeyLength {
+		return inputError(ctx, fmt.Sprintf("PubKey is an invalid length, expected: %d but got: %d", paymail.PubKeyLength, len(pubKey)))
+	} else if _, err = hex.DecodeString(pubKey); err != nil {
+		return inputError(ctx, fmt.Sprintf("PubKey is not valid hex: %s", err.Error()))
+	}
+
+	// Get the capabilities
+	var capabilities *paymail.CapabilitiesResponse
+	if capabilities, err = getCapabilities(ctx, domain, true); err != nil {
+		if isTimeout(err) {
+			logWithContext(ctx, chalker.WARN, fmt.Sprintf("No capabilities found for: %s (%s)", domain, err.Error()))
+			return fmt.Errorf("no capabilities found for: %s: %w", domain, err)
 		}
-	},
+		return logFailure(ctx, "Error", err)
+	}
+
+	// Set the URL - Does the paymail provider have the capability?
+	verifyURL := capabilities.GetString(paymail.BRFCVerifyPublicKeyOwner, "")
+	if len(verifyURL) == 0 {
+		return logError(ctx, fmt.Sprintf("The provider %s is missing a required capability: %s", domain, paymail.BRFCVerifyPublicKeyOwner))
+	}
+
+	// Fire the verify request
+	var verify *paymail.VerificationResponse
+	if verify, err = verifyPubKey(ctx, verifyURL, alias, domain, pubKey); err != nil {
+		return logFailure(ctx, "verify pubkey request failed", err)
+	}
+	report.Verification = &verify.VerificationPayload
+
+	// Rendering profile information
+	displayRequestHeader(ctx, chalker.BOLD, fmt.Sprintf("Rendering verify response for %s...", color.CyanString(paymailAddress)))
+
+	// Show the results
+	logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("Paymail: %s", color.CyanString(paymailAddress)))
+	logWithContext(ctx, chalker.DEFAULT, fmt.Sprintf("PubKey : %s", color.CyanString(pubKey)))
+
+	if verify.Match {
+		logWithContext(ctx, chalker.SUCCESS, "Paymail & PubKey Match! (service responded: match=true)")
+	} else {
+		logWithContext(ctx, chalker.ERROR, "DO NOT MATCH! (service responded: match=false)")
+		return fmt.Errorf("paymail %s and pubkey %s do not match", paymailAddress, pubKey)
+	}
+
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(verifyCmd)
+
+	// Batch mode (file or stdin)
+	addBatchFlags(verifyCmd)
 }
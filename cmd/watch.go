@@ -0,0 +1,437 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/fatih/color"
+	"github.com/mrz1836/go-sanitize"
+	"github.com/mrz1836/paymail-inspector/chalker"
+	"github.com/mrz1836/paymail-inspector/database"
+	"github.com/mrz1836/paymail-inspector/dnsreport"
+	"github.com/mrz1836/paymail-inspector/tlsreport"
+	"github.com/spf13/cobra"
+)
+
+// Defaults for the watch command
+const (
+	defaultWatchInterval = 5 * time.Minute  // Time between each run
+	maxWatchEvents       = 1000             // Latest change events kept in the report (ndjson writes all of them)
+	minWatchInterval     = 10 * time.Second // Shortest interval allowed (be nice to the providers)
+	watchKeyPrefix       = "watch-snapshot-"
+	watchStatusError     = "error" // The check could not finish (IE: a timeout or a refused connection)
+	watchStatusInvalid   = "invalid"
+	watchStatusValid     = "valid"
+)
+
+// Types of change events
+const (
+	watchChangeAdded   = "added"
+	watchChangeChanged = "changed"
+	watchChangeRemoved = "removed"
+)
+
+// WatchSnapshot is the state of one target at a point in time (kept in the local database)
+type WatchSnapshot struct {
+	Capabilities map[string]string `json:"capabilities"`
+	CheckedAt    time.Time         `json:"checked_at"`
+	DNSSEC       string            `json:"dnssec,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	PubKey       string            `json:"pubkey,omitempty"`
+	SRV          string            `json:"srv,omitempty"`
+	SSL          string            `json:"ssl,omitempty"`
+	Target       string            `json:"target"`
+}
+
+// WatchEvent is one change detected between two snapshots
+type WatchEvent struct {
+	Change     string    `json:"change"`
+	DetectedAt time.Time `json:"detected_at"`
+	Field      string    `json:"field"`
+	New        string    `json:"new,omitempty"`
+	Old        string    `json:"old,omitempty"`
+	Target     string    `json:"target"`
+}
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Poll paymail addresses and domains for changes (PKI, capabilities, SRV, SSL, DNSSEC)",
+	Long: color.GreenString(`
+                 __         .__
+__  _  _______ _/  |_  ____ |  |__
+\ \/ \/ /\__  \\   __\/ ___\|  |  \
+ \     /  / __ \|  | \  \___|   Y  \
+  \/\_/  (____  /__|  \___  >___|  /
+              \/          \/     \/`) + `
+` + color.YellowString(`
+This command will poll a list of paymail addresses and domains on an interval and report any changes.
+
+Each run is compared with the previous snapshot in the local database: the PKI pubkey (addresses),
+capabilities added, removed or changed, the SRV target, and the SSL and DNSSEC checks.
+
+Change events are logged, and emitted as they happen using: `+color.CyanString("--output ndjson")+`
+
+Press CTRL+C to stop watching (or use --count to stop after a number of runs).`),
+	Aliases:     []string{"monitor"},
+	Annotations: map[string]string{annotationLongRunning: "true"},
+	SuggestFor:  []string{"poll"},
+	Example: applicationName + ` watch mrz@` + defaultDomainName + ` ` + defaultDomainName + `
+` + applicationName + ` watch --input paymails.txt --interval 1m
+` + applicationName + ` watch mrz@` + defaultDomainName + ` --count 1 --output ndjson`,
+	Args: func(_ *cobra.Command, args []string) error {
+		if len(args) == 0 && len(inputFile) == 0 {
+			return chalker.Error("watch requires at least one paymail address or domain (or --input)")
+		} else if watchInterval < minWatchInterval {
+			return chalker.Error(fmt.Sprintf("watch --interval must be at least %s", minWatchInterval))
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
+
+		// The database is required (snapshots)
+		if !databaseEnabled {
+			chalker.Log(chalker.ERROR, "The local database is not available (required for snapshots)")
+			return
+		}
+
+		// Load all the targets
+		targets, err := getWatchTargets(args, inputFile)
+		if err != nil {
+			chalker.Log(chalker.ERROR, fmt.Sprintf("Error loading targets: %s", err.Error()))
+			return
+		} else if len(targets) == 0 {
+			chalker.Log(chalker.ERROR, "No valid paymail addresses or domains to watch")
+			return
+		}
+
+		var events int
+		for run := 1; ; run++ {
+			// Each run has its own report (watch can run forever)
+			report := newReport(runReport.Command, args)
+			runCtx := withReport(ctx, report)
+
+			displayRequestHeader(runCtx, chalker.BOLD, fmt.Sprintf("Watch run #%d for %d target(s) at %s...", run, len(targets), time.Now().Format(time.RFC3339)))
+			for _, target := range targets {
+				if ctx.Err() != nil {
+					break
+				}
+				events += watchTarget(runCtx, target)
+			}
+			keepWatchRun(report)
+
+			// Done?
+			if watchCount > 0 && run >= watchCount {
+				break
+			}
+			chalker.Log(chalker.DIM, fmt.Sprintf("Next run in %s (press CTRL+C to stop)", watchInterval))
+			select {
+			case <-ctx.Done():
+			case <-time.After(watchInterval):
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		displayHeader(chalker.BOLD, "Watch stopped")
+		chalker.Log(chalker.DEFAULT, fmt.Sprintf("Changes detected: %s", color.CyanString(strconv.Itoa(events))))
+	},
+}
+
+// getWatchTargets will load all targets from the arguments and the input file (sanitized and unique)
+func getWatchTargets(args []string, path string) (targets []string, err error) {
+	values := append([]string{}, args...)
+	if len(path) > 0 {
+		var entries []*BatchEntry
+		if entries, err = readBatchInput(path); err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			values = append(values, entry.Fields[0])
+		}
+	}
+
+	unique := make(map[string]bool)
+	for _, value := range values {
+		target := sanitizeWatchTarget(value)
+		if len(target) == 0 {
+			chalker.Log(chalker.WARN, fmt.Sprintf("Skipping invalid target: %s", value))
+			continue
+		}
+		if !unique[target] {
+			unique[target] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets, nil
+}
+
+// sanitizeWatchTarget returns the paymail address or domain (empty if invalid)
+func sanitizeWatchTarget(value string) string {
+	if strings.Contains(value, "@") {
+		_, domain, paymailAddress := paymail.SanitizePaymail(value)
+		if len(paymailAddress) == 0 || paymail.ValidateDomain(domain) != nil {
+			return ""
+		}
+		return paymailAddress
+	}
+	domain, _ := sanitize.Domain(value, false, true)
+	if paymail.ValidateDomain(domain) != nil {
+		return ""
+	}
+	return domain
+}
+
+// watchTarget will take a new snapshot, compare it with the previous one and store it (returns the number of changes)
+func watchTarget(ctx context.Context, target string) int {
+	current := takeWatchSnapshot(ctx, target)
+
+	// Stopped during the run (CTRL+C or --timeout), the snapshot is not complete
+	if ctx.Err() != nil {
+		logWithContext(ctx, chalker.DIM, fmt.Sprintf("Watch stopped before %s was checked, the snapshot was not stored", target))
+		return 0
+	}
+
+	// Load the previous snapshot
+	keyName := watchKeyPrefix + target
+	previous := new(WatchSnapshot)
+	jsonStr, err := database.Get(keyName)
+	if err != nil {
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error loading snapshot for %s: %s", target, err.Error()))
+		return 0
+	} else if len(jsonStr) > 0 {
+		if err = json.Unmarshal([]byte(jsonStr), previous); err != nil {
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error loading snapshot for %s: %s", target, err.Error()))
+			previous = nil
+		}
+	} else {
+		previous = nil
+	}
+
+	// Compare with the last known state (a failed run keeps the pubkey and capabilities it could not get)
+	var events []*WatchEvent
+	if previous != nil {
+		events = compareWatchSnapshots(previous, current)
+		current.carryForward(previous)
+	}
+
+	// Store the new snapshot (no expiration)
+	var data []byte
+	if data, err = json.Marshal(current); err == nil {
+		err = database.Set(keyName, string(data), 0)
+	}
+	if err != nil {
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error storing snapshot for %s: %s", target, err.Error()))
+	}
+
+	// First time for this target
+	if previous == nil {
+		logWithContext(ctx, chalker.INFO, fmt.Sprintf("Recorded the first snapshot for %s", color.CyanString(target)))
+		return 0
+	}
+
+	// Report the changes
+	for _, event := range events {
+		emitWatchEvent(ctx, event)
+	}
+	if len(events) == 0 {
+		logWithContext(ctx, chalker.SUCCESS, fmt.Sprintf("No changes for %s since %s", color.CyanString(target), previous.CheckedAt.Format(time.RFC3339)))
+	}
+	return len(events)
+}
+
+// takeWatchSnapshot will get the current state of the target (using the same flows as the other commands, no cache)
+// All logs and traces are kept in the report of the context (see: withReport)
+func takeWatchSnapshot(ctx context.Context, target string) *WatchSnapshot {
+	snapshot := &WatchSnapshot{CheckedAt: time.Now().UTC(), Target: target}
+
+	domain := target
+	var alias string
+	if strings.Contains(target, "@") {
+		alias, domain, _ = paymail.SanitizePaymail(target)
+	}
+
+	// Get the SRV records (not used with a custom host), the checks use the first target (RFC 2782 order)
+	checkDomain, checkPort := domain, paymail.DefaultPort
+	if len(hostOverride) > 0 {
+		var err error
+		if checkDomain, checkPort, err = splitHostOverride(hostOverride); err != nil {
+			snapshot.Error = err.Error()
+			return snapshot
+		}
+	} else if records, err := getSrvRecords(ctx, domain, false); err != nil {
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("Error getting SRV record: %s", err.Error()))
+	} else if len(records) > 0 {
+		snapshot.SRV = srvTargetsString(records)
+		selected := selectSrvTargets(records)[0]
+		checkDomain, checkPort = selected.Target, int(selected.Port)
+	}
+
+	// DNSSEC and SSL of the target (a check that could not finish is an error, not invalid)
+	if !skipDNSCheck {
+		resolver := &dnsreport.Client{NameServer: nameServer, Timeout: dnsTimeout}
+		if chain, err := resolver.Chain(ctx, checkDomain, 0, time.Now()); err != nil {
+			logWithContext(ctx, chalker.WARN, fmt.Sprintf("Error checking DNSSEC: %s", requestError(ctx, "dnssec", err).Error()))
+			snapshot.DNSSEC = watchStatusError
+		} else {
+			snapshot.DNSSEC = watchStatus(chain.Secure)
+		}
+	}
+	if !skipSSLCheck {
+		inspector := &tlsreport.Client{Insecure: insecure, Timeout: sslTimeout}
+		if report, err := inspector.Inspect(ctx, checkDomain, checkPort, 0, 0, time.Now()); err != nil {
+			logWithContext(ctx, chalker.WARN, fmt.Sprintf("Error checking SSL: %s", requestError(ctx, "ssl", err).Error()))
+			snapshot.SSL = watchStatusError
+		} else {
+			snapshot.SSL = watchStatus(report.Valid)
+		}
+	}
+
+	// Get the capabilities
+	capabilities, err := getCapabilities(ctx, domain, false)
+	if err != nil {
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error getting capabilities: %s", err.Error()))
+		snapshot.Error = "capabilities: " + err.Error()
+		return snapshot
+	}
+	snapshot.Capabilities = capabilityStrings(capabilities.Capabilities)
+
+	// Get the PKI (addresses only)
+	if len(alias) == 0 {
+		return snapshot
+	}
+	pkiURL := capabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
+	if len(pkiURL) == 0 {
+		snapshot.Error = "missing capability: " + paymail.BRFCPki
+		return snapshot
+	}
+	var pki *paymail.PKIResponse
+	if pki, err = getPki(ctx, pkiURL, alias, domain, false); err != nil {
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error getting PKI: %s", err.Error()))
+		snapshot.Error = "pki: " + err.Error()
+		return snapshot
+	}
+	snapshot.PubKey = pki.PubKey
+
+	return snapshot
+}
+
+// compareWatchSnapshots will return all changes between two snapshots of the same target
+func compareWatchSnapshots(previous, current *WatchSnapshot) (events []*WatchEvent) {
+	add := func(field, oldValue, newValue string) {
+		if oldValue == newValue {
+			return
+		}
+		change := watchChangeChanged
+		if len(oldValue) == 0 {
+			change = watchChangeAdded
+		} else if len(newValue) == 0 {
+			change = watchChangeRemoved
+		}
+		events = append(events, &WatchEvent{
+			Change:     change,
+			DetectedAt: current.CheckedAt,
+			Field:      field,
+			New:        newValue,
+			Old:        oldValue,
+			Target:     current.Target,
+		})
+	}
+
+	add("error", previous.Error, current.Error)
+	add("srv", previous.SRV, current.SRV)
+	add("ssl", previous.SSL, current.SSL)
+	add("dnssec", previous.DNSSEC, current.DNSSEC)
+
+	// Only compare the pubkey and capabilities if both are known (a failed run is covered by the error event)
+	if len(previous.PubKey) > 0 && len(current.PubKey) > 0 {
+		add("pubkey", previous.PubKey, current.PubKey)
+	}
+	if previous.Capabilities != nil && current.Capabilities != nil {
+		for _, change := range diffCapabilities(previous.Capabilities, current.Capabilities) {
+			add("capability:"+change.BRFC, change.Old, change.New)
+		}
+	}
+	return events
+}
+
+// carryForward will keep the last known pubkey and capabilities when this run could not get them
+func (s *WatchSnapshot) carryForward(previous *WatchSnapshot) {
+	if s.Capabilities == nil {
+		s.Capabilities = previous.Capabilities
+	}
+	if len(s.PubKey) == 0 {
+		s.PubKey = previous.PubKey
+	}
+}
+
+// emitWatchEvent will log the change (and write it right away for ndjson)
+func emitWatchEvent(ctx context.Context, event *WatchEvent) {
+	switch event.Change {
+	case watchChangeAdded:
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("%s %s added: %s", event.Target, event.Field, event.New))
+	case watchChangeRemoved:
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("%s %s removed (was: %s)", event.Target, event.Field, event.Old))
+	default:
+		logWithContext(ctx, chalker.WARN, fmt.Sprintf("%s %s changed: %s -> %s", event.Target, event.Field, event.Old, event.New))
+	}
+
+	report := reportFromContext(ctx)
+	report.mu.Lock()
+	report.WatchEvents = append(report.WatchEvents, event)
+	report.mu.Unlock()
+
+	if outputFormat == outputNDJSON {
+		if err := writeDocument(event); err != nil {
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error rendering output: %s", err.Error()))
+		}
+	}
+}
+
+// keepWatchRun will keep the logs and traces of the latest run in the report of the command (and the latest change events)
+func keepWatchRun(report *Report) {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	runReport.mu.Lock()
+	defer runReport.mu.Unlock()
+
+	runReport.CacheHits, runReport.Errors, runReport.Traces, runReport.Warnings = report.CacheHits, report.Errors, report.Traces, report.Warnings
+	runReport.WatchEvents = append(runReport.WatchEvents, report.WatchEvents...)
+	if dropped := len(runReport.WatchEvents) - maxWatchEvents; dropped > 0 {
+		runReport.WatchEvents = runReport.WatchEvents[dropped:]
+	}
+}
+
+// watchStatus returns the status of a check for the snapshot
+func watchStatus(valid bool) string {
+	if valid {
+		return watchStatusValid
+	}
+	return watchStatusInvalid
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	// Set the input file
+	watchCmd.Flags().StringVarP(&inputFile, "input", "i", "", "File of paymail addresses and domains to watch (one per line), use - for stdin")
+
+	// Set the interval
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", defaultWatchInterval, "Time between each run")
+
+	// Set the number of runs
+	watchCmd.Flags().IntVar(&watchCount, "count", 0, "Stop after this many runs (0 = until CTRL+C)")
+
+	// Run the DNSSEC check on the target domain
+	watchCmd.Flags().BoolVarP(&skipDNSCheck, "skip-dnssec", "d", false, "Skip checking DNSSEC of the target domain")
+
+	// Run the SSL check on the target domain
+	watchCmd.Flags().BoolVar(&skipSSLCheck, "skip-ssl", false, "Skip checking SSL of the target domain")
+}
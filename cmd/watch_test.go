@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCompareWatchSnapshots will test the method compareWatchSnapshots()
+func TestCompareWatchSnapshots(t *testing.T) {
+	t.Parallel()
+
+	checkedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	base := WatchSnapshot{
+		Capabilities: map[string]string{"pki": "https://example.com/pki", "f12f968c92d6": "true"},
+		DNSSEC:       watchStatusValid,
+		PubKey:       "02aaaa",
+		SRV:          "example.com:443",
+		SSL:          watchStatusValid,
+		Target:       "mrz@example.com",
+	}
+	with := func(change func(snapshot *WatchSnapshot)) *WatchSnapshot {
+		snapshot := base
+		snapshot.CheckedAt = checkedAt
+		change(&snapshot)
+		return &snapshot
+	}
+	event := func(change, field, oldValue, newValue string) *WatchEvent {
+		return &WatchEvent{Change: change, DetectedAt: checkedAt, Field: field, New: newValue, Old: oldValue, Target: base.Target}
+	}
+
+	var tests = []struct {
+		name     string
+		previous *WatchSnapshot
+		current  *WatchSnapshot
+		expected []*WatchEvent
+	}{
+		{"no changes", &base, with(func(*WatchSnapshot) {}), nil},
+		{"pubkey changed", &base, with(func(s *WatchSnapshot) { s.PubKey = "03bbbb" }), []*WatchEvent{
+			event(watchChangeChanged, "pubkey", "02aaaa", "03bbbb"),
+		}},
+		{"srv, ssl and dnssec", &base, with(func(s *WatchSnapshot) {
+			s.SRV, s.SSL, s.DNSSEC = "other.com:443", watchStatusInvalid, ""
+		}), []*WatchEvent{
+			event(watchChangeChanged, "srv", "example.com:443", "other.com:443"),
+			event(watchChangeChanged, "ssl", watchStatusValid, watchStatusInvalid),
+			event(watchChangeRemoved, "dnssec", watchStatusValid, ""),
+		}},
+		{"check errors", &base, with(func(s *WatchSnapshot) { s.SSL, s.DNSSEC = watchStatusError, watchStatusError }), []*WatchEvent{
+			event(watchChangeChanged, "ssl", watchStatusValid, watchStatusError),
+			event(watchChangeChanged, "dnssec", watchStatusValid, watchStatusError),
+		}},
+		{"capabilities", &base, with(func(s *WatchSnapshot) {
+			s.Capabilities = map[string]string{"pki": "https://example.com/v2/pki", "a9f510c16bde": "https://example.com/verify"}
+		}), []*WatchEvent{
+			event(watchChangeAdded, "capability:a9f510c16bde", "", "https://example.com/verify"),
+			event(watchChangeRemoved, "capability:f12f968c92d6", "true", ""),
+			event(watchChangeChanged, "capability:pki", "https://example.com/pki", "https://example.com/v2/pki"),
+		}},
+		{"failed run skips the unknown pubkey and capabilities", &base, with(func(s *WatchSnapshot) {
+			s.Capabilities, s.Error, s.PubKey = nil, "capabilities: timeout", ""
+		}), []*WatchEvent{
+			event(watchChangeAdded, "error", "", "capabilities: timeout"),
+		}},
+		{"failed pki still compares the capabilities", &base, with(func(s *WatchSnapshot) {
+			s.Capabilities = map[string]string{"pki": "https://example.com/pki"}
+			s.Error, s.PubKey = "pki: not found", ""
+		}), []*WatchEvent{
+			event(watchChangeAdded, "error", "", "pki: not found"),
+			event(watchChangeRemoved, "capability:f12f968c92d6", "true", ""),
+		}},
+		{"recovered run compares with the carried state", with(func(s *WatchSnapshot) {
+			s.Error = "capabilities: timeout"
+		}), with(func(s *WatchSnapshot) { s.PubKey = "03bbbb" }), []*WatchEvent{
+			event(watchChangeRemoved, "error", "capabilities: timeout", ""),
+			event(watchChangeChanged, "pubkey", "02aaaa", "03bbbb"),
+		}},
+		{"nothing known before", with(func(s *WatchSnapshot) {
+			s.Capabilities, s.Error, s.PubKey = nil, "capabilities: timeout", ""
+		}), with(func(*WatchSnapshot) {}), []*WatchEvent{
+			event(watchChangeRemoved, "error", "capabilities: timeout", ""),
+		}},
+	}
+
+	for _, test := range tests {
+		if output := compareWatchSnapshots(test.previous, test.current); !reflect.DeepEqual(output, test.expected) {
+			t.Errorf("%s Failed: [%s] inputted and [%d] events expected, received: [%d]", t.Name(), test.name, len(test.expected), len(output))
+			for _, item := range output {
+				t.Logf("received: %+v", *item)
+			}
+		}
+	}
+}
+
+// TestWatchSnapshotCarryForward will test the method carryForward()
+func TestWatchSnapshotCarryForward(t *testing.T) {
+	t.Parallel()
+
+	previous := &WatchSnapshot{Capabilities: map[string]string{"pki": "a"}, PubKey: "02aaaa", SSL: watchStatusValid}
+
+	var tests = []struct {
+		name                 string
+		current              *WatchSnapshot
+		expectedCapabilities map[string]string
+		expectedPubKey       string
+	}{
+		{"failed run", &WatchSnapshot{Error: "timeout"}, map[string]string{"pki": "a"}, "02aaaa"},
+		{"failed pki", &WatchSnapshot{Capabilities: map[string]string{"pki": "b"}, Error: "pki: timeout"}, map[string]string{"pki": "b"}, "02aaaa"},
+		{"successful run", &WatchSnapshot{Capabilities: map[string]string{}, PubKey: "03bbbb"}, map[string]string{}, "03bbbb"},
+	}
+
+	for _, test := range tests {
+		test.current.carryForward(previous)
+		if !reflect.DeepEqual(test.current.Capabilities, test.expectedCapabilities) || test.current.PubKey != test.expectedPubKey {
+			t.Errorf("%s Failed: [%s] inputted and [%v] [%s] expected, received: [%v] [%s]", t.Name(), test.name, test.expectedCapabilities, test.expectedPubKey, test.current.Capabilities, test.current.PubKey)
+		} else if len(test.current.SSL) > 0 {
+			t.Errorf("%s Failed: [%s] inputted and only the pubkey and capabilities expected, received ssl: [%s]", t.Name(), test.name, test.current.SSL)
+		}
+	}
+}
+
+// TestTakeWatchSnapshot will test the method takeWatchSnapshot() (against the mock provider)
+func TestTakeWatchSnapshot(t *testing.T) {
+	// Not parallel: points the requests at the mock provider (global flags)
+
+	startMockProvider(t, false, nil)
+	previousDNS, previousSSL := skipDNSCheck, skipSSLCheck
+	skipDNSCheck = true
+	t.Cleanup(func() {
+		skipDNSCheck, skipSSLCheck = previousDNS, previousSSL
+	})
+	mockHost := hostOverride
+
+	var tests = []struct {
+		name          string
+		target        string
+		host          string
+		insecure      bool
+		skipSSL       bool
+		expectedSSL   string
+		expectedError string // Prefix
+		expectedKey   bool
+	}{
+		{"address", "satoshi@paymail.test", mockHost, true, false, watchStatusValid, "", true},
+		{"domain", "paymail.test", mockHost, true, false, watchStatusValid, "", false},
+		{"untrusted certificate", "satoshi@paymail.test", mockHost, false, false, watchStatusInvalid, "capabilities: ", false},
+		{"ssl check failed", "satoshi@paymail.test", "127.0.0.1:1", true, false, watchStatusError, "capabilities: ", false},
+		{"ssl check skipped", "satoshi@paymail.test", mockHost, true, true, "", "", true},
+		{"invalid host", "satoshi@paymail.test", "127.0.0.1:port", true, false, "", "invalid", false},
+	}
+
+	for _, test := range tests {
+		hostOverride, insecure, skipSSLCheck = test.host, test.insecure, test.skipSSL
+		snapshot := takeWatchSnapshot(context.Background(), test.target)
+		if snapshot.SSL != test.expectedSSL || len(snapshot.DNSSEC) > 0 {
+			t.Errorf("%s Failed: [%s] inputted and ssl [%s] expected, received: [%s] dnssec [%s]", t.Name(), test.name, test.expectedSSL, snapshot.SSL, snapshot.DNSSEC)
+		}
+		if !strings.HasPrefix(snapshot.Error, test.expectedError) || (len(test.expectedError) == 0) != (len(snapshot.Error) == 0) {
+			t.Errorf("%s Failed: [%s] inputted and error [%s] expected, received: [%s]", t.Name(), test.name, test.expectedError, snapshot.Error)
+		}
+		if (len(snapshot.PubKey) > 0) != test.expectedKey {
+			t.Errorf("%s Failed: [%s] inputted and pubkey %v expected, received: [%s]", t.Name(), test.name, test.expectedKey, snapshot.PubKey)
+		}
+		if len(test.expectedError) == 0 && len(snapshot.Capabilities) == 0 {
+			t.Errorf("%s Failed: [%s] inputted and the capabilities expected", t.Name(), test.name)
+		}
+	}
+}
+
+// TestSanitizeWatchTarget will test the method sanitizeWatchTarget()
+func TestSanitizeWatchTarget(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		value    string
+		expected string
+	}{
+		{"mrz@moneybutton.com", "mrz@moneybutton.com"},
+		{"MRZ@MoneyButton.com", "mrz@moneybutton.com"},
+		{"moneybutton.com", "moneybutton.com"},
+		{"https://www.moneybutton.com/", "moneybutton.com"},
+		{"mrz@", ""},
+		{"@moneybutton.com", ""},
+		{"not a domain", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if output := sanitizeWatchTarget(test.value); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.value, test.expected, output)
+		}
+	}
+}
+
+// TestKeepWatchRun will test the method keepWatchRun()
+func TestKeepWatchRun(t *testing.T) {
+	original := runReport
+	runReport = newReport("watch", nil)
+	t.Cleanup(func() {
+		runReport = original
+	})
+
+	for run := 1; run <= 3; run++ {
+		report := newReport("watch", nil)
+		report.Errors = []string{fmt.Sprintf("error in run %d", run)}
+		for i := 0; i < maxWatchEvents/2+1; i++ {
+			report.WatchEvents = append(report.WatchEvents, &WatchEvent{Field: fmt.Sprintf("run-%d", run)})
+		}
+		keepWatchRun(report)
+	}
+
+	if !reflect.DeepEqual(runReport.Errors, []string{"error in run 3"}) {
+		t.Errorf("%s Failed: only the errors of the latest run expected, received: [%v]", t.Name(), runReport.Errors)
+	}
+	if len(runReport.WatchEvents) != maxWatchEvents {
+		t.Fatalf("%s Failed: [%d] events expected, received: [%d]", t.Name(), maxWatchEvents, len(runReport.WatchEvents))
+	}
+	if first, last := runReport.WatchEvents[0].Field, runReport.WatchEvents[maxWatchEvents-1].Field; first != "run-2" || last != "run-3" {
+		t.Errorf("%s Failed: the latest events expected (run-2 to run-3), received: [%s] to [%s]", t.Name(), first, last)
+	}
+}
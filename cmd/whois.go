@@ -1,8 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"strconv"
+	"os"
 	"strings"
 	"sync"
 
@@ -13,6 +14,9 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// defaultWhoisConcurrency is the number of providers searched at the same time
+const defaultWhoisConcurrency = 4
+
 // whoisCmd represents the whois command
 var whoisCmd = &cobra.Command{
 	Use:        "whois",
@@ -22,7 +26,9 @@ var whoisCmd = &cobra.Command{
 	Example: applicationName + ` whois mrz
 ` + applicationName + ` w mrz
 ` + applicationName + ` w \$mr-z
-` + applicationName + ` w 1mrz`,
+` + applicationName + ` w 1mrz
+` + applicationName + ` w mrz --provider example.com --provider example.org
+` + applicationName + ` w mrz --concurrency 8`,
 	Long: color.GreenString(`
         .__           .__        
 __  _  _|  |__   ____ |__| ______
@@ -32,7 +38,10 @@ __  _  _|  |__   ____ |__| ______
              \/               \/`) + `
 ` + color.YellowString(`
 
-Search `+strconv.Itoa(len(providers))+` public paymail providers for a handle.`),
+Search public paymail providers for a handle.
+
+Providers are loaded from the registry (see: `+applicationName+` providers) and the config file (providers),
+use --provider to search additional domains for one run. At most --concurrency providers are searched at the same time.`),
 	Args: func(_ *cobra.Command, args []string) error {
 		if len(args) < 1 {
 			return chalker.Error("whois requires a handle")
@@ -41,19 +50,11 @@ Search `+strconv.Itoa(len(providers))+` public paymail providers for a handle.`)
 		}
 		return nil
 	},
-	Run: func(_ *cobra.Command, args []string) {
-		// Handle to search
-		var handle string
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := cmd.Context()
 
-		// Are we using a paymail address?
-		if strings.Contains(args[0], "@") {
-			handle, _, _ = paymail.SanitizePaymail(args[0])
-		} else { // Using an alias or $handle
-			handle, _, _ = paymail.SanitizePaymail(paymail.ConvertHandle(args[0], false))
-		}
-
-		// Sanitize
-		handle = sanitize.Custom(handle, `[^a-zA-Z0-9-_.+]`)
+		// Handle to search
+		handle := sanitizeHandle(args[0])
 
 		// Invalid handle?
 		if len(handle) == 0 || len(handle) > 255 {
@@ -61,18 +62,12 @@ Search `+strconv.Itoa(len(providers))+` public paymail providers for a handle.`)
 			return
 		}
 
-		// List of paymails found
-		var paymails []*PaymailDetails
+		// Load the providers (registry, config and flags)
+		providers := getProviders(whoisProviders)
 
-		// Loop each provider (break into a Go routine for each provider)
-		var wg sync.WaitGroup
-		for _, provider := range providers {
-			wg.Add(1)
-			go fetchPaymailInfo(&wg, handle, provider, &paymails)
-		}
-
-		// Waiting for all providers to finish
-		wg.Wait()
+		// Search all providers
+		paymails := searchProviders(ctx, handle, providers, whoisConcurrency, true)
+		runReport.Paymails = paymails
 
 		// If we don't have results
 		if len(paymails) == 0 {
@@ -85,31 +80,61 @@ Search `+strconv.Itoa(len(providers))+` public paymail providers for a handle.`)
 
 		// Loop results
 		for _, result := range paymails {
-			result.Display()
+			result.Display(ctx)
 		}
 	},
 }
 
-// fetchPaymailInfo will get the paymail information from the provider
-func fetchPaymailInfo(wg *sync.WaitGroup, handle string, provider *Provider, results *[]*PaymailDetails) {
-	defer wg.Done()
+// sanitizeHandle will return the alias from a paymail address, alias or $handle
+func sanitizeHandle(value string) (handle string) {
+	if strings.Contains(value, "@") {
+		handle, _, _ = paymail.SanitizePaymail(value)
+	} else {
+		handle, _, _ = paymail.SanitizePaymail(paymail.ConvertHandle(value, false))
+	}
+	return sanitize.Custom(handle, `[^a-zA-Z0-9-_.+]`)
+}
 
+// searchProviders will search all providers for the handle (at most workers at the same time)
+// The logs of each provider are written as one block and the results are in the order of the providers
+//
+// live shows the progress line on a terminal (the command), the API only writes the logs
+func searchProviders(ctx context.Context, handle string, providers []*Provider, workers int, live bool) (paymails []*PaymailDetails) {
+	// Each result is kept at the index of its provider
+	results := make([]*PaymailDetails, len(providers))
+	progress := newSearchProgress(providers, live)
+	runJobs(ctx, len(providers), workers, false, func(ctx context.Context, index int) {
+		results[index] = fetchPaymailInfo(ctx, handle, providers[index])
+	}, progress.done)
+	progress.clear()
+
+	// Keep the order of the providers
+	for _, result := range results {
+		if result != nil {
+			paymails = append(paymails, result)
+		}
+	}
+	return paymails
+}
+
+// fetchPaymailInfo will get the paymail information from the provider (nil if the provider failed)
+func fetchPaymailInfo(ctx context.Context, handle string, provider *Provider) *PaymailDetails {
 	// Get the capabilities
-	capabilities, err := getCapabilities(provider.Domain, true)
+	capabilities, err := getCapabilities(ctx, provider.Domain, true)
 	if err != nil {
-		if strings.Contains(err.Error(), "context deadline exceeded") {
-			chalker.Log(chalker.WARN, fmt.Sprintf("No capabilities found for: %s", provider.Domain))
+		if isTimeout(err) {
+			logWithContext(ctx, chalker.WARN, fmt.Sprintf("No capabilities found for: %s (%s)", provider.Domain, err.Error()))
 		} else {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
 		}
-		return
+		return nil
 	}
 
 	// Set the URL - Does the paymail provider have the capability?
 	pkiURL := capabilities.GetString(paymail.BRFCPki, paymail.BRFCPkiAlternate)
 	if len(pkiURL) == 0 {
-		chalker.Log(chalker.ERROR, fmt.Sprintf("The provider %s is missing a required capability: %s", provider.Domain, paymail.BRFCPki))
-		return
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("The provider %s is missing a required capability: %s", provider.Domain, paymail.BRFCPki))
+		return nil
 	}
 
 	// Create result
@@ -119,25 +144,98 @@ func fetchPaymailInfo(wg *sync.WaitGroup, handle string, provider *Provider, res
 	}
 
 	// Get the PKI for the given address
-	if result.PKI, err = getPki(pkiURL, handle, provider.Domain, true); err != nil || result.PKI == nil {
+	if result.PKI, err = getPki(ctx, pkiURL, handle, provider.Domain, true); err != nil || result.PKI == nil {
 		if err != nil {
-			chalker.Log(chalker.ERROR, fmt.Sprintf("Search response: %s", err.Error()))
+			logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Search response: %s", err.Error()))
 		}
-		*results = append(*results, result)
-		return
+		return result
 	}
 
 	// Get all the public info
-	if err = result.GetPublicInfo(capabilities); err != nil {
-		chalker.Log(chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+	if err = result.GetPublicInfo(ctx, capabilities); err != nil {
+		logWithContext(ctx, chalker.ERROR, fmt.Sprintf("Error: %s", err.Error()))
+	}
+
+	return result
+}
+
+// searchProgressNames is the number of pending providers shown on the progress line
+const searchProgressNames = 4
+
+// searchProgress writes the logs of each finished provider and a live line with the pending providers
+// (the line is only shown if stderr is a terminal)
+type searchProgress struct {
+	finished  []bool
+	live      bool
+	mu        sync.Mutex
+	providers []*Provider
+}
+
+// newSearchProgress will start the progress for the providers (the line is only shown if live)
+func newSearchProgress(providers []*Provider, live bool) *searchProgress {
+	p := &searchProgress{
+		finished:  make([]bool, len(providers)),
+		live:      live && isTerminal(os.Stderr),
+		providers: providers,
 	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.render()
+	return p
+}
 
-	// Add to list
-	*results = append(*results, result)
+// done will write the logs of the finished provider (as one block) and update the progress line
+func (p *searchProgress) done(index int, buffer *chalker.Buffer) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished[index] = true
+	p.erase()
+	buffer.Flush()
+	p.render()
+}
+
+// clear will remove the progress line
+func (p *searchProgress) clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.erase()
+}
+
+// erase will remove the current progress line
+func (p *searchProgress) erase() {
+	if p.live {
+		_, _ = fmt.Fprint(os.Stderr, "\r\033[K")
+	}
+}
+
+// render will write the progress line (without a new line)
+func (p *searchProgress) render() {
+	if !p.live {
+		return
+	}
+	var pending []string
+	for index, provider := range p.providers {
+		if !p.finished[index] {
+			pending = append(pending, provider.Domain)
+		}
+	}
+	if len(pending) == 0 {
+		return
+	}
+	names := pending
+	if len(names) > searchProgressNames {
+		names = append(names[:searchProgressNames:searchProgressNames], fmt.Sprintf("+%d more", len(pending)-searchProgressNames))
+	}
+	_, _ = fmt.Fprint(os.Stderr, color.CyanString("Searching %d/%d providers, pending: %s",
+		len(p.providers)-len(pending), len(p.providers), strings.Join(names, ", ")))
 }
 
 func init() {
 	rootCmd.AddCommand(whoisCmd)
 
-	// todo: flag for custom provider (not in the list)
+	// Set the concurrency
+	whoisCmd.Flags().IntVar(&whoisConcurrency, "concurrency", defaultWhoisConcurrency, "Number of providers searched at the same time")
+
+	// Add custom providers (not in the list)
+	whoisCmd.Flags().StringArrayVar(&whoisProviders, "provider", nil, "Additional provider domain to search (repeatable)")
 }
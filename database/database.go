@@ -4,6 +4,7 @@ Package database is for a local storage layer for the application
 package database
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"os"
@@ -15,6 +16,21 @@ import (
 
 var db *badger.DB // The active database connection
 
+// metaStoredAt is set (user meta) on values that start with the time they were stored
+const (
+	metaStoredAt   byte = 1
+	storedAtLength      = 8 // Unix nano (big endian)
+)
+
+// Item is a stored key and its metadata
+type Item struct {
+	ExpiresAt time.Time // Zero if the key does not expire
+	Key       string    // The full key
+	Size      int64     // Estimated size of the key and value
+	StoredAt  time.Time // Zero if unknown (stored before timestamps were kept)
+	Value     string    // Only set by GetItem()
+}
+
 // Connect will make a new database connection and new folder/file(s) if needed
 func Connect(folder, database string) (err error) {
 	// Get the home dir
@@ -41,7 +57,10 @@ func Set(key, value string, ttl time.Duration) error {
 		return fmt.Errorf("database is not connected")
 	}
 	return db.Update(func(txn *badger.Txn) error {
-		entry := badger.NewEntry([]byte(key), []byte(value))
+		// Keep the time the value was stored (used for the age of cached models)
+		data := make([]byte, storedAtLength, storedAtLength+len(value))
+		binary.BigEndian.PutUint64(data, uint64(time.Now().UnixNano()))
+		entry := badger.NewEntry([]byte(key), append(data, value...)).WithMeta(metaStoredAt)
 		if ttl > 0 {
 			entry = entry.WithTTL(ttl)
 		}
@@ -52,17 +71,24 @@ func Set(key, value string, ttl time.Duration) error {
 
 // Get will retrieve a value from a key (if found)
 func Get(key string) (string, error) {
+	item, err := GetItem(key)
+	if err != nil || item == nil {
+		return "", err
+	}
+	return item.Value, nil
+}
+
+// GetItem will retrieve a key, value and metadata (nil if not found)
+func GetItem(key string) (item *Item, err error) {
 	if db == nil {
-		return "", fmt.Errorf("database is not connected")
+		return nil, fmt.Errorf("database is not connected")
 	}
-	var valCopy []byte
-	err := db.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
+	err = db.View(func(txn *badger.Txn) error {
+		badgerItem, err := txn.Get([]byte(key))
 		if err != nil {
 			return err
 		}
-
-		valCopy, err = item.ValueCopy(nil)
+		item, err = newItem(badgerItem, true)
 		return err
 	})
 
@@ -71,7 +97,95 @@ func Get(key string) (string, error) {
 		err = nil
 	}
 
-	return string(valCopy), err
+	return item, err
+}
+
+// List will return all keys (and metadata) that start with the prefix (empty for all keys)
+func List(prefix string) (items []*Item, err error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is not connected")
+	}
+	err = db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		iterator := txn.NewIterator(opts)
+		defer iterator.Close()
+
+		for iterator.Rewind(); iterator.Valid(); iterator.Next() {
+			item, err := newItem(iterator.Item(), false)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		return nil
+	})
+	return items, err
+}
+
+// Delete will remove the keys
+func Delete(keys ...string) error {
+	if db == nil {
+		return fmt.Errorf("database is not connected")
+	}
+	batch := db.NewWriteBatch()
+	defer batch.Cancel()
+	for _, key := range keys {
+		if err := batch.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// DeletePrefix will remove all keys that start with the prefix (returns the number of keys removed)
+func DeletePrefix(prefix string) (int, error) {
+	items, err := List(prefix)
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	return len(keys), Delete(keys...)
+}
+
+// Size returns the size of the LSM tree and the value log (in bytes)
+func Size() (lsm, vlog int64, err error) {
+	if db == nil {
+		return 0, 0, fmt.Errorf("database is not connected")
+	}
+	lsm, vlog = db.Size()
+	return lsm, vlog, nil
+}
+
+// newItem will create an item from a badger item (the value is only kept if requested)
+func newItem(badgerItem *badger.Item, withValue bool) (*Item, error) {
+	item := &Item{
+		Key:  string(badgerItem.KeyCopy(nil)),
+		Size: badgerItem.EstimatedSize(),
+	}
+	if expiresAt := badgerItem.ExpiresAt(); expiresAt > 0 {
+		item.ExpiresAt = time.Unix(int64(expiresAt), 0) //nolint:gosec // G115 - unix time fits
+	}
+
+	// Values without metadata are returned as-is (stored before timestamps were kept)
+	timestamped := badgerItem.UserMeta()&metaStoredAt != 0
+	if !timestamped && !withValue {
+		return item, nil
+	}
+	return item, badgerItem.Value(func(value []byte) error {
+		if timestamped && len(value) >= storedAtLength {
+			item.StoredAt = time.Unix(0, int64(binary.BigEndian.Uint64(value[:storedAtLength]))) //nolint:gosec // G115 - unix time fits
+			value = value[storedAtLength:]
+		}
+		if withValue {
+			item.Value = string(value)
+		}
+		return nil
+	})
 }
 
 // Flush will empty the entire database
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// connectTest will connect to an in-memory database for the test
+func connectTest(t *testing.T) {
+	var err error
+	if db, err = badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil)); err != nil {
+		t.Fatalf("%s Failed: error opening the database: %s", t.Name(), err.Error())
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+		db = nil
+	})
+}
+
+// setTest will store all keys for the test
+func setTest(t *testing.T, keys ...string) {
+	for _, key := range keys {
+		if err := Set(key, "value-"+key, 0); err != nil {
+			t.Fatalf("%s Failed: error storing %s: %s", t.Name(), key, err.Error())
+		}
+	}
+}
+
+// listKeys returns the keys of all items that start with the prefix
+func listKeys(t *testing.T, prefix string) (keys []string) {
+	items, err := List(prefix)
+	if err != nil {
+		t.Fatalf("%s Failed: error listing %s: %s", t.Name(), prefix, err.Error())
+	}
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	return keys
+}
+
+// TestNotConnected will test all methods without a connection
+func TestNotConnected(t *testing.T) {
+	if err := Set("key", "value", 0); err == nil {
+		t.Errorf("%s Failed: Set() expected an error", t.Name())
+	}
+	if _, err := GetItem("key"); err == nil {
+		t.Errorf("%s Failed: GetItem() expected an error", t.Name())
+	}
+	if _, err := List(""); err == nil {
+		t.Errorf("%s Failed: List() expected an error", t.Name())
+	}
+	if err := Delete("key"); err == nil {
+		t.Errorf("%s Failed: Delete() expected an error", t.Name())
+	}
+	if _, err := DeletePrefix("key"); err == nil {
+		t.Errorf("%s Failed: DeletePrefix() expected an error", t.Name())
+	}
+}
+
+// TestList will test the method List()
+func TestList(t *testing.T) {
+	connectTest(t)
+	setTest(t, "model-pki-b@example.com", "model-pki-a@example.com", "model-srv-example.com", "other")
+
+	var tests = []struct {
+		prefix   string
+		expected []string
+	}{
+		{"", []string{"model-pki-a@example.com", "model-pki-b@example.com", "model-srv-example.com", "other"}},
+		{"model-", []string{"model-pki-a@example.com", "model-pki-b@example.com", "model-srv-example.com"}},
+		{"model-pki-", []string{"model-pki-a@example.com", "model-pki-b@example.com"}},
+		{"model-pki-a", []string{"model-pki-a@example.com"}},
+		{"missing-", nil},
+	}
+
+	for _, test := range tests {
+		if output := listKeys(t, test.prefix); !slices.Equal(output, test.expected) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.prefix, test.expected, output)
+		}
+	}
+
+	// The values are not loaded
+	items, _ := List("other")
+	if len(items) != 1 || len(items[0].Value) > 0 || items[0].Size <= 0 {
+		t.Errorf("%s Failed: expected one item with a size and no value, received: [%+v]", t.Name(), items)
+	}
+}
+
+// TestDeletePrefix will test the method DeletePrefix()
+func TestDeletePrefix(t *testing.T) {
+	var tests = []struct {
+		prefix    string
+		removed   int
+		remaining []string
+	}{
+		{"model-pki-", 2, []string{"model-srv-example.com", "other"}},
+		{"model-", 3, []string{"other"}},
+		{"missing-", 0, []string{"model-pki-a@example.com", "model-pki-b@example.com", "model-srv-example.com", "other"}},
+		{"", 4, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.prefix, func(t *testing.T) {
+			connectTest(t)
+			setTest(t, "model-pki-a@example.com", "model-pki-b@example.com", "model-srv-example.com", "other")
+
+			removed, err := DeletePrefix(test.prefix)
+			if err != nil {
+				t.Fatalf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.prefix, err.Error())
+			} else if removed != test.removed {
+				t.Errorf("%s Failed: [%s] inputted and [%d] removed expected, received: [%d]", t.Name(), test.prefix, test.removed, removed)
+			}
+			if output := listKeys(t, ""); !slices.Equal(output, test.remaining) {
+				t.Errorf("%s Failed: [%s] inputted and [%v] remaining expected, received: [%v]", t.Name(), test.prefix, test.remaining, output)
+			}
+		})
+	}
+}
+
+// TestDelete will test the method Delete()
+func TestDelete(t *testing.T) {
+	connectTest(t)
+	setTest(t, "a", "b", "c")
+
+	if err := Delete("a", "c", "missing"); err != nil {
+		t.Fatalf("%s Failed: received an error: %s", t.Name(), err.Error())
+	}
+	if output := listKeys(t, ""); !slices.Equal(output, []string{"b"}) {
+		t.Errorf("%s Failed: [b] remaining expected, received: [%v]", t.Name(), output)
+	}
+}
+
+// TestStoredAt will test the time kept with each value (and values stored before timestamps were kept)
+func TestStoredAt(t *testing.T) {
+	connectTest(t)
+
+	before := time.Now()
+	if err := Set("model-pki-mrz@moneybutton.com", `{"pubkey":"abc"}`, time.Hour); err != nil {
+		t.Fatalf("%s Failed: received an error: %s", t.Name(), err.Error())
+	}
+	after := time.Now()
+
+	// Stored before timestamps were kept (no user meta)
+	if err := db.Update(func(txn *badger.Txn) error {
+		return txn.Set([]byte("model-pki-old@moneybutton.com"), []byte(`{"pubkey":"old"}`))
+	}); err != nil {
+		t.Fatalf("%s Failed: received an error: %s", t.Name(), err.Error())
+	}
+
+	var tests = []struct {
+		key           string
+		expectedValue string
+		timestamped   bool
+		expires       bool
+	}{
+		{"model-pki-mrz@moneybutton.com", `{"pubkey":"abc"}`, true, true},
+		{"model-pki-old@moneybutton.com", `{"pubkey":"old"}`, false, false},
+	}
+
+	for _, test := range tests {
+		item, err := GetItem(test.key)
+		if err != nil || item == nil {
+			t.Fatalf("%s Failed: [%s] inputted, received: [%v] [%v]", t.Name(), test.key, item, err)
+		}
+		if item.Value != test.expectedValue {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.key, test.expectedValue, item.Value)
+		}
+		if value, _ := Get(test.key); value != test.expectedValue {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected from Get(), received: [%s]", t.Name(), test.key, test.expectedValue, value)
+		}
+		if test.timestamped && (item.StoredAt.Before(before) || item.StoredAt.After(after)) {
+			t.Errorf("%s Failed: [%s] inputted and stored between [%s] and [%s] expected, received: [%s]", t.Name(), test.key, before, after, item.StoredAt)
+		} else if !test.timestamped && !item.StoredAt.IsZero() {
+			t.Errorf("%s Failed: [%s] inputted and no stored time expected, received: [%s]", t.Name(), test.key, item.StoredAt)
+		}
+		if item.ExpiresAt.IsZero() == test.expires {
+			t.Errorf("%s Failed: [%s] inputted and expires [%v] expected, received: [%s]", t.Name(), test.key, test.expires, item.ExpiresAt)
+		}
+
+		// The listing also has the stored time (without the value)
+		items, _ := List(test.key)
+		if len(items) != 1 || !items[0].StoredAt.Equal(item.StoredAt) || len(items[0].Value) > 0 {
+			t.Errorf("%s Failed: [%s] inputted and the same stored time expected from List(), received: [%+v]", t.Name(), test.key, items)
+		}
+	}
+
+	// Not found is not an error
+	if item, err := GetItem("missing"); item != nil || err != nil {
+		t.Errorf("%s Failed: [missing] inputted and nothing expected, received: [%v] [%v]", t.Name(), item, err)
+	}
+}
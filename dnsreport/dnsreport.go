@@ -0,0 +1,83 @@
+/*
+Package dnsreport queries a name server for all records of a paymail domain (SRV, A/AAAA, CNAME, TXT, CAA)
+and flags common misconfigurations (IE: an SRV target that is a CNAME, a target without an A/AAAA record)
+*/
+package dnsreport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Defaults for the client
+const (
+	DefaultPort    = "53"            // Port of the name server (if not set in the address)
+	DefaultTimeout = 5 * time.Second // Time allowed for one query
+	ednsBufferSize = 4096            // UDP buffer size advertised with EDNS0
+)
+
+// Client sends the queries to one name server
+type Client struct {
+	NameServer string        // Address of the name server (IE: 8.8.8.8 or 8.8.8.8:53)
+	Timeout    time.Duration // Time allowed for one query
+}
+
+// Record is one DNS record (IE: A 127.0.0.1 with a TTL of 300)
+type Record struct {
+	Name  string `json:"name"`
+	TTL   uint32 `json:"ttl"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// NewRecord will convert a resource record (the value is everything after the header)
+func NewRecord(rr dns.RR) *Record {
+	header := rr.Header()
+	return &Record{
+		Name:  strings.TrimSuffix(header.Name, "."),
+		TTL:   header.Ttl,
+		Type:  dns.TypeToString[header.Rrtype],
+		Value: strings.TrimSpace(strings.TrimPrefix(rr.String(), header.String())),
+	}
+}
+
+// Query will ask the name server for the records of the name (retried over TCP if the answer was truncated)
+func (c *Client) Query(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	return c.exchange(ctx, name, qtype, false)
+}
+
+// exchange will send the question (dnssec asks for the RRSIG records and turns off validation by the server)
+func (c *Client) exchange(ctx context.Context, name string, qtype uint16, dnssec bool) (*dns.Msg, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	msg.SetEdns0(ednsBufferSize, dnssec)
+	msg.CheckingDisabled = dnssec
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	client := &dns.Client{Timeout: timeout}
+	response, _, err := client.ExchangeContext(ctx, msg, c.address())
+	if err == nil && response.Truncated {
+		client.Net = "tcp"
+		response, _, err = client.ExchangeContext(ctx, msg, c.address())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", dns.TypeToString[qtype], zoneLabel(dns.Fqdn(name)), err)
+	}
+	return response, nil
+}
+
+// address returns the name server with a port
+func (c *Client) address() string {
+	if _, _, err := net.SplitHostPort(c.NameServer); err == nil {
+		return c.NameServer
+	}
+	return net.JoinHostPort(c.NameServer, DefaultPort)
+}
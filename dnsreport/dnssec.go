@@ -0,0 +1,404 @@
+package dnsreport
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// DefaultExpiryWarning is how soon a signature must expire to be flagged
+const DefaultExpiryWarning = 7 * 24 * time.Hour
+
+// Roles of a DNSKEY (secure entry point flag)
+const (
+	RoleKSK = "KSK"
+	RoleZSK = "ZSK"
+)
+
+// rootTrustAnchors are the DS records of the root key signing keys (see: https://data.iana.org/root-anchors/root-anchors.xml)
+var rootTrustAnchors = []string{
+	". IN DS 20326 8 2 E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D",
+	". IN DS 38696 8 2 683D2D0ACB8C9B712A1948B27F741219298D0A450D612C483AF444A4C0FB2B16",
+}
+
+// Key is one DNSKEY record of a zone
+type Key struct {
+	Algorithm string `json:"algorithm"`
+	Flags     uint16 `json:"flags"`
+	KeyTag    uint16 `json:"key_tag"`
+	Role      string `json:"role"`
+	Trusted   bool   `json:"trusted"` // Matches a DS record in the parent zone (or a root trust anchor)
+}
+
+// DelegationSigner is one DS record of a zone (kept in the parent zone)
+type DelegationSigner struct {
+	Algorithm  string `json:"algorithm"`
+	Digest     string `json:"digest"`
+	DigestType string `json:"digest_type"`
+	KeyTag     uint16 `json:"key_tag"`
+	Matched    bool   `json:"matched"` // A DNSKEY of the zone matches the digest
+}
+
+// Signature is one RRSIG record (over the DNSKEY or DS records of a zone, or the records of the name)
+type Signature struct {
+	Algorithm  string    `json:"algorithm"`
+	Covered    string    `json:"covered"`
+	Error      string    `json:"error,omitempty"`
+	Expiration time.Time `json:"expiration"`
+	Inception  time.Time `json:"inception"`
+	KeyTag     uint16    `json:"key_tag"`
+	Signer     string    `json:"signer"`
+	Valid      bool      `json:"valid"`
+}
+
+// Zone is one level of the chain of trust (IE: ".", "com", "example.com")
+type Zone struct {
+	DNSKEYs    []*Key              `json:"dnskeys"`
+	DS         []*DelegationSigner `json:"ds"`
+	Name       string              `json:"name"`
+	Secure     bool                `json:"secure"`
+	Signatures []*Signature        `json:"signatures"`
+}
+
+// Chain is the chain of trust from the root to the name
+type Chain struct {
+	BrokenAt string   `json:"broken_at,omitempty"` // Zone (or name) where the chain is broken
+	Issues   []*Issue `json:"issues"`
+	Name     string   `json:"name"`
+	Secure   bool     `json:"secure"`
+	Zones    []*Zone  `json:"zones"`
+}
+
+// Chain will walk the chain of trust from the root to the name (DS -> DNSKEY -> RRSIG at each zone cut)
+//
+// Signatures expiring within expiryWarning (0 uses the default) are flagged
+func (c *Client) Chain(ctx context.Context, name string, expiryWarning time.Duration, now time.Time) (*Chain, error) {
+	if expiryWarning <= 0 {
+		expiryWarning = DefaultExpiryWarning
+	}
+	name = dns.Fqdn(strings.ToLower(name))
+	chain := &Chain{Issues: []*Issue{}, Name: strings.TrimSuffix(name, "."), Zones: []*Zone{}}
+
+	// Walk the zone cuts from the root down to the name
+	var parentKeys []*dns.DNSKEY
+	labels := dns.SplitDomainName(name)
+	for i := len(labels); i >= 0; i-- {
+		zoneName := "."
+		if i < len(labels) {
+			zoneName = dns.Fqdn(strings.Join(labels[i:], "."))
+		}
+
+		// The DS records (kept in the parent zone, the root uses the trust anchors)
+		var ds []*dns.DS
+		var dsSignatures []*dns.RRSIG
+		var dsRecords []dns.RR
+		if zoneName == "." {
+			for _, anchor := range rootTrustAnchors {
+				rr, _ := dns.NewRR(anchor)
+				ds = append(ds, rr.(*dns.DS))
+			}
+		} else {
+			response, err := c.secureQuery(ctx, zoneName, dns.TypeDS)
+			if err != nil {
+				return chain, err
+			}
+			dsRecords, dsSignatures = answerRecords(response, zoneName, dns.TypeDS)
+			for _, rr := range dsRecords {
+				ds = append(ds, rr.(*dns.DS))
+			}
+			if len(ds) == 0 {
+				var isZone bool
+				if isZone, err = c.isZoneCut(ctx, zoneName); err != nil {
+					return chain, err
+				} else if !isZone {
+					continue
+				}
+				chain.Zones = append(chain.Zones, &Zone{Name: zoneLabel(zoneName), DNSKEYs: []*Key{}, DS: []*DelegationSigner{}, Signatures: []*Signature{}})
+				chain.broken(zoneName, fmt.Sprintf("no DS record for %s in the parent zone (unsigned delegation, the chain of trust ends here)", zoneLabel(zoneName)))
+				return chain, nil
+			}
+		}
+
+		// Check the zone
+		keys, err := c.checkZone(ctx, chain, zoneName, ds, dsRecords, dsSignatures, parentKeys, expiryWarning, now)
+		if err != nil || len(chain.BrokenAt) > 0 {
+			return chain, err
+		}
+		parentKeys = keys
+	}
+
+	// The last link: the records of the name are signed by its zone
+	if err := c.checkAnswer(ctx, chain, name, parentKeys, expiryWarning, now); err != nil || len(chain.BrokenAt) > 0 {
+		return chain, err
+	}
+
+	chain.Secure = true
+	return chain, nil
+}
+
+// checkZone will match the DS records to the DNSKEY records and check the signatures of both (returns the keys of the zone)
+func (c *Client) checkZone(ctx context.Context, chain *Chain, zoneName string, ds []*dns.DS, dsRecords []dns.RR,
+	dsSignatures []*dns.RRSIG, parentKeys []*dns.DNSKEY, expiryWarning time.Duration, now time.Time,
+) ([]*dns.DNSKEY, error) {
+	zone := &Zone{Name: zoneLabel(zoneName), DNSKEYs: []*Key{}, DS: []*DelegationSigner{}, Signatures: []*Signature{}}
+	chain.Zones = append(chain.Zones, zone)
+	for _, record := range ds {
+		zone.DS = append(zone.DS, &DelegationSigner{
+			Algorithm:  dns.AlgorithmToString[record.Algorithm],
+			Digest:     strings.ToUpper(record.Digest),
+			DigestType: dns.HashToString[record.DigestType],
+			KeyTag:     record.KeyTag,
+		})
+	}
+
+	// The DS records are signed by the parent zone (the root uses the trust anchors)
+	if zoneName != "." {
+		signed := false
+		for _, rrsig := range dsSignatures {
+			signature := checkSignature(rrsig, parentKeys, dsRecords, now)
+			zone.Signatures = append(zone.Signatures, signature)
+			signed = signed || signature.Valid
+			chain.checkExpiry(zoneName, signature, expiryWarning, now)
+		}
+		if !signed {
+			chain.broken(zoneName, fmt.Sprintf("the DS records of %s are not signed by a key of the parent zone%s", zoneLabel(zoneName), signatureErrors(zone.Signatures)))
+			return nil, nil
+		}
+	}
+
+	// The DNSKEY records
+	response, err := c.secureQuery(ctx, zoneName, dns.TypeDNSKEY)
+	if err != nil {
+		return nil, err
+	}
+	keyRecords, keySignatures := answerRecords(response, zoneName, dns.TypeDNSKEY)
+	if len(keyRecords) == 0 {
+		chain.broken(zoneName, fmt.Sprintf("no DNSKEY record for %s (the DS records in the parent zone point to nothing)", zoneLabel(zoneName)))
+		return nil, nil
+	}
+
+	// Match the DS records (digest of a key)
+	keys := make([]*dns.DNSKEY, 0, len(keyRecords))
+	var trusted []uint16
+	for _, rr := range keyRecords {
+		key := rr.(*dns.DNSKEY)
+		keys = append(keys, key)
+		detail := &Key{
+			Algorithm: dns.AlgorithmToString[key.Algorithm],
+			Flags:     key.Flags,
+			KeyTag:    key.KeyTag(),
+			Role:      RoleZSK,
+		}
+		if key.Flags&dns.SEP == dns.SEP {
+			detail.Role = RoleKSK
+		}
+		for index, record := range ds {
+			if record.KeyTag != detail.KeyTag || record.Algorithm != key.Algorithm {
+				continue
+			}
+			if digest := key.ToDS(record.DigestType); digest != nil && strings.EqualFold(digest.Digest, record.Digest) {
+				zone.DS[index].Matched = true
+				detail.Trusted = true
+			}
+		}
+		if detail.Trusted {
+			trusted = append(trusted, detail.KeyTag)
+		}
+		zone.DNSKEYs = append(zone.DNSKEYs, detail)
+	}
+	if len(trusted) == 0 {
+		chain.broken(zoneName, fmt.Sprintf("no DNSKEY of %s matches the DS records (key tags: %s)", zoneLabel(zoneName), dsKeyTags(ds)))
+		return nil, nil
+	}
+
+	// The DNSKEY records are signed by a trusted key (a signature by another key of the zone is not enough)
+	signed := false
+	for _, rrsig := range keySignatures {
+		signature := checkSignature(rrsig, keys, keyRecords, now)
+		zone.Signatures = append(zone.Signatures, signature)
+		signed = signed || (signature.Valid && isTrusted(trusted, rrsig.KeyTag))
+		chain.checkExpiry(zoneName, signature, expiryWarning, now)
+	}
+	if !signed {
+		chain.broken(zoneName, fmt.Sprintf("the DNSKEY records of %s are not signed by a key matching the DS records%s", zoneLabel(zoneName), signatureErrors(zone.Signatures)))
+		return nil, nil
+	}
+
+	zone.Secure = true
+	return keys, nil
+}
+
+// checkAnswer will check the signature of the A records of the name (or the SOA record if there are no A records)
+func (c *Client) checkAnswer(ctx context.Context, chain *Chain, name string, keys []*dns.DNSKEY,
+	expiryWarning time.Duration, now time.Time,
+) error {
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeSOA} {
+		response, err := c.secureQuery(ctx, name, qtype)
+		if err != nil {
+			return err
+		}
+		records, signatures := answerRecords(response, name, qtype)
+		if len(records) == 0 {
+			continue
+		}
+
+		zone := chain.Zones[len(chain.Zones)-1]
+		checked := make([]*Signature, 0, len(signatures))
+		signed := false
+		for _, rrsig := range signatures {
+			signature := checkSignature(rrsig, keys, records, now)
+			checked = append(checked, signature)
+			signed = signed || signature.Valid
+			chain.checkExpiry(name, signature, expiryWarning, now)
+		}
+		zone.Signatures = append(zone.Signatures, checked...)
+		if !signed {
+			chain.broken(name, fmt.Sprintf("the %s records of %s are not signed by %s%s", dns.TypeToString[qtype], zoneLabel(name), zone.Name, signatureErrors(checked)))
+		}
+		return nil
+	}
+	chain.Issues = append(chain.Issues, &Issue{Level: LevelInfo, Message: "no A or SOA records to check the last link", Name: zoneLabel(name)})
+	return nil
+}
+
+// isZoneCut returns true if the name is the apex of a zone (has its own SOA record)
+func (c *Client) isZoneCut(ctx context.Context, name string) (bool, error) {
+	response, err := c.secureQuery(ctx, name, dns.TypeSOA)
+	if err != nil {
+		return false, err
+	}
+	records, _ := answerRecords(response, name, dns.TypeSOA)
+	return len(records) > 0, nil
+}
+
+// secureQuery will ask for the records and their RRSIG records (a failed query is an error, NXDOMAIN is an empty answer)
+func (c *Client) secureQuery(ctx context.Context, name string, qtype uint16) (*dns.Msg, error) {
+	response, err := c.exchange(ctx, name, qtype, true)
+	if err != nil {
+		return nil, err
+	}
+	if response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("%s %s: query failed: %s", dns.TypeToString[qtype], zoneLabel(dns.Fqdn(name)), dns.RcodeToString[response.Rcode])
+	}
+	return response, nil
+}
+
+// broken will mark the chain as broken at the name
+func (ch *Chain) broken(name, message string) {
+	ch.BrokenAt = zoneLabel(name)
+	ch.Issues = append(ch.Issues, &Issue{Level: LevelError, Message: message, Name: ch.BrokenAt})
+}
+
+// checkExpiry will flag a valid signature that expires soon
+func (ch *Chain) checkExpiry(name string, signature *Signature, expiryWarning time.Duration, now time.Time) {
+	if !signature.Valid {
+		return
+	}
+	if left := signature.Expiration.Sub(now); left < expiryWarning {
+		ch.Issues = append(ch.Issues, &Issue{
+			Level: LevelWarn,
+			Message: fmt.Sprintf("RRSIG over %s (key tag %d) expires in %s on %s",
+				signature.Covered, signature.KeyTag, strings.TrimSuffix(left.Round(time.Minute).String(), "0s"), signature.Expiration.Format(time.RFC3339)),
+			Name: zoneLabel(name),
+		})
+	}
+}
+
+// zoneLabel returns the name without the trailing period (the root stays ".")
+func zoneLabel(name string) string {
+	if name == "." {
+		return name
+	}
+	return strings.TrimSuffix(name, ".")
+}
+
+// checkSignature will verify the RRSIG with the key that has the same key tag and algorithm
+func checkSignature(rrsig *dns.RRSIG, keys []*dns.DNSKEY, records []dns.RR, now time.Time) *Signature {
+	signature := &Signature{
+		Algorithm:  dns.AlgorithmToString[rrsig.Algorithm],
+		Covered:    dns.TypeToString[rrsig.TypeCovered],
+		Expiration: time.Unix(int64(rrsig.Expiration), 0).UTC(),
+		Inception:  time.Unix(int64(rrsig.Inception), 0).UTC(),
+		KeyTag:     rrsig.KeyTag,
+		Signer:     zoneLabel(rrsig.SignerName),
+	}
+
+	var key *dns.DNSKEY
+	for _, candidate := range keys {
+		if candidate.KeyTag() == rrsig.KeyTag && candidate.Algorithm == rrsig.Algorithm {
+			key = candidate
+			break
+		}
+	}
+	switch {
+	case key == nil:
+		signature.Error = fmt.Sprintf("no key with tag %d", rrsig.KeyTag)
+	case now.Before(signature.Inception):
+		signature.Error = "not valid yet (inception is in the future)"
+	case now.After(signature.Expiration):
+		signature.Error = "expired"
+	default:
+		if err := rrsig.Verify(key, records); err != nil {
+			signature.Error = err.Error()
+		} else {
+			signature.Valid = true
+		}
+	}
+	return signature
+}
+
+// isTrusted returns true if the key tag is in the list
+func isTrusted(trusted []uint16, keyTag uint16) bool {
+	for _, tag := range trusted {
+		if tag == keyTag {
+			return true
+		}
+	}
+	return false
+}
+
+// answerRecords returns the records of the type for the name and the RRSIG records that cover them
+func answerRecords(response *dns.Msg, name string, qtype uint16) (records []dns.RR, signatures []*dns.RRSIG) {
+	for _, rr := range response.Answer {
+		if !strings.EqualFold(rr.Header().Name, name) {
+			continue
+		}
+		if rr.Header().Rrtype == qtype {
+			records = append(records, rr)
+		} else if rrsig, ok := rr.(*dns.RRSIG); ok && rrsig.TypeCovered == qtype {
+			signatures = append(signatures, rrsig)
+		}
+	}
+	return records, signatures
+}
+
+// signatureErrors returns the errors of the signatures (for the broken link message)
+func signatureErrors(signatures []*Signature) string {
+	if len(signatures) == 0 {
+		return " (no RRSIG records found)"
+	}
+	var errs []string
+	for _, signature := range signatures {
+		if len(signature.Error) > 0 {
+			errs = append(errs, fmt.Sprintf("key tag %d: %s", signature.KeyTag, signature.Error))
+		}
+	}
+	if len(errs) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(errs, ", ") + ")"
+}
+
+// dsKeyTags returns the key tags of the DS records (sorted)
+func dsKeyTags(ds []*dns.DS) string {
+	tags := make([]int, 0, len(ds))
+	for _, record := range ds {
+		tags = append(tags, int(record.KeyTag))
+	}
+	sort.Ints(tags)
+	return strings.Trim(fmt.Sprint(tags), "[]")
+}
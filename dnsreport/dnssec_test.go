@@ -0,0 +1,234 @@
+package dnsreport
+
+import (
+	"crypto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// signedRecords will sign the records with a new zone signing key (valid from inception to expiration)
+func signedRecords(t *testing.T, records []dns.RR, inception, expiration time.Time) (*dns.DNSKEY, *dns.RRSIG) {
+	key := &dns.DNSKEY{
+		Algorithm: dns.ECDSAP256SHA256,
+		Flags:     dns.ZONE,
+		Hdr:       dns.RR_Header{Class: dns.ClassINET, Name: "example.com.", Rrtype: dns.TypeDNSKEY, Ttl: 3600},
+		Protocol:  3,
+	}
+	private, err := key.Generate(256)
+	if err != nil {
+		t.Fatalf("%s Failed: error generating a key: %s", t.Name(), err.Error())
+	}
+	rrsig := &dns.RRSIG{
+		Algorithm:  key.Algorithm,
+		Expiration: uint32(expiration.Unix()), //nolint:gosec // G115 - unix time fits
+		Inception:  uint32(inception.Unix()),  //nolint:gosec // G115 - unix time fits
+		KeyTag:     key.KeyTag(),
+		SignerName: "example.com.",
+	}
+	if err = rrsig.Sign(private.(crypto.Signer), records); err != nil {
+		t.Fatalf("%s Failed: error signing: %s", t.Name(), err.Error())
+	}
+	return key, rrsig
+}
+
+// newRR will parse a record for the test
+func newRR(t *testing.T, line string) dns.RR {
+	rr, err := dns.NewRR(line)
+	if err != nil {
+		t.Fatalf("%s Failed: invalid record [%s]: %s", t.Name(), line, err.Error())
+	}
+	return rr
+}
+
+// TestCheckSignature will test the method checkSignature()
+func TestCheckSignature(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	records := []dns.RR{newRR(t, "example.com. 3600 IN A 192.0.2.1")}
+	key, rrsig := signedRecords(t, records, now.Add(-time.Hour), now.Add(30*24*time.Hour))
+	otherKey, _ := signedRecords(t, records, now.Add(-time.Hour), now.Add(time.Hour))
+
+	var tests = []struct {
+		name          string
+		keys          []*dns.DNSKEY
+		records       []dns.RR
+		now           time.Time
+		expectedValid bool
+		expectedError string
+	}{
+		{"valid", []*dns.DNSKEY{otherKey, key}, records, now, true, ""},
+		{"no key with the tag", []*dns.DNSKEY{otherKey}, records, now, false, "no key with tag"},
+		{"not valid yet", []*dns.DNSKEY{key}, records, now.Add(-2 * time.Hour), false, "not valid yet (inception is in the future)"},
+		{"expired", []*dns.DNSKEY{key}, records, now.Add(31 * 24 * time.Hour), false, "expired"},
+		{"records changed", []*dns.DNSKEY{key}, []dns.RR{newRR(t, "example.com. 3600 IN A 192.0.2.2")}, now, false, "bad signature"},
+	}
+
+	for _, test := range tests {
+		output := checkSignature(rrsig, test.keys, test.records, test.now)
+		if output.Valid != test.expectedValid || !strings.Contains(output.Error, test.expectedError) || (len(test.expectedError) == 0 && len(output.Error) > 0) {
+			t.Errorf("%s Failed: [%s] inputted and [%v] [%s] expected, received: [%v] [%s]", t.Name(), test.name, test.expectedValid, test.expectedError, output.Valid, output.Error)
+		}
+		if output.Covered != "A" || output.Signer != "example.com" || output.KeyTag != key.KeyTag() || output.Algorithm != "ECDSAP256SHA256" {
+			t.Errorf("%s Failed: [%s] inputted and the signature details expected, received: [%+v]", t.Name(), test.name, output)
+		}
+		if !output.Expiration.Equal(now.Add(30*24*time.Hour)) || !output.Inception.Equal(now.Add(-time.Hour)) {
+			t.Errorf("%s Failed: [%s] inputted and the validity period expected, received: [%s] to [%s]", t.Name(), test.name, output.Inception, output.Expiration)
+		}
+	}
+}
+
+// TestChain_checkExpiry will test the method checkExpiry()
+func TestChain_checkExpiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var tests = []struct {
+		name      string
+		signature *Signature
+		expected  string
+	}{
+		{"expires later", &Signature{Covered: "A", Expiration: now.Add(30 * 24 * time.Hour), KeyTag: 1, Valid: true}, ""},
+		{"invalid signature", &Signature{Covered: "A", Expiration: now.Add(time.Hour), KeyTag: 1}, ""},
+		{"expires soon", &Signature{Covered: "DNSKEY", Expiration: now.Add(49*time.Hour + 30*time.Minute), KeyTag: 12345, Valid: true},
+			"warn example.com: RRSIG over DNSKEY (key tag 12345) expires in 49h30m on 2024-01-04T04:34:05Z"},
+	}
+
+	for _, test := range tests {
+		chain := &Chain{Issues: []*Issue{}}
+		chain.checkExpiry("example.com.", test.signature, 7*24*time.Hour, now)
+		output := strings.Join(issueStrings(chain.Issues), "\n")
+		if output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
+
+// TestChain_broken will test the method broken()
+func TestChain_broken(t *testing.T) {
+	t.Parallel()
+
+	chain := &Chain{Issues: []*Issue{}}
+	chain.broken("example.com.", "no DS record")
+	if chain.BrokenAt != "example.com" || len(chain.Issues) != 1 || chain.Issues[0].Level != LevelError || chain.Issues[0].Name != "example.com" {
+		t.Errorf("%s Failed: broken at [example.com] with one error expected, received: [%s] %q", t.Name(), chain.BrokenAt, issueStrings(chain.Issues))
+	}
+}
+
+// TestSignatureErrors will test the method signatureErrors()
+func TestSignatureErrors(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name       string
+		signatures []*Signature
+		expected   string
+	}{
+		{"none", nil, " (no RRSIG records found)"},
+		{"all valid", []*Signature{{KeyTag: 1, Valid: true}}, ""},
+		{"errors", []*Signature{{KeyTag: 1, Error: "expired"}, {KeyTag: 2, Valid: true}, {KeyTag: 3, Error: "no key with tag 3"}},
+			" (key tag 1: expired, key tag 3: no key with tag 3)"},
+	}
+
+	for _, test := range tests {
+		if output := signatureErrors(test.signatures); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
+
+// TestDsKeyTags will test the method dsKeyTags()
+func TestDsKeyTags(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		tags     []uint16
+		expected string
+	}{
+		{nil, ""},
+		{[]uint16{20326}, "20326"},
+		{[]uint16{38696, 20326, 1}, "1 20326 38696"},
+	}
+
+	for _, test := range tests {
+		ds := make([]*dns.DS, 0, len(test.tags))
+		for _, tag := range test.tags {
+			ds = append(ds, &dns.DS{KeyTag: tag})
+		}
+		if output := dsKeyTags(ds); output != test.expected {
+			t.Errorf("%s Failed: [%v] inputted and [%s] expected, received: [%s]", t.Name(), test.tags, test.expected, output)
+		}
+	}
+}
+
+// TestZoneLabel will test the method zoneLabel()
+func TestZoneLabel(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		expected string
+	}{
+		{".", "."},
+		{"com.", "com"},
+		{"example.com.", "example.com"},
+		{"example.com", "example.com"},
+	}
+
+	for _, test := range tests {
+		if output := zoneLabel(test.name); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
+
+// TestAnswerRecords will test the method answerRecords()
+func TestAnswerRecords(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	records := []dns.RR{newRR(t, "example.com. 3600 IN A 192.0.2.1"), newRR(t, "example.com. 3600 IN A 192.0.2.2")}
+	_, rrsig := signedRecords(t, records, now.Add(-time.Hour), now.Add(time.Hour))
+	_, otherRRSIG := signedRecords(t, []dns.RR{newRR(t, "example.com. 3600 IN TXT \"text\"")}, now.Add(-time.Hour), now.Add(time.Hour))
+
+	response := new(dns.Msg)
+	response.Answer = append(response.Answer, records...)
+	response.Answer = append(response.Answer, rrsig, otherRRSIG, newRR(t, "other.com. 3600 IN A 192.0.2.3"), newRR(t, "EXAMPLE.com. 3600 IN AAAA 2001:db8::1"))
+
+	output, signatures := answerRecords(response, "example.com.", dns.TypeA)
+	if len(output) != 2 || output[0] != records[0] || output[1] != records[1] {
+		t.Errorf("%s Failed: the two A records expected, received: %v", t.Name(), output)
+	}
+	if len(signatures) != 1 || signatures[0] != rrsig {
+		t.Errorf("%s Failed: the RRSIG over A expected, received: %v", t.Name(), signatures)
+	}
+
+	if output, signatures = answerRecords(response, "example.com.", dns.TypeAAAA); len(output) != 1 || len(signatures) != 0 {
+		t.Errorf("%s Failed: one AAAA record (case insensitive) without signatures expected, received: %v %v", t.Name(), output, signatures)
+	}
+}
+
+// TestIsTrusted will test the method isTrusted()
+func TestIsTrusted(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		trusted  []uint16
+		keyTag   uint16
+		expected bool
+	}{
+		{nil, 1, false},
+		{[]uint16{20326, 38696}, 38696, true},
+		{[]uint16{20326, 38696}, 1, false},
+	}
+
+	for _, test := range tests {
+		if output := isTrusted(test.trusted, test.keyTag); output != test.expected {
+			t.Errorf("%s Failed: [%v] [%d] inputted and [%v] expected, received: [%v]", t.Name(), test.trusted, test.keyTag, test.expected, output)
+		}
+	}
+}
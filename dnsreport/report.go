@@ -0,0 +1,293 @@
+package dnsreport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// Levels of an issue
+const (
+	LevelError = "error"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+)
+
+// Roles of a section (what the name is used for)
+const (
+	RoleDomain = "domain"
+	RoleSRV    = "srv"
+	RoleTarget = "target"
+)
+
+// LowTTL is the TTL (in seconds) below which a record is flagged (IE: left over from a migration)
+const LowTTL = 300
+
+// Issue is a misconfiguration (or a note) found in the records
+type Issue struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	Name    string `json:"name"`
+}
+
+// Section is all records found for one name
+type Section struct {
+	Authoritative bool      `json:"authoritative"` // All answers came from an authoritative name server (the TTLs are the configured values)
+	Name          string    `json:"name"`
+	Records       []*Record `json:"records"`
+	Role          string    `json:"role"`
+}
+
+// Report is all records for a paymail domain and the issues found
+type Report struct {
+	Domain     string     `json:"domain"`
+	Issues     []*Issue   `json:"issues"`
+	NameServer string     `json:"name_server"`
+	Sections   []*Section `json:"sections"`
+	Targets    []string   `json:"targets"`
+}
+
+// Types of records queried for each role
+var (
+	domainTypes = []uint16{dns.TypeNS, dns.TypeCNAME, dns.TypeA, dns.TypeAAAA, dns.TypeTXT, dns.TypeCAA}
+	targetTypes = []uint16{dns.TypeCNAME, dns.TypeA, dns.TypeAAAA}
+)
+
+// Inspect will query all records for the domain, the SRV record (service and protocol) and each SRV target
+func (c *Client) Inspect(ctx context.Context, domain, service, proto string) (*Report, error) {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	report := &Report{Domain: domain, Issues: []*Issue{}, NameServer: c.address()}
+
+	// The domain
+	domainSection, err := c.section(ctx, report, domain, RoleDomain, domainTypes)
+	if err != nil {
+		return report, err
+	}
+
+	// The SRV record
+	srvName := fmt.Sprintf("_%s._%s.%s", service, proto, domain)
+	section, err := c.section(ctx, report, srvName, RoleSRV, []uint16{dns.TypeSRV})
+	if err != nil {
+		return report, err
+	}
+	for _, record := range section.Records {
+		if record.Type != dns.TypeToString[dns.TypeSRV] || !strings.EqualFold(record.Name, srvName) {
+			continue
+		}
+		fields := strings.Fields(record.Value) // priority weight port target
+		if len(fields) != 4 {
+			continue
+		}
+		target := strings.TrimSuffix(strings.ToLower(fields[3]), ".")
+		if len(target) == 0 {
+			report.addIssue(LevelError, srvName, `SRV target is "." (the service is decidedly not available, RFC 2782)`)
+			continue
+		}
+		report.addTarget(target)
+	}
+	if hasType(section, dns.TypeCNAME) {
+		report.addIssue(LevelError, srvName, "the SRV name is a CNAME")
+	}
+	fromSRV := len(report.Targets) > 0
+	if !fromSRV {
+		report.addIssue(LevelInfo, srvName, fmt.Sprintf("no SRV record, clients will use %s:443", domain))
+		report.Targets = []string{domain}
+	}
+
+	// Each target (the domain was already queried)
+	for _, target := range report.Targets {
+		section = domainSection
+		if target != domain {
+			if section, err = c.section(ctx, report, target, RoleTarget, targetTypes); err != nil {
+				return report, err
+			}
+		}
+		if err = c.checkTarget(ctx, report, section, fromSRV); err != nil {
+			return report, err
+		}
+	}
+
+	// Low TTLs (once per name and type), a recursive resolver returns the time left in its cache (not the configured TTL)
+	flagged := make(map[string]bool)
+	cached := false
+	for _, section := range report.Sections {
+		for _, record := range section.Records {
+			key := record.Name + "|" + record.Type
+			if record.TTL >= LowTTL || flagged[key] {
+				continue
+			} else if !section.Authoritative {
+				cached = true
+				continue
+			}
+			flagged[key] = true
+			report.addIssue(LevelWarn, record.Name, fmt.Sprintf("low TTL on %s record: %ds (left over from a migration?)", record.Type, record.TTL))
+		}
+	}
+	if cached {
+		report.addIssue(LevelInfo, domain, fmt.Sprintf("TTLs from %s are not authoritative (they count down in its cache), "+
+			"use an authoritative name server to check for low TTLs", report.NameServer))
+	}
+
+	return report, nil
+}
+
+// checkTarget will flag a target that is a CNAME (fromSRV), has no A/AAAA record or is blocked by CAA
+func (c *Client) checkTarget(ctx context.Context, report *Report, section *Section, fromSRV bool) error {
+	target := section.Name
+	hasA, hasAAAA := hasType(section, dns.TypeA), hasType(section, dns.TypeAAAA)
+
+	// CNAME (not allowed as an SRV target, or dangling)
+	var cname string
+	for _, record := range section.Records {
+		if record.Type == dns.TypeToString[dns.TypeCNAME] && strings.EqualFold(record.Name, target) {
+			cname = strings.TrimSuffix(record.Value, ".")
+		}
+	}
+	if len(cname) > 0 && fromSRV {
+		report.addIssue(LevelError, target, fmt.Sprintf("SRV target is a CNAME to %s (RFC 2782 requires a name with an A/AAAA record)", cname))
+	}
+
+	// Addresses
+	switch {
+	case !hasA && !hasAAAA && len(cname) > 0:
+		report.addIssue(LevelError, target, fmt.Sprintf("dangling CNAME: %s has no A/AAAA record", cname))
+	case !hasA && !hasAAAA:
+		report.addIssue(LevelError, target, "no A/AAAA record (clients can't connect)")
+	case !hasAAAA:
+		report.addIssue(LevelWarn, target, "no AAAA record (IPv6 only clients can't connect)")
+	case !hasA:
+		report.addIssue(LevelWarn, target, "no A record (IPv4 only clients can't connect)")
+	}
+
+	// CAA (the closest name with records applies)
+	records, owner, err := c.caa(ctx, target)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	if !strings.EqualFold(owner, target) {
+		for _, record := range records {
+			section.Records = append(section.Records, NewRecord(record))
+		}
+	}
+	issuers, restricted := caaIssuers(records)
+	switch {
+	case !restricted:
+		return nil
+	case len(issuers) == 0:
+		report.addIssue(LevelError, target, fmt.Sprintf("CAA on %s blocks all certificate issuance", owner))
+	default:
+		report.addIssue(LevelInfo, target, fmt.Sprintf("CAA on %s only allows: %s (the certificate must be issued by one of them)", owner, strings.Join(issuers, ", ")))
+	}
+	return nil
+}
+
+// caa will return the CAA records that apply to the name (the name, or the closest parent with records)
+func (c *Client) caa(ctx context.Context, name string) (records []*dns.CAA, owner string, err error) {
+	labels := dns.SplitDomainName(name)
+	for i := 0; i < len(labels)-1; i++ {
+		owner = strings.Join(labels[i:], ".")
+		var response *dns.Msg
+		if response, err = c.Query(ctx, owner, dns.TypeCAA); err != nil {
+			return nil, owner, err
+		}
+		for _, rr := range response.Answer {
+			if record, ok := rr.(*dns.CAA); ok {
+				records = append(records, record)
+			}
+		}
+		if len(records) > 0 {
+			return records, owner, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// caaIssuers returns the issuers allowed by the issue properties (RFC 8659), in order and once each
+//
+// The issuer is the domain before any parameters (IE: "letsencrypt.org; validationmethods=dns-01"), an empty
+// domain (IE: ";") allows no issuer. Only issue properties restrict issuance (not iodef or issuewild alone)
+func caaIssuers(records []*dns.CAA) (issuers []string, restricted bool) {
+	for _, record := range records {
+		if !strings.EqualFold(record.Tag, "issue") {
+			continue
+		}
+		restricted = true
+		issuer, _, _ := strings.Cut(record.Value, ";")
+		if issuer = strings.ToLower(strings.TrimSpace(issuer)); len(issuer) > 0 && !slices.Contains(issuers, issuer) {
+			issuers = append(issuers, issuer)
+		}
+	}
+	return issuers, restricted
+}
+
+// section will query all types for the name (records are only added once)
+func (c *Client) section(ctx context.Context, report *Report, name, role string, types []uint16) (*Section, error) {
+	section := &Section{Name: name, Records: []*Record{}, Role: role}
+	report.Sections = append(report.Sections, section)
+
+	seen := make(map[string]bool)
+	authoritative := true
+	for _, qtype := range types {
+		response, err := c.Query(ctx, name, qtype)
+		if err != nil {
+			if ctx.Err() != nil {
+				return section, ctx.Err()
+			}
+			report.addIssue(LevelError, name, err.Error())
+			continue
+		}
+		switch response.Rcode {
+		case dns.RcodeSuccess:
+		case dns.RcodeNameError:
+			if qtype == types[0] && role != RoleSRV {
+				report.addIssue(LevelError, name, "the name does not exist (NXDOMAIN)")
+			}
+			continue
+		default:
+			report.addIssue(LevelError, name, fmt.Sprintf("%s query failed: %s", dns.TypeToString[qtype], dns.RcodeToString[response.Rcode]))
+			continue
+		}
+		if len(response.Answer) > 0 && !response.Authoritative {
+			authoritative = false
+		}
+		for _, rr := range response.Answer {
+			if key := rr.String(); !seen[key] {
+				seen[key] = true
+				section.Records = append(section.Records, NewRecord(rr))
+			}
+		}
+	}
+	section.Authoritative = authoritative && len(section.Records) > 0
+	return section, nil
+}
+
+// addIssue will add an issue to the report
+func (r *Report) addIssue(level, name, message string) {
+	r.Issues = append(r.Issues, &Issue{Level: level, Message: message, Name: name})
+}
+
+// addTarget will add an SRV target (once)
+func (r *Report) addTarget(target string) {
+	for _, existing := range r.Targets {
+		if existing == target {
+			return
+		}
+	}
+	r.Targets = append(r.Targets, target)
+}
+
+// hasType returns true if the section has a record of the type
+func hasType(section *Section, qtype uint16) bool {
+	for _, record := range section.Records {
+		if record.Type == dns.TypeToString[qtype] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,320 @@
+package dnsreport
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// startServer will start a local name server that answers from the records (NXDOMAIN for unknown names)
+//
+// A server that is not authoritative answers like a recursive resolver (the AA flag is not set)
+func startServer(t *testing.T, zone []string, authoritative bool) string {
+	records := make([]dns.RR, 0, len(zone))
+	for _, line := range zone {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			t.Fatalf("%s Failed: invalid record [%s]: %s", t.Name(), line, err.Error())
+		}
+		records = append(records, rr)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("%s Failed: error listening: %s", t.Name(), err.Error())
+	}
+	started := make(chan struct{})
+	server := &dns.Server{
+		NotifyStartedFunc: func() { close(started) },
+		PacketConn:        conn,
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, request *dns.Msg) {
+			response := new(dns.Msg)
+			response.SetReply(request)
+			response.Authoritative = authoritative
+			question := request.Question[0]
+			exists := false
+			for _, rr := range records {
+				if !strings.EqualFold(rr.Header().Name, question.Name) {
+					continue
+				}
+				exists = true
+				if rr.Header().Rrtype == question.Qtype || rr.Header().Rrtype == dns.TypeCNAME {
+					response.Answer = append(response.Answer, rr)
+				}
+			}
+			if !exists {
+				response.Rcode = dns.RcodeNameError
+			}
+			_ = w.WriteMsg(response)
+		}),
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+	t.Cleanup(func() {
+		_ = server.Shutdown()
+	})
+	return conn.LocalAddr().String()
+}
+
+// issueStrings returns the issues as "level name: message"
+func issueStrings(issues []*Issue) []string {
+	list := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		list = append(list, issue.Level+" "+issue.Name+": "+issue.Message)
+	}
+	return list
+}
+
+// TestClient_Inspect will test the method Inspect() (the misconfiguration checks)
+func TestClient_Inspect(t *testing.T) {
+	t.Parallel()
+
+	healthy := []string{
+		"example.com. 3600 IN NS ns1.example.com.",
+		"example.com. 3600 IN A 192.0.2.1",
+		"example.com. 3600 IN AAAA 2001:db8::1",
+		"_bsvalias._tcp.example.com. 3600 IN SRV 10 10 443 paymail.example.com.",
+		"paymail.example.com. 3600 IN A 192.0.2.2",
+		"paymail.example.com. 3600 IN AAAA 2001:db8::2",
+	}
+
+	var tests = []struct {
+		name            string
+		zone            []string
+		expectedIssues  []string
+		expectedTargets []string
+	}{
+		{"healthy", healthy, []string{}, []string{"paymail.example.com"}},
+		{"no srv record", []string{
+			"example.com. 3600 IN A 192.0.2.1",
+		}, []string{
+			"info _bsvalias._tcp.example.com: no SRV record, clients will use example.com:443",
+			"warn example.com: no AAAA record (IPv6 only clients can't connect)",
+		}, []string{"example.com"}},
+		{"srv target is a cname", []string{
+			"example.com. 3600 IN A 192.0.2.1",
+			"example.com. 3600 IN AAAA 2001:db8::1",
+			"_bsvalias._tcp.example.com. 3600 IN SRV 10 10 443 paymail.example.com.",
+			"paymail.example.com. 3600 IN CNAME host.provider.com.",
+		}, []string{
+			"error paymail.example.com: SRV target is a CNAME to host.provider.com (RFC 2782 requires a name with an A/AAAA record)",
+			"error paymail.example.com: dangling CNAME: host.provider.com has no A/AAAA record",
+		}, []string{"paymail.example.com"}},
+		{"srv target is not available", []string{
+			"example.com. 3600 IN A 192.0.2.1",
+			"example.com. 3600 IN AAAA 2001:db8::1",
+			"_bsvalias._tcp.example.com. 3600 IN SRV 0 0 0 .",
+		}, []string{
+			`error _bsvalias._tcp.example.com: SRV target is "." (the service is decidedly not available, RFC 2782)`,
+			"info _bsvalias._tcp.example.com: no SRV record, clients will use example.com:443",
+		}, []string{"example.com"}},
+		{"srv target without addresses and duplicate targets", []string{
+			"example.com. 3600 IN A 192.0.2.1",
+			"example.com. 3600 IN AAAA 2001:db8::1",
+			"_bsvalias._tcp.example.com. 3600 IN SRV 10 10 443 paymail.example.com.",
+			"_bsvalias._tcp.example.com. 3600 IN SRV 20 10 443 PAYMAIL.example.com.",
+			"paymail.example.com. 3600 IN AAAA 2001:db8::2",
+			"paymail.example.com. 3600 IN TXT \"no address\"",
+		}, []string{
+			"warn paymail.example.com: no A record (IPv4 only clients can't connect)",
+		}, []string{"paymail.example.com"}},
+		{"missing target", []string{
+			"example.com. 3600 IN A 192.0.2.1",
+			"example.com. 3600 IN AAAA 2001:db8::1",
+			"_bsvalias._tcp.example.com. 3600 IN SRV 10 10 443 missing.example.com.",
+		}, []string{
+			"error missing.example.com: the name does not exist (NXDOMAIN)",
+			"error missing.example.com: no A/AAAA record (clients can't connect)",
+		}, []string{"missing.example.com"}},
+		{"domain does not exist", []string{}, []string{
+			"error example.com: the name does not exist (NXDOMAIN)",
+			"info _bsvalias._tcp.example.com: no SRV record, clients will use example.com:443",
+			"error example.com: no A/AAAA record (clients can't connect)",
+		}, []string{"example.com"}},
+		{"low ttl", []string{
+			"example.com. 3600 IN A 192.0.2.1",
+			"example.com. 60 IN AAAA 2001:db8::1",
+			"_bsvalias._tcp.example.com. 120 IN SRV 10 10 443 example.com.",
+		}, []string{
+			"warn example.com: low TTL on AAAA record: 60s (left over from a migration?)",
+			"warn _bsvalias._tcp.example.com: low TTL on SRV record: 120s (left over from a migration?)",
+		}, []string{"example.com"}},
+		{"caa on the parent blocks issuance", append(append([]string{}, healthy...),
+			`example.com. 3600 IN CAA 0 issue ";"`,
+		), []string{
+			"error paymail.example.com: CAA on example.com blocks all certificate issuance",
+		}, []string{"paymail.example.com"}},
+		{"caa allows issuers", append(append([]string{}, healthy...),
+			`paymail.example.com. 3600 IN CAA 0 issue "letsencrypt.org"`,
+			`paymail.example.com. 3600 IN CAA 0 issuewild "digicert.com"`,
+			`paymail.example.com. 3600 IN CAA 0 issue "sectigo.com"`,
+		), []string{
+			"info paymail.example.com: CAA on paymail.example.com only allows: letsencrypt.org, sectigo.com (the certificate must be issued by one of them)",
+		}, []string{"paymail.example.com"}},
+		{"caa issue with parameters", append(append([]string{}, healthy...),
+			`paymail.example.com. 3600 IN CAA 0 issue "letsencrypt.org; validationmethods=dns-01"`,
+			`paymail.example.com. 3600 IN CAA 0 issue ";"`,
+		), []string{
+			"info paymail.example.com: CAA on paymail.example.com only allows: letsencrypt.org (the certificate must be issued by one of them)",
+		}, []string{"paymail.example.com"}},
+		{"caa without issue properties does not restrict", append(append([]string{}, healthy...),
+			`example.com. 3600 IN CAA 0 iodef "mailto:security@example.com"`,
+			`example.com. 3600 IN CAA 0 issuewild ";"`,
+		), []string{}, []string{"paymail.example.com"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := &Client{NameServer: startServer(t, test.zone, true), Timeout: 2 * time.Second}
+			report, err := client.Inspect(context.Background(), "Example.com.", "bsvalias", "tcp")
+			if err != nil {
+				t.Fatalf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.name, err.Error())
+			}
+			if report.Domain != "example.com" {
+				t.Errorf("%s Failed: [example.com] domain expected, received: [%s]", t.Name(), report.Domain)
+			}
+			if output := issueStrings(report.Issues); !slices.Equal(output, test.expectedIssues) {
+				t.Errorf("%s Failed: [%s] inputted and issues %q expected, received: %q", t.Name(), test.name, test.expectedIssues, output)
+			}
+			if !slices.Equal(report.Targets, test.expectedTargets) {
+				t.Errorf("%s Failed: [%s] inputted and targets %v expected, received: %v", t.Name(), test.name, test.expectedTargets, report.Targets)
+			}
+		})
+	}
+}
+
+// TestCaaIssuers will test the method caaIssuers()
+func TestCaaIssuers(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name               string
+		records            []string
+		expected           []string
+		expectedRestricted bool
+	}{
+		{"no records", []string{}, nil, false},
+		{"one issuer", []string{`0 issue "letsencrypt.org"`}, []string{"letsencrypt.org"}, true},
+		{"issuer with parameters", []string{`0 issue "letsencrypt.org; validationmethods=dns-01"`}, []string{"letsencrypt.org"}, true},
+		{"issuer with spaces and case", []string{`0 issue " LetsEncrypt.org ;accounturi=https://example.com/1"`}, []string{"letsencrypt.org"}, true},
+		{"blocks all", []string{`0 issue ";"`}, nil, true},
+		{"empty value blocks all", []string{`0 issue ""`}, nil, true},
+		{"tag is not case sensitive", []string{`0 ISSUE "digicert.com"`}, []string{"digicert.com"}, true},
+		{"duplicate issuers", []string{`0 issue "digicert.com"`, `0 issue "digicert.com; cansignhttpexchanges=yes"`}, []string{"digicert.com"}, true},
+		{"blocked and allowed", []string{`0 issue ";"`, `128 issue "sectigo.com"`}, []string{"sectigo.com"}, true},
+		{"iodef only", []string{`0 iodef "mailto:security@example.com"`}, nil, false},
+		{"issuewild only", []string{`0 issuewild ";"`}, nil, false},
+	}
+
+	for _, test := range tests {
+		records := make([]*dns.CAA, 0, len(test.records))
+		for _, value := range test.records {
+			records = append(records, newRR(t, "example.com. 3600 IN CAA "+value).(*dns.CAA))
+		}
+		issuers, restricted := caaIssuers(records)
+		if !slices.Equal(issuers, test.expected) || restricted != test.expectedRestricted {
+			t.Errorf("%s Failed: [%s] inputted and %q restricted %v expected, received: %q restricted %v",
+				t.Name(), test.name, test.expected, test.expectedRestricted, issuers, restricted)
+		}
+	}
+}
+
+// TestClient_InspectResolver will test the method Inspect() (low TTLs are only flagged from an authoritative name server)
+func TestClient_InspectResolver(t *testing.T) {
+	t.Parallel()
+
+	zone := []string{
+		"example.com. 3600 IN A 192.0.2.1",
+		"example.com. 60 IN AAAA 2001:db8::1",
+		"_bsvalias._tcp.example.com. 3600 IN SRV 10 10 443 example.com.",
+	}
+
+	var tests = []struct {
+		name          string
+		authoritative bool
+		expected      []string
+	}{
+		{"authoritative", true, []string{
+			"warn example.com: low TTL on AAAA record: 60s (left over from a migration?)",
+		}},
+		{"recursive resolver", false, []string{
+			"info example.com: TTLs from {server} are not authoritative (they count down in its cache), use an authoritative name server to check for low TTLs",
+		}},
+	}
+
+	for _, test := range tests {
+		client := &Client{NameServer: startServer(t, zone, test.authoritative), Timeout: 2 * time.Second}
+		report, err := client.Inspect(context.Background(), "example.com", "bsvalias", "tcp")
+		if err != nil {
+			t.Fatalf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.name, err.Error())
+		}
+		expected := make([]string, 0, len(test.expected))
+		for _, issue := range test.expected {
+			expected = append(expected, strings.ReplaceAll(issue, "{server}", client.NameServer))
+		}
+		if output := issueStrings(report.Issues); !slices.Equal(output, expected) {
+			t.Errorf("%s Failed: [%s] inputted and issues %q expected, received: %q", t.Name(), test.name, expected, output)
+		}
+		for _, section := range report.Sections {
+			if section.Authoritative != test.authoritative {
+				t.Errorf("%s Failed: [%s] inputted and authoritative %v expected for [%s], received: [%v]",
+					t.Name(), test.name, test.authoritative, section.Name, section.Authoritative)
+			}
+		}
+	}
+}
+
+// TestNewRecord will test the method NewRecord()
+func TestNewRecord(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		line     string
+		expected Record
+	}{
+		{"example.com. 300 IN A 192.0.2.1", Record{Name: "example.com", TTL: 300, Type: "A", Value: "192.0.2.1"}},
+		{"_bsvalias._tcp.example.com. 3600 IN SRV 10 20 443 paymail.example.com.", Record{Name: "_bsvalias._tcp.example.com", TTL: 3600, Type: "SRV", Value: "10 20 443 paymail.example.com."}},
+		{`example.com. 60 IN TXT "v=spf1 -all"`, Record{Name: "example.com", TTL: 60, Type: "TXT", Value: `"v=spf1 -all"`}},
+		{`example.com. 60 IN CAA 0 issue "letsencrypt.org"`, Record{Name: "example.com", TTL: 60, Type: "CAA", Value: `0 issue "letsencrypt.org"`}},
+	}
+
+	for _, test := range tests {
+		rr, err := dns.NewRR(test.line)
+		if err != nil {
+			t.Fatalf("%s Failed: invalid record [%s]: %s", t.Name(), test.line, err.Error())
+		}
+		if output := NewRecord(rr); *output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%+v] expected, received: [%+v]", t.Name(), test.line, test.expected, *output)
+		}
+	}
+}
+
+// TestClient_address will test the method address()
+func TestClient_address(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		nameServer string
+		expected   string
+	}{
+		{"8.8.8.8", "8.8.8.8:53"},
+		{"8.8.8.8:5353", "8.8.8.8:5353"},
+		{"2001:4860:4860::8888", "[2001:4860:4860::8888]:53"},
+		{"[2001:4860:4860::8888]:53", "[2001:4860:4860::8888]:53"},
+	}
+
+	for _, test := range tests {
+		if output := (&Client{NameServer: test.nameServer}).address(); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.nameServer, test.expected, output)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+/*
+Package har records the HTTP requests made with resty clients and saves them as a HAR 1.2 file
+(HTTP Archive, it can be opened in the browser devtools or attached to a support ticket)
+
+Read more at: http://www.softwareishard.com/blog/har-12-spec/
+*/
+package har
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Defaults for the archive
+const (
+	Version = "1.2"    // Version of the HAR format
+	pageID  = "page_1" // All requests of a command are one page
+)
+
+// File is the root of a HAR file
+type File struct {
+	Log *Log `json:"log"`
+}
+
+// Log is the archive (the application that made it, the page and all entries)
+type Log struct {
+	Creator *Creator `json:"creator"`
+	Entries []*Entry `json:"entries"`
+	Pages   []*Page  `json:"pages"`
+	Version string   `json:"version"`
+}
+
+// Creator is the application that made the archive
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Page is a group of entries (IE: the command that was run)
+type Page struct {
+	ID              string       `json:"id"`
+	PageTimings     *PageTimings `json:"pageTimings"`
+	StartedDateTime time.Time    `json:"startedDateTime"`
+	Title           string       `json:"title"`
+}
+
+// PageTimings are the timings of a page (not used, a command has no page load events)
+type PageTimings struct{}
+
+// Entry is one HTTP exchange (each retry attempt is an entry)
+type Entry struct {
+	Attempt         int       `json:"_attempt,omitempty"` // Attempt number (if retried)
+	Cache           struct{}  `json:"cache"`
+	Error           string    `json:"_error,omitempty"` // Network error (no response)
+	Name            string    `json:"_name,omitempty"`  // Name of the request (IE: capabilities), see WithName
+	PageRef         string    `json:"pageref"`
+	Request         *Request  `json:"request"`
+	Response        *Response `json:"response"`
+	ServerIPAddress string    `json:"serverIPAddress,omitempty"`
+	StartedDateTime time.Time `json:"startedDateTime"`
+	Time            float64   `json:"time"`
+	Timings         *Timings  `json:"timings"`
+}
+
+// NameValue is a header, query string parameter or cookie
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// PostData is the body of a request
+type PostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// Request is the request of an entry
+type Request struct {
+	BodySize    int          `json:"bodySize"`
+	Cookies     []*NameValue `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	HeadersSize int          `json:"headersSize"`
+	HTTPVersion string       `json:"httpVersion"`
+	Method      string       `json:"method"`
+	PostData    *PostData    `json:"postData,omitempty"`
+	QueryString []*NameValue `json:"queryString"`
+	URL         string       `json:"url"`
+}
+
+// Content is the body of a response
+type Content struct {
+	Encoding string `json:"encoding,omitempty"`
+	MimeType string `json:"mimeType"`
+	Size     int    `json:"size"`
+	Text     string `json:"text,omitempty"`
+}
+
+// Response is the response of an entry (status 0 on a network error)
+type Response struct {
+	BodySize    int          `json:"bodySize"`
+	Content     *Content     `json:"content"`
+	Cookies     []*NameValue `json:"cookies"`
+	Headers     []*NameValue `json:"headers"`
+	HeadersSize int          `json:"headersSize"`
+	HTTPVersion string       `json:"httpVersion"`
+	RedirectURL string       `json:"redirectURL"`
+	Status      int          `json:"status"`
+	StatusText  string       `json:"statusText"`
+}
+
+// Timings are the phases of an entry in milliseconds (-1 if not used, IE: a reused connection)
+type Timings struct {
+	Blocked float64 `json:"blocked"`
+	Connect float64 `json:"connect"`
+	DNS     float64 `json:"dns"`
+	Receive float64 `json:"receive"`
+	Send    float64 `json:"send"`
+	SSL     float64 `json:"ssl"`
+	Wait    float64 `json:"wait"`
+}
+
+// Recorder keeps all entries of the clients it was applied to (safe for concurrent requests)
+type Recorder struct {
+	creator  *Creator
+	entries  []*Entry
+	mu       sync.Mutex
+	recorded map[*resty.Request]int // Last attempt recorded per request (in progress)
+	started  time.Time
+	title    string
+}
+
+// NewRecorder will start a new recorder (title is the page title, IE: the command that was run)
+func NewRecorder(name, version, title string) *Recorder {
+	return &Recorder{
+		creator:  &Creator{Name: name, Version: version},
+		recorded: make(map[*resty.Request]int),
+		started:  time.Now(),
+		title:    title,
+	}
+}
+
+// Apply will record every attempt made with the client (tracing is turned on for the timings)
+func (r *Recorder) Apply(client *resty.Client) *resty.Client {
+	client.
+		OnBeforeRequest(func(_ *resty.Client, request *resty.Request) error {
+			request.EnableTrace()
+			return nil
+		}).
+		OnAfterResponse(func(_ *resty.Client, response *resty.Response) error {
+			r.record(response.Request, response, nil)
+			return nil
+		}).
+		AddRetryHook(func(response *resty.Response, err error) {
+			if err != nil && response != nil && response.Request != nil && response.RawResponse == nil {
+				r.record(response.Request, nil, err)
+			}
+		}).
+		OnError(func(request *resty.Request, err error) {
+			r.record(request, nil, err)
+			r.finish(request)
+		}).
+		OnSuccess(func(_ *resty.Client, response *resty.Response) {
+			r.finish(response.Request)
+		})
+	return client
+}
+
+// Len returns the number of entries recorded
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries)
+}
+
+// Archive returns the HAR file of all entries (sorted by start time)
+func (r *Recorder) Archive() *File {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]*Entry, len(r.entries))
+	copy(entries, r.entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+	return &File{Log: &Log{
+		Creator: r.creator,
+		Entries: entries,
+		Pages:   []*Page{{ID: pageID, PageTimings: &PageTimings{}, StartedDateTime: r.started, Title: r.title}},
+		Version: Version,
+	}}
+}
+
+// Save will write the HAR file (indented JSON)
+func (r *Recorder) Save(filename string) error {
+	data, err := json.MarshalIndent(r.Archive(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0o600)
+}
+
+// record will add the attempt of the request (once per attempt)
+func (r *Recorder) record(request *resty.Request, response *resty.Response, err error) {
+	if request == nil || request.RawRequest == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if attempt, ok := r.recorded[request]; ok && attempt >= request.Attempt {
+		return
+	}
+	r.recorded[request] = request.Attempt
+
+	entry := &Entry{
+		PageRef:         pageID,
+		Request:         newRequest(request.RawRequest),
+		Response:        &Response{Content: &Content{}, Cookies: []*NameValue{}, Headers: []*NameValue{}, HeadersSize: -1, BodySize: -1},
+		StartedDateTime: request.Time,
+		Timings:         newTimings(request.TraceInfo()),
+	}
+	if request.Attempt > 1 {
+		entry.Attempt = request.Attempt
+	}
+	if name, ok := request.Context().Value(nameKey{}).(string); ok {
+		entry.Name = name
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if response != nil && response.RawResponse != nil {
+		entry.Response = newResponse(response)
+	}
+	if addr := request.TraceInfo().RemoteAddr; addr != nil {
+		entry.ServerIPAddress, _, _ = net.SplitHostPort(addr.String())
+	}
+	entry.Time = entry.Timings.total()
+	if entry.Time == 0 && response != nil {
+		entry.Time = milliseconds(response.Time())
+	}
+	r.entries = append(r.entries, entry)
+}
+
+// finish will forget the request after its last attempt (only requests in progress are kept)
+func (r *Recorder) finish(request *resty.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.recorded, request)
+}
+
+// newRequest will convert the request (the body is read from a copy)
+func newRequest(raw *http.Request) *Request {
+	request := &Request{
+		BodySize:    0,
+		Cookies:     []*NameValue{},
+		Headers:     nameValues(raw.Header),
+		HeadersSize: -1,
+		HTTPVersion: raw.Proto,
+		Method:      raw.Method,
+		QueryString: nameValues(raw.URL.Query()),
+		URL:         raw.URL.String(),
+	}
+	for _, cookie := range raw.Cookies() {
+		request.Cookies = append(request.Cookies, &NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	if raw.GetBody != nil {
+		if body, err := raw.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			_ = body.Close()
+			if len(data) > 0 {
+				request.BodySize = len(data)
+				request.PostData = &PostData{MimeType: raw.Header.Get("Content-Type"), Text: string(data)}
+			}
+		}
+	}
+	return request
+}
+
+// newResponse will convert the response (a binary body is base64 encoded)
+func newResponse(response *resty.Response) *Response {
+	raw := response.RawResponse
+	body := response.Body()
+	converted := &Response{
+		BodySize:    len(body),
+		Content:     &Content{MimeType: raw.Header.Get("Content-Type"), Size: len(body)},
+		Cookies:     []*NameValue{},
+		Headers:     nameValues(raw.Header),
+		HeadersSize: -1,
+		HTTPVersion: raw.Proto,
+		RedirectURL: raw.Header.Get("Location"),
+		Status:      raw.StatusCode,
+		StatusText:  http.StatusText(raw.StatusCode),
+	}
+	for _, cookie := range raw.Cookies() {
+		converted.Cookies = append(converted.Cookies, &NameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	if utf8.Valid(body) {
+		converted.Content.Text = string(body)
+	} else {
+		converted.Content.Encoding = "base64"
+		converted.Content.Text = base64.StdEncoding.EncodeToString(body)
+	}
+	return converted
+}
+
+// newTimings will convert the resty trace into the HAR phases
+func newTimings(trace resty.TraceInfo) *Timings {
+	timings := &Timings{
+		Blocked: -1,
+		Connect: -1,
+		DNS:     -1,
+		Receive: milliseconds(trace.ResponseTime),
+		SSL:     -1,
+		Wait:    milliseconds(trace.ServerTime),
+	}
+	if !trace.IsConnReused {
+		timings.DNS = milliseconds(trace.DNSLookup)
+		timings.Connect = milliseconds(trace.TCPConnTime + trace.TLSHandshake) // HAR: connect includes ssl
+		if trace.TLSHandshake > 0 {
+			timings.SSL = milliseconds(trace.TLSHandshake)
+		}
+		if blocked := trace.ConnTime - trace.DNSLookup - trace.TCPConnTime - trace.TLSHandshake; blocked > 0 {
+			timings.Blocked = milliseconds(blocked)
+		}
+	}
+	return timings
+}
+
+// total returns the time of the entry (the sum of all phases, ssl is part of connect)
+func (t *Timings) total() float64 {
+	var total float64
+	for _, phase := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if phase > 0 {
+			total += phase
+		}
+	}
+	return total
+}
+
+// nameValues will convert the headers (or query string) sorted by name
+func nameValues(values map[string][]string) []*NameValue {
+	list := make([]*NameValue, 0, len(values))
+	for name, items := range values {
+		for _, value := range items {
+			list = append(list, &NameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// milliseconds returns the duration in milliseconds (with fractions)
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+// recorderKey is the context key for the recorder
+type recorderKey struct{}
+
+// WithRecorder returns a context that carries the recorder (used by Apply)
+func WithRecorder(ctx context.Context, recorder *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// nameKey is the context key for the name of the request
+type nameKey struct{}
+
+// WithName returns a context that names the requests made with it (IE: capabilities, bitpic-search)
+func WithName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, nameKey{}, name)
+}
+
+// Apply will record the requests of the client if the context carries a recorder (otherwise nothing is changed)
+func Apply(ctx context.Context, client *resty.Client) *resty.Client {
+	if recorder, ok := ctx.Value(recorderKey{}).(*Recorder); ok && recorder != nil {
+		recorder.Apply(client)
+	}
+	return client
+}
@@ -0,0 +1,155 @@
+package har
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// TestNewTimings will test the method newTimings()
+func TestNewTimings(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		trace    resty.TraceInfo
+		expected Timings
+	}{
+		{"reused connection", resty.TraceInfo{
+			IsConnReused: true, ResponseTime: 2 * time.Millisecond, ServerTime: 10 * time.Millisecond,
+		}, Timings{Blocked: -1, Connect: -1, DNS: -1, Receive: 2, SSL: -1, Wait: 10}},
+		{"new tls connection", resty.TraceInfo{
+			ConnTime: 40 * time.Millisecond, DNSLookup: 5 * time.Millisecond, ResponseTime: time.Millisecond,
+			ServerTime: 20 * time.Millisecond, TCPConnTime: 10 * time.Millisecond, TLSHandshake: 15 * time.Millisecond,
+		}, Timings{Blocked: 10, Connect: 25, DNS: 5, Receive: 1, SSL: 15, Wait: 20}},
+		{"new plain connection", resty.TraceInfo{
+			ConnTime: 15 * time.Millisecond, DNSLookup: 5 * time.Millisecond, ServerTime: 3 * time.Millisecond,
+			TCPConnTime: 10 * time.Millisecond,
+		}, Timings{Blocked: -1, Connect: 10, DNS: 5, Receive: 0, SSL: -1, Wait: 3}},
+		{"fractions", resty.TraceInfo{
+			IsConnReused: true, ResponseTime: 1500 * time.Microsecond, ServerTime: 250 * time.Microsecond,
+		}, Timings{Blocked: -1, Connect: -1, DNS: -1, Receive: 1.5, SSL: -1, Wait: 0.25}},
+	}
+
+	for _, test := range tests {
+		if output := newTimings(test.trace); *output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%+v] expected, received: [%+v]", t.Name(), test.name, test.expected, *output)
+		}
+	}
+}
+
+// TestTimings_total will test the method total()
+func TestTimings_total(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		timings  Timings
+		expected float64
+	}{
+		{"nothing used", Timings{Blocked: -1, Connect: -1, DNS: -1, Receive: 0, Send: 0, SSL: -1, Wait: 0}, 0},
+		{"reused connection", Timings{Blocked: -1, Connect: -1, DNS: -1, Receive: 2, SSL: -1, Wait: 10}, 12},
+		{"ssl is part of connect", Timings{Blocked: 10, Connect: 25, DNS: 5, Receive: 1, Send: 1, SSL: 15, Wait: 20}, 62},
+	}
+
+	for _, test := range tests {
+		if output := test.timings.total(); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
+
+// TestRecorder will test recording every attempt of the requests (and forgetting finished requests)
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	// The first request to /retry fails with a 503
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/retry":
+			if atomic.AddInt32(&attempts, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	recorder := NewRecorder("paymail-inspector", "1.0.0", "test")
+	client := recorder.Apply(resty.New().
+		SetRetryCount(1).
+		SetRetryWaitTime(time.Millisecond).
+		AddRetryCondition(func(response *resty.Response, _ error) bool {
+			return response != nil && response.StatusCode() == http.StatusServiceUnavailable
+		}))
+
+	for _, path := range []string{"/ok", "/retry", "/missing"} {
+		if _, err := client.R().SetContext(WithName(context.Background(), path[1:])).Get(server.URL + path); err != nil {
+			t.Fatalf("%s Failed: [%s] inputted, received an error: %s", t.Name(), path, err.Error())
+		}
+	}
+	if _, err := client.R().Get("http://127.0.0.1:1/refused"); err == nil {
+		t.Fatalf("%s Failed: a network error expected", t.Name())
+	}
+
+	var tests = []struct {
+		url     string
+		name    string
+		attempt int
+		status  int
+		failed  bool
+	}{
+		{server.URL + "/ok", "ok", 0, http.StatusOK, false},
+		{server.URL + "/retry", "retry", 0, http.StatusServiceUnavailable, false},
+		{server.URL + "/retry", "retry", 2, http.StatusOK, false},
+		{server.URL + "/missing", "missing", 0, http.StatusNotFound, false},
+		{"http://127.0.0.1:1/refused", "", 0, 0, true},
+	}
+
+	archive := recorder.Archive()
+	if len(archive.Log.Entries) != len(tests) || recorder.Len() != len(tests) {
+		t.Fatalf("%s Failed: [%d] entries expected, received: [%d]", t.Name(), len(tests), len(archive.Log.Entries))
+	}
+	for index, test := range tests {
+		entry := archive.Log.Entries[index]
+		if entry.Request.URL != test.url || entry.Attempt != test.attempt || entry.Response.Status != test.status || (len(entry.Error) > 0) != test.failed {
+			t.Errorf("%s Failed: [%s] attempt %d status %d expected at %d, received: [%s] attempt %d status %d error [%s]",
+				t.Name(), test.url, test.attempt, test.status, index, entry.Request.URL, entry.Attempt, entry.Response.Status, entry.Error)
+		}
+		if entry.Name != test.name {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.url, test.name, entry.Name)
+		}
+		if entry.PageRef != pageID || entry.Timings == nil {
+			t.Errorf("%s Failed: [%s] inputted and a page and timings expected, received: [%+v]", t.Name(), test.url, entry)
+		}
+	}
+	if text := archive.Log.Entries[0].Response.Content.Text; text != `{"ok":true}` {
+		t.Errorf("%s Failed: the response body expected, received: [%s]", t.Name(), text)
+	}
+
+	// Finished requests are not kept
+	recorder.mu.Lock()
+	inProgress := len(recorder.recorded)
+	recorder.mu.Unlock()
+	if inProgress != 0 {
+		t.Errorf("%s Failed: no requests in progress expected, received: [%d]", t.Name(), inProgress)
+	}
+
+	// Save the archive
+	filename := filepath.Join(t.TempDir(), "requests.har")
+	if err := recorder.Save(filename); err != nil {
+		t.Errorf("%s Failed: received an error saving: %s", t.Name(), err.Error())
+	}
+}
@@ -5,11 +5,15 @@ Read more at: https://baemail.me/
 package baemail
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/mrz1836/paymail-inspector/har"
+	"github.com/mrz1836/paymail-inspector/retry"
 )
 
 // Defaults for baemail package
@@ -21,37 +25,46 @@ const (
 
 // Override the package defaults
 var (
-	Network   = baemailURL       // override the default network
-	UserAgent = defaultUserAgent // override the default user agent
+	Enabled   = true                            // turn the integration on or off
+	Network   = baemailURL                      // override the default network
+	Retries   = 2                               // number of retries for a failed request (transient errors only)
+	Timeout   = defaultGetTimeout * time.Second // time allowed for one request
+	UserAgent = defaultUserAgent                // override the default user agent
 )
 
 // Response is the standard fields returned on all responses
 type Response struct {
-	ComposeURL string          `json:"compose_url"` // Compose email url
-	Found      bool            `json:"found"`       // Flag if the profile was found
-	StatusCode int             `json:"status_code"` // Status code returned on the request
-	Tracing    resty.TraceInfo `json:"-"`           // Trace information if enabled on the request
+	Attempts   []*retry.Attempt `json:"-"`           // Failed attempts that were retried
+	ComposeURL string           `json:"compose_url"` // Compose email url
+	Found      bool             `json:"found"`       // Flag if the profile was found
+	StatusCode int              `json:"status_code"` // Status code returned on the request
+	Tracing    resty.TraceInfo  `json:"-"`           // Trace information if enabled on the request
 }
 
 // HasProfile will check if a profile exists for the given paymail address
 // Specs: (no docs)
-func HasProfile(alias, domain string, tracing bool) (response *Response, err error) {
+func HasProfile(ctx context.Context, alias, domain string, tracing bool) (response *Response, err error) {
 	// Set the url for the request
-	reqURL := fmt.Sprintf("%s/api/exists/%s@%s", Network, alias, domain)
+	reqURL := fmt.Sprintf("%s/api/exists/%s@%s", strings.TrimSuffix(Network, "/"), alias, domain)
 
 	// Create a Client and start the request
-	client := resty.New().SetTimeout(defaultGetTimeout * time.Second)
+	client := har.Apply(ctx, resty.New().SetTimeout(Timeout))
+	var attempts []*retry.Attempt
+	retry.Policy{Attempts: Retries + 1}.Apply(client, func(attempt *retry.Attempt) {
+		attempts = append(attempts, attempt)
+	})
 	var resp *resty.Response
-	req := client.R().SetHeader("User-Agent", UserAgent)
+	req := client.R().SetContext(har.WithName(ctx, "baemail")).SetHeader("User-Agent", UserAgent)
 	if tracing {
 		req.EnableTrace()
 	}
 	if resp, err = req.Get(reqURL); err != nil {
-		return response, err
+		return &Response{Attempts: attempts}, err
 	}
 
 	// Start the response
 	response = new(Response)
+	response.Attempts = attempts
 
 	// Tracing enabled?
 	if tracing {
@@ -77,5 +90,5 @@ func HasProfile(alias, domain string, tracing bool) (response *Response, err err
 // Compose will return an url for composing a baemail
 // Specs: https://baemail.me/compose?to=user%40domain
 func Compose(alias, domain string) (url string) {
-	return fmt.Sprintf("%s/compose?to=%s@%s", Network, alias, domain)
+	return fmt.Sprintf("%s/compose?to=%s@%s", strings.TrimSuffix(Network, "/"), alias, domain)
 }
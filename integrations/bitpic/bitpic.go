@@ -5,12 +5,16 @@ Read more at: https://bitpic.network/about
 package bitpic
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/mrz1836/paymail-inspector/har"
+	"github.com/mrz1836/paymail-inspector/retry"
 )
 
 // Defaults for bitpic package
@@ -22,24 +26,29 @@ const (
 
 // Override the package defaults
 var (
-	DefaultImage string             // custom default image (if no image is found)
-	Network      = bitPicURL        // override the default network
-	UserAgent    = defaultUserAgent // override the default user agent
+	DefaultImage string                            // custom default image (if no image is found)
+	Enabled      = true                            // turn the integration on or off
+	Network      = bitPicURL                       // override the default network
+	Retries      = 2                               // number of retries for a failed request (transient errors only)
+	Timeout      = defaultGetTimeout * time.Second // time allowed for one request
+	UserAgent    = defaultUserAgent                // override the default user agent
 )
 
 // Response is the standard fields returned on all responses
 type Response struct {
-	Found      bool            `json:"found"`       // Flag if the bitpic was found
-	StatusCode int             `json:"status_code"` // Status code returned on the request
-	Tracing    resty.TraceInfo `json:"tracing"`     // Trace information if enabled on the request
-	URL        string          `json:"url"`         // The bitpic url for the image
+	Attempts   []*retry.Attempt `json:"-"`           // Failed attempts that were retried
+	Found      bool             `json:"found"`       // Flag if the bitpic was found
+	StatusCode int              `json:"status_code"` // Status code returned on the request
+	Tracing    resty.TraceInfo  `json:"tracing"`     // Trace information if enabled on the request
+	URL        string           `json:"url"`         // The bitpic url for the image
 }
 
 // SearchResponse is the response from /search
 type SearchResponse struct {
-	Result     *SearchResult   `json:"result"`      // Result from BitPics
-	StatusCode int             `json:"status_code"` // Status code returned on the request
-	Tracing    resty.TraceInfo `json:"-"`           // Trace information if enabled on the request
+	Attempts   []*retry.Attempt `json:"-"`           // Failed attempts that were retried
+	Result     *SearchResult    `json:"result"`      // Result from BitPics
+	StatusCode int              `json:"status_code"` // Status code returned on the request
+	Tracing    resty.TraceInfo  `json:"-"`           // Trace information if enabled on the request
 }
 
 // SearchResult is the child of the response
@@ -77,23 +86,28 @@ type Meta struct {
 
 // GetPic will check if a bitpic exists for the given paymail address and fetch the url if found
 // Specs: https://bitpic.network/about
-func GetPic(alias, domain string, tracing bool) (response *Response, err error) {
+func GetPic(ctx context.Context, alias, domain string, tracing bool) (response *Response, err error) {
 	// Set the url for the request
-	reqURL := fmt.Sprintf("https://%s/exists/%s@%s", Network, alias, domain)
+	reqURL := fmt.Sprintf("%s/exists/%s@%s", baseURL(""), alias, domain)
 
 	// Create a Client and start the request
-	client := resty.New().SetTimeout(defaultGetTimeout * time.Second)
+	client := har.Apply(ctx, resty.New().SetTimeout(Timeout))
+	var attempts []*retry.Attempt
+	retry.Policy{Attempts: Retries + 1}.Apply(client, func(attempt *retry.Attempt) {
+		attempts = append(attempts, attempt)
+	})
 	var resp *resty.Response
-	req := client.R().SetHeader("User-Agent", UserAgent)
+	req := client.R().SetContext(har.WithName(ctx, "bitpic")).SetHeader("User-Agent", UserAgent)
 	if tracing {
 		req.EnableTrace()
 	}
 	if resp, err = req.Get(reqURL); err != nil {
-		return response, err
+		return &Response{Attempts: attempts}, err
 	}
 
 	// Start the response
 	response = new(Response)
+	response.Attempts = attempts
 
 	// Tracing enabled?
 	if tracing {
@@ -120,39 +134,44 @@ func GetPic(alias, domain string, tracing bool) (response *Response, err error)
 // Specs: https://bitpic.network/about
 func URL(alias, domain string) string {
 	if len(DefaultImage) > 0 {
-		return fmt.Sprintf("https://%s/u/%s@%s?d=%s", Network, alias, domain, DefaultImage)
+		return fmt.Sprintf("%s/u/%s@%s?d=%s", baseURL(""), alias, domain, DefaultImage)
 	}
-	return fmt.Sprintf("https://%s/u/%s@%s", Network, alias, domain)
+	return fmt.Sprintf("%s/u/%s@%s", baseURL(""), alias, domain)
 }
 
 // URLFromPaymail will return an HTTP url for the bitpic avatar using a paymail
 // Specs: https://bitpic.network/about
 func URLFromPaymail(paymail string) string {
 	if len(DefaultImage) > 0 {
-		return fmt.Sprintf("https://%s/u/%s?d=%s", Network, paymail, DefaultImage)
+		return fmt.Sprintf("%s/u/%s?d=%s", baseURL(""), paymail, DefaultImage)
 	}
-	return fmt.Sprintf("https://%s/u/%s", Network, paymail)
+	return fmt.Sprintf("%s/u/%s", baseURL(""), paymail)
 }
 
 // Search will perform a search on the BitPic network
 // https://txt.bitpic.network/search/json?text=alias@domain
-func Search(alias, domain string, tracing bool) (response *SearchResponse, err error) {
+func Search(ctx context.Context, alias, domain string, tracing bool) (response *SearchResponse, err error) {
 	// Set the url for the request
-	reqURL := fmt.Sprintf("https://txt.%s/search/json?text=%s@%s", bitPicURL, alias, domain)
+	reqURL := fmt.Sprintf("%s/search/json?text=%s@%s", baseURL("txt"), alias, domain)
 
 	// Create a Client and start the request
-	client := resty.New().SetTimeout(defaultGetTimeout * time.Second)
+	client := har.Apply(ctx, resty.New().SetTimeout(Timeout))
+	var attempts []*retry.Attempt
+	retry.Policy{Attempts: Retries + 1}.Apply(client, func(attempt *retry.Attempt) {
+		attempts = append(attempts, attempt)
+	})
 	var resp *resty.Response
-	req := client.R().SetHeader("User-Agent", UserAgent)
+	req := client.R().SetContext(har.WithName(ctx, "bitpic-search")).SetHeader("User-Agent", UserAgent)
 	if tracing {
 		req.EnableTrace()
 	}
 	if resp, err = req.Get(reqURL); err != nil {
-		return response, err
+		return &SearchResponse{Attempts: attempts}, err
 	}
 
 	// Start the response
 	response = new(SearchResponse)
+	response.Attempts = attempts
 
 	// Tracing enabled?
 	if tracing {
@@ -176,3 +195,14 @@ func Search(alias, domain string, tracing bool) (response *SearchResponse, err e
 
 	return response, err
 }
+
+// baseURL returns the url of the network or a subdomain of it (IE: txt.bitpic.network)
+// A full url in Network is used as-is for all requests (IE: a mirror or local stand-in)
+func baseURL(subdomain string) string {
+	if strings.Contains(Network, "://") {
+		return strings.TrimSuffix(Network, "/")
+	} else if len(subdomain) > 0 {
+		return "https://" + subdomain + "." + Network
+	}
+	return "https://" + Network
+}
@@ -0,0 +1,91 @@
+package bitpic
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/mrz1836/paymail-inspector/integrations"
+)
+
+func init() {
+	integrations.Register(&Integration{})
+}
+
+// Profile is the combined result of checking for a bitpic and searching for all bitpics
+type Profile struct {
+	Pic    *Response       `json:"pic"`
+	Search *SearchResponse `json:"search"`
+}
+
+// Integration checks Bitpic for avatars (registered in the integrations registry)
+type Integration struct{}
+
+// Enabled returns true if the integration is turned on
+func (i *Integration) Enabled() bool {
+	return Enabled
+}
+
+// Settings returns the current settings of the integration
+func (i *Integration) Settings() *integrations.Settings {
+	return &integrations.Settings{Enabled: Enabled, Network: Network, Retries: Retries, Timeout: Timeout, UserAgent: UserAgent}
+}
+
+// Configure will apply the settings to all requests of the package
+func (i *Integration) Configure(settings *integrations.Settings) {
+	Enabled, Network, Retries, Timeout, UserAgent = settings.Enabled, settings.Network, settings.Retries, settings.Timeout, settings.UserAgent
+}
+
+// Name returns the unique name of the integration
+func (i *Integration) Name() string {
+	return "bitpic"
+}
+
+// Order returns the position of the integration in the output
+func (i *Integration) Order() int {
+	return 10
+}
+
+// Title returns the display name of the integration
+func (i *Integration) Title() string {
+	return "Bitpic"
+}
+
+// Lookup will check for a bitpic and search for all bitpics of the given paymail address
+func (i *Integration) Lookup(ctx context.Context, alias, domain string, tracing bool) (*integrations.Result, error) {
+	profile := new(Profile)
+	result := &integrations.Result{Data: profile}
+
+	// Check for the bitpic
+	var picErr, searchErr error
+	if profile.Pic, picErr = GetPic(ctx, alias, domain, tracing); profile.Pic != nil {
+		result.AddAttempts(i.Name(), profile.Pic.Attempts)
+		if profile.Pic.StatusCode > 0 {
+			result.AddRequest(i.Name(), profile.Pic.StatusCode, profile.Pic.Tracing)
+		}
+	}
+
+	// Search for all bitpics
+	if profile.Search, searchErr = Search(ctx, alias, domain, tracing); profile.Search != nil {
+		result.AddAttempts(i.Name()+"-search", profile.Search.Attempts)
+		if profile.Search.StatusCode > 0 {
+			result.AddRequest(i.Name()+"-search", profile.Search.StatusCode, profile.Search.Tracing)
+		}
+	}
+
+	// Possible matches are shown first (otherwise the bitpic url)
+	if searchErr == nil && profile.Search != nil && profile.Search.Result != nil && len(profile.Search.Result.Posts) > 0 {
+		result.Found = true
+		result.AddField("Bitpic URL", URLFromPaymail(profile.Search.Result.Posts[0].Data.Paymail))
+		for index, post := range profile.Search.Result.Posts {
+			if post.Data != nil && len(post.Data.Paymail) > 0 {
+				result.AddField("Bitpic Img #"+strconv.Itoa(index+1), post.Data.BitFs)
+			}
+		}
+	} else if picErr == nil && profile.Pic != nil && profile.Pic.Found {
+		result.Found = true
+		result.AddField("Bitpic", profile.Pic.URL)
+	}
+
+	return result, errors.Join(picErr, searchErr)
+}
@@ -0,0 +1,123 @@
+/*
+Package integrations is the registry of third-party services that are checked for a paymail address (IE: Bitpic, Baemail)
+
+Each integration registers itself (usually in the init() of its package) and is looked up by the CLI
+with the same caching, skip flags, tracing and display handling.
+*/
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/mrz1836/paymail-inspector/retry"
+)
+
+// Integration is a third-party service that can be checked for a paymail address
+type Integration interface {
+	Configure(settings *Settings)                                                    // Apply the settings (IE: from the config file)
+	Enabled() bool                                                                   // Flag if the integration is turned on
+	Lookup(ctx context.Context, alias, domain string, tracing bool) (*Result, error) // Check the service for alias@domain
+	Name() string                                                                    // Unique name (IE: bitpic), used for flags and cache keys
+	Order() int                                                                      // Position in the output and the flags (lowest first)
+	Settings() *Settings                                                             // Current settings
+	Title() string                                                                   // Display name (IE: Bitpic)
+}
+
+// Settings are the options of an integration (used for all requests to the service)
+type Settings struct {
+	Enabled   bool          `json:"enabled"`    // Flag if the integration is turned on
+	Network   string        `json:"network"`    // Base url (or host) of the service (IE: a mirror)
+	Retries   int           `json:"retries"`    // Number of retries for a failed request
+	Timeout   time.Duration `json:"timeout"`    // Time allowed for one request
+	UserAgent string        `json:"user_agent"` // User agent sent on all requests
+}
+
+// Field is one line of display output (IE: Baemail: https://baemail.me/compose?to=...)
+type Field struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Request is one request made during a lookup (trace information if enabled on the request)
+type Request struct {
+	Name       string          `json:"name"`        // Name of the request (IE: bitpic-search)
+	StatusCode int             `json:"status_code"` // Status code returned on the request
+	Tracing    resty.TraceInfo `json:"-"`           // Trace information if enabled on the request
+}
+
+// Result is the result of a lookup
+type Result struct {
+	Data     interface{} `json:"data,omitempty"`   // Response from the service (typed per integration)
+	Fields   []*Field    `json:"fields,omitempty"` // Lines to display (if found)
+	Found    bool        `json:"found"`            // Flag if the paymail was found on the service
+	Requests []*Request  `json:"-"`                // Requests made during the lookup
+}
+
+// AddRequest will add a request to the result (for tracing)
+func (r *Result) AddRequest(name string, statusCode int, tracing resty.TraceInfo) {
+	r.Requests = append(r.Requests, &Request{Name: name, StatusCode: statusCode, Tracing: tracing})
+}
+
+// AddAttempts will add the failed attempts that were retried (for tracing)
+func (r *Result) AddAttempts(name string, attempts []*retry.Attempt) {
+	for _, attempt := range attempts {
+		r.AddRequest(fmt.Sprintf("%s-attempt-%d", name, attempt.Number), attempt.StatusCode, attempt.Tracing)
+	}
+}
+
+// AddField will add a line to display (empty values are skipped)
+func (r *Result) AddField(label, value string) {
+	if len(value) > 0 {
+		r.Fields = append(r.Fields, &Field{Label: label, Value: value})
+	}
+}
+
+// registry is all registered integrations by name
+var (
+	registry   = make(map[string]Integration)
+	registryMu sync.RWMutex
+)
+
+// Register will add an integration (panics if the name is empty or already registered)
+func Register(integration Integration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if integration == nil || len(integration.Name()) == 0 {
+		panic("integrations: missing integration or name")
+	}
+	if _, ok := registry[integration.Name()]; ok {
+		panic(fmt.Sprintf("integrations: %s is already registered", integration.Name()))
+	}
+	registry[integration.Name()] = integration
+}
+
+// Get will return an integration by name (nil if not found)
+func Get(name string) Integration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[name]
+}
+
+// All will return all registered integrations (sorted by order, then by name)
+//
+// The order is explicit: the init() order of the packages would sort them by import path
+func All() []Integration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	list := make([]Integration, 0, len(registry))
+	for _, integration := range registry {
+		list = append(list, integration)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Order() != list[j].Order() {
+			return list[i].Order() < list[j].Order()
+		}
+		return list[i].Name() < list[j].Name()
+	})
+	return list
+}
@@ -5,12 +5,16 @@ Read more at: https://powping.com/about
 package powping
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	"github.com/mrz1836/paymail-inspector/har"
+	"github.com/mrz1836/paymail-inspector/retry"
 )
 
 // Defaults for powping package
@@ -22,15 +26,19 @@ const (
 
 // Override the package defaults
 var (
-	Network   = powPingURL       // override the default network
-	UserAgent = defaultUserAgent // override the default user agent
+	Enabled   = true                            // turn the integration on or off
+	Network   = powPingURL                      // override the default network
+	Retries   = 2                               // number of retries for a failed request (transient errors only)
+	Timeout   = defaultGetTimeout * time.Second // time allowed for one request
+	UserAgent = defaultUserAgent                // override the default user agent
 )
 
 // Response is the response from fetching a profile
 type Response struct {
-	Profile    *Profile        `json:"profile"`     // The roundesk profile data
-	StatusCode int             `json:"status_code"` // Status code returned on the request
-	Tracing    resty.TraceInfo `json:"-"`           // Trace information if enabled on the request
+	Attempts   []*retry.Attempt `json:"-"`           // Failed attempts that were retried
+	Profile    *Profile         `json:"profile"`     // The roundesk profile data
+	StatusCode int              `json:"status_code"` // Status code returned on the request
+	Tracing    resty.TraceInfo  `json:"-"`           // Trace information if enabled on the request
 }
 
 // Profile is the public profile information for a given paymail
@@ -40,23 +48,28 @@ type Profile struct {
 
 // GetProfile will get a powping profile if it exists for the given paymail address
 // Specs: https://powping.com/about
-func GetProfile(alias, domain string, tracing bool) (response *Response, err error) {
+func GetProfile(ctx context.Context, alias, domain string, tracing bool) (response *Response, err error) {
 	// Set the url for the request
-	reqURL := fmt.Sprintf("%su?paymail=%s@%s", Network, alias, domain)
+	reqURL := fmt.Sprintf("%s/u?paymail=%s@%s", strings.TrimSuffix(Network, "/"), alias, domain)
 
 	// Create a Client and start the request
-	client := resty.New().SetTimeout(defaultGetTimeout * time.Second)
+	client := har.Apply(ctx, resty.New().SetTimeout(Timeout))
+	var attempts []*retry.Attempt
+	retry.Policy{Attempts: Retries + 1}.Apply(client, func(attempt *retry.Attempt) {
+		attempts = append(attempts, attempt)
+	})
 	var resp *resty.Response
-	req := client.R().SetHeader("User-Agent", UserAgent)
+	req := client.R().SetContext(har.WithName(ctx, "powping")).SetHeader("User-Agent", UserAgent)
 	if tracing {
 		req.EnableTrace()
 	}
 	if resp, err = req.Get(reqURL); err != nil {
-		return response, err
+		return &Response{Attempts: attempts}, err
 	}
 
 	// Start the response
 	response = new(Response)
+	response.Attempts = attempts
 
 	// Tracing enabled?
 	if tracing {
@@ -0,0 +1,74 @@
+package roundesk
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mrz1836/paymail-inspector/integrations"
+)
+
+// profileURL is the public profile page (the api is under the network)
+const profileURL = "https://roundesk.co/u/"
+
+func init() {
+	integrations.Register(&Integration{})
+}
+
+// Integration checks Roundesk for a profile (registered in the integrations registry)
+type Integration struct{}
+
+// Enabled returns true if the integration is turned on
+func (i *Integration) Enabled() bool {
+	return Enabled
+}
+
+// Settings returns the current settings of the integration
+func (i *Integration) Settings() *integrations.Settings {
+	return &integrations.Settings{Enabled: Enabled, Network: Network, Retries: Retries, Timeout: Timeout, UserAgent: UserAgent}
+}
+
+// Configure will apply the settings to all requests of the package
+func (i *Integration) Configure(settings *integrations.Settings) {
+	Enabled, Network, Retries, Timeout, UserAgent = settings.Enabled, settings.Network, settings.Retries, settings.Timeout, settings.UserAgent
+}
+
+// Name returns the unique name of the integration
+func (i *Integration) Name() string {
+	return "roundesk"
+}
+
+// Order returns the position of the integration in the output
+func (i *Integration) Order() int {
+	return 30
+}
+
+// Title returns the display name of the integration
+func (i *Integration) Title() string {
+	return "Roundesk"
+}
+
+// Lookup will get a Roundesk profile if it exists for the given paymail address
+func (i *Integration) Lookup(ctx context.Context, alias, domain string, tracing bool) (*integrations.Result, error) {
+	response, err := GetProfile(ctx, alias, domain, tracing)
+	result := new(integrations.Result)
+	if response == nil {
+		return result, err
+	}
+	result.AddAttempts(i.Name(), response.Attempts)
+	if response.StatusCode == 0 {
+		return result, err // No response (IE: a network error)
+	}
+	result.Data = response
+	result.AddRequest(i.Name(), response.StatusCode, response.Tracing)
+	if err == nil && response.Profile != nil && len(response.Profile.Paymail) > 0 {
+		result.Found = true
+		result.AddField("Roundesk", profileURL+alias+"@"+domain)
+		result.AddField("Roundesk Name", response.Profile.Name)
+		result.AddField("Headline", response.Profile.Headline)
+		result.AddField("Bio", strings.TrimSuffix(response.Profile.Bio, "\n"))
+		if len(response.Profile.Twetch) > 0 {
+			result.AddField("Twetch", "https://twetch.app/u/"+response.Profile.Twetch)
+		}
+	}
+	return result, err
+}
@@ -0,0 +1,245 @@
+/*
+Package metrics is a small registry of gauges, counters and histograms written in the Prometheus text format
+*/
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types (Prometheus text format)
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// ContentType is the Prometheus text format content type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the default histogram buckets (in seconds) for request latency
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Labels are the label names and values of one series
+type Labels map[string]string
+
+// Registry holds all metric families (safe for concurrent use)
+type Registry struct {
+	families map[string]*family
+	mu       sync.Mutex
+}
+
+// family is one metric name with all of its series
+type family struct {
+	buckets []float64
+	help    string
+	kind    string
+	name    string
+	series  map[string]*series
+}
+
+// series is one set of label values for a metric
+type series struct {
+	buckets []uint64 // Histogram only (cumulative counts are computed when writing)
+	count   uint64   // Histogram only
+	labels  string   // Rendered labels: {a="b",c="d"}
+	value   float64  // Gauge and counter value, histogram sum
+}
+
+// NewRegistry will create a new empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Set will set the value of a gauge
+func (r *Registry) Set(name, help string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.getSeries(name, help, typeGauge, nil, labels).value = value
+}
+
+// Add will add to the value of a counter
+func (r *Registry) Add(name, help string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.getSeries(name, help, typeCounter, nil, labels).value += value
+}
+
+// Observe will add an observation to a histogram (buckets are only used for a new metric)
+func (r *Registry) Observe(name, help string, buckets []float64, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.getSeries(name, help, typeHistogram, buckets, labels)
+	for index, bound := range r.families[name].buckets {
+		if value <= bound {
+			s.buckets[index]++
+			break
+		}
+	}
+	s.count++
+	s.value += value
+}
+
+// Delete will remove one series (IE: a target that is no longer probed)
+func (r *Registry) Delete(name string, labels Labels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		delete(f.series, formatLabels(labels))
+	}
+}
+
+// getSeries will get (or create) a series (the registry must be locked)
+func (r *Registry) getSeries(name, help, kind string, buckets []float64, labels Labels) *series {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{help: help, kind: kind, name: name, series: make(map[string]*series)}
+		if kind == typeHistogram {
+			if len(buckets) == 0 {
+				buckets = DefaultBuckets
+			}
+			f.buckets = append([]float64{}, buckets...)
+			sort.Float64s(f.buckets)
+		}
+		r.families[name] = f
+	}
+	key := formatLabels(labels)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: key}
+		if f.kind == typeHistogram {
+			s.buckets = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// WriteTo will write all metrics in the Prometheus text format (sorted by name and labels)
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counter := &countWriter{writer: bufio.NewWriter(w)}
+	for _, name := range names {
+		r.families[name].write(counter)
+	}
+	if err := counter.writer.Flush(); err != nil {
+		return counter.count, err
+	}
+	return counter.count, counter.err
+}
+
+// ServeHTTP will serve all metrics (implements http.Handler)
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = r.WriteTo(w)
+}
+
+// write will write one family with all of its series
+func (f *family) write(w *countWriter) {
+	w.printf("# HELP %s %s\n", f.name, escapeHelp(f.help))
+	w.printf("# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind != typeHistogram {
+			w.printf("%s%s %s\n", f.name, s.labels, formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for index, bound := range f.buckets {
+			cumulative += s.buckets[index]
+			w.printf("%s_bucket%s %d\n", f.name, withLabel(s.labels, "le", formatFloat(bound)), cumulative)
+		}
+		w.printf("%s_bucket%s %d\n", f.name, withLabel(s.labels, "le", "+Inf"), s.count)
+		w.printf("%s_sum%s %s\n", f.name, s.labels, formatFloat(s.value))
+		w.printf("%s_count%s %d\n", f.name, s.labels, s.count)
+	}
+}
+
+// formatLabels will render the labels sorted by name: {a="b",c="d"}
+func formatLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabel(labels[name])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel will add one more label to rendered labels (used for the le label)
+func withLabel(rendered, name, value string) string {
+	pair := name + `="` + value + `"`
+	if len(rendered) == 0 {
+		return "{" + pair + "}"
+	}
+	return strings.TrimSuffix(rendered, "}") + "," + pair + "}"
+}
+
+// formatFloat will render a value the way Prometheus expects
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeLabel will escape a label value (backslash, double-quote and line feed)
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// escapeHelp will escape a help text (backslash and line feed)
+func escapeHelp(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(value)
+}
+
+// countWriter keeps the number of bytes written and the first error
+type countWriter struct {
+	count  int64
+	err    error
+	writer *bufio.Writer
+}
+
+// printf will write a formatted line (skipped after the first error)
+func (c *countWriter) printf(format string, args ...interface{}) {
+	if c.err != nil {
+		return
+	}
+	var n int
+	n, c.err = fmt.Fprintf(c.writer, format, args...)
+	c.count += int64(n)
+}
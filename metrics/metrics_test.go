@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestRegistry_WriteTo will test the method WriteTo()
+func TestRegistry_WriteTo(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name     string
+		fill     func(r *Registry)
+		expected string
+	}{
+		{"empty", func(*Registry) {}, ""},
+		{"gauge", func(r *Registry) {
+			r.Set("up", "Whether it is up", Labels{"target": "b"}, 0)
+			r.Set("up", "Whether it is up", Labels{"target": "a"}, 1)
+			r.Set("up", "Whether it is up", Labels{"target": "a"}, 1)
+		}, `# HELP up Whether it is up
+# TYPE up gauge
+up{target="a"} 1
+up{target="b"} 0
+`},
+		{"counter without labels", func(r *Registry) {
+			r.Add("requests_total", "Requests made", nil, 1)
+			r.Add("requests_total", "Requests made", Labels{}, 2.5)
+		}, `# HELP requests_total Requests made
+# TYPE requests_total counter
+requests_total 3.5
+`},
+		{"sorted by name and labels", func(r *Registry) {
+			r.Set("b_metric", "B", Labels{"z": "1", "a": "2"}, 1)
+			r.Set("a_metric", "A", Labels{"x": "1"}, 1e-07)
+		}, `# HELP a_metric A
+# TYPE a_metric gauge
+a_metric{x="1"} 1e-07
+# HELP b_metric B
+# TYPE b_metric gauge
+b_metric{a="2",z="1"} 1
+`},
+		{"escaping", func(r *Registry) {
+			r.Set("escaped", "Help with \\ and\nnew line", Labels{"value": "a \"quoted\" \\ value\n"}, 1)
+		}, `# HELP escaped Help with \\ and\nnew line
+# TYPE escaped gauge
+escaped{value="a \"quoted\" \\ value\n"} 1
+`},
+		{"special values", func(r *Registry) {
+			r.Set("special", "Special", Labels{"v": "inf"}, math.Inf(1))
+			r.Set("special", "Special", Labels{"v": "nan"}, math.NaN())
+			r.Set("special", "Special", Labels{"v": "negative-inf"}, math.Inf(-1))
+		}, `# HELP special Special
+# TYPE special gauge
+special{v="inf"} +Inf
+special{v="nan"} NaN
+special{v="negative-inf"} -Inf
+`},
+		{"histogram", func(r *Registry) {
+			r.Observe("latency_seconds", "Latency", []float64{1, 0.1, 0.5}, Labels{"phase": "total"}, 0.05)
+			r.Observe("latency_seconds", "Latency", []float64{1, 0.1, 0.5}, Labels{"phase": "total"}, 0.5)
+			r.Observe("latency_seconds", "Latency", nil, Labels{"phase": "total"}, 3)
+		}, `# HELP latency_seconds Latency
+# TYPE latency_seconds histogram
+latency_seconds_bucket{phase="total",le="0.1"} 1
+latency_seconds_bucket{phase="total",le="0.5"} 2
+latency_seconds_bucket{phase="total",le="1"} 2
+latency_seconds_bucket{phase="total",le="+Inf"} 3
+latency_seconds_sum{phase="total"} 3.55
+latency_seconds_count{phase="total"} 3
+`},
+		{"histogram without labels", func(r *Registry) {
+			r.Observe("wait_seconds", "Wait", []float64{1}, nil, 2)
+		}, `# HELP wait_seconds Wait
+# TYPE wait_seconds histogram
+wait_seconds_bucket{le="1"} 0
+wait_seconds_bucket{le="+Inf"} 1
+wait_seconds_sum 2
+wait_seconds_count 1
+`},
+		{"deleted series", func(r *Registry) {
+			r.Set("up", "Up", Labels{"target": "a"}, 1)
+			r.Set("up", "Up", Labels{"target": "b"}, 1)
+			r.Delete("up", Labels{"target": "a"})
+			r.Delete("missing", Labels{"target": "a"})
+		}, `# HELP up Up
+# TYPE up gauge
+up{target="b"} 1
+`},
+	}
+
+	for _, test := range tests {
+		registry := NewRegistry()
+		test.fill(registry)
+
+		var buffer bytes.Buffer
+		count, err := registry.WriteTo(&buffer)
+		if err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.name, err.Error())
+		} else if output := buffer.String(); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expected, output)
+		} else if count != int64(buffer.Len()) {
+			t.Errorf("%s Failed: [%s] inputted and [%d] bytes expected, received: [%d]", t.Name(), test.name, buffer.Len(), count)
+		}
+	}
+}
+
+// TestRegistry_ServeHTTP will test the method ServeHTTP()
+func TestRegistry_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	registry.Set("up", "Up", nil, 1)
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != ContentType {
+		t.Errorf("%s Failed: [%s] content type expected, received: [%s]", t.Name(), ContentType, contentType)
+	}
+	if body := recorder.Body.String(); body != "# HELP up Up\n# TYPE up gauge\nup 1\n" {
+		t.Errorf("%s Failed: unexpected body: [%s]", t.Name(), body)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Package mock is a local bsvalias (paymail) provider used for offline development and testing
+*/
+package mock
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+	"go.yaml.in/yaml/v3"
+)
+
+// Defaults for the mock provider
+const (
+	DefaultDomain       = "paymail.test" // Used if no domain is set in the config
+	certificateLifetime = 24 * time.Hour // Self-signed certificates are only for one session
+	privateKeyHexLength = 64             // Length of a hex encoded private key
+)
+
+// Config is the mock provider configuration (loaded from a YAML file)
+type Config struct {
+	Aliases          []*Alias `yaml:"aliases"`           // All the known paymail aliases
+	Domain           string   `yaml:"domain"`            // The paymail domain (alias@domain)
+	SenderValidation bool     `yaml:"sender_validation"` // Enforce sender validation (6745385c3fc0)
+}
+
+// Alias is one paymail address on the mock provider
+type Alias struct {
+	Alias      string `yaml:"alias"`       // The alias (alias@domain)
+	Avatar     string `yaml:"avatar"`      // Public profile avatar url
+	Name       string `yaml:"name"`        // Public profile name
+	PrivateKey string `yaml:"private_key"` // WIF or hex (used for the pubkey, outputs and signatures)
+	PubKey     string `yaml:"pubkey"`      // Hex encoded compressed pubkey (only if no private key is set)
+
+	privateKey *ec.PrivateKey
+	publicKey  *ec.PublicKey
+}
+
+// LoadConfig will load and validate a config file
+func LoadConfig(path string) (config *Config, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil { //nolint:gosec // G304 - user supplied file
+		return nil, err
+	}
+	config = new(Config)
+	if err = yaml.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, config.Validate()
+}
+
+// Validate will check the config and load all the keys
+func (c *Config) Validate() (err error) {
+	if len(c.Domain) == 0 {
+		c.Domain = DefaultDomain
+	}
+	c.Domain = strings.ToLower(strings.TrimSpace(c.Domain))
+	if err = paymail.ValidateDomain(c.Domain); err != nil {
+		return err
+	}
+	if len(c.Aliases) == 0 {
+		return errors.New("at least one alias is required")
+	}
+
+	seen := make(map[string]bool)
+	for _, alias := range c.Aliases {
+		alias.Alias = strings.ToLower(strings.TrimSpace(alias.Alias))
+		if len(alias.Alias) == 0 {
+			return errors.New("alias is required")
+		} else if seen[alias.Alias] {
+			return fmt.Errorf("duplicate alias: %s", alias.Alias)
+		}
+		seen[alias.Alias] = true
+
+		if err = alias.loadKeys(); err != nil {
+			return fmt.Errorf("alias %s: %w", alias.Alias, err)
+		}
+	}
+	return nil
+}
+
+// GetAlias will return the alias if found (and the domain matches)
+func (c *Config) GetAlias(alias, domain string) *Alias {
+	if !strings.EqualFold(domain, c.Domain) {
+		return nil
+	}
+	alias = strings.ToLower(alias)
+	for _, a := range c.Aliases {
+		if a.Alias == alias {
+			return a
+		}
+	}
+	return nil
+}
+
+// loadKeys will parse the private key (or pubkey)
+func (a *Alias) loadKeys() (err error) {
+	if len(a.PrivateKey) > 0 {
+		if a.privateKey, err = parsePrivateKey(a.PrivateKey); err != nil {
+			return err
+		}
+		a.publicKey = a.privateKey.PubKey()
+		return nil
+	} else if len(a.PubKey) == 0 {
+		return errors.New("private_key or pubkey is required")
+	}
+	a.publicKey, err = ec.PublicKeyFromString(a.PubKey)
+	return err
+}
+
+// PubKeyHex returns the compressed pubkey (hex)
+func (a *Alias) PubKeyHex() string {
+	return hex.EncodeToString(a.publicKey.Compressed())
+}
+
+// Address returns the legacy address for the alias pubkey
+func (a *Alias) Address() (string, error) {
+	address, err := script.NewAddressFromPublicKey(a.publicKey, true)
+	if err != nil {
+		return "", err
+	}
+	return address.AddressString, nil
+}
+
+// OutputScript returns the hex encoded P2PKH locking script for the alias pubkey
+func (a *Alias) OutputScript() (string, error) {
+	address, err := script.NewAddressFromPublicKey(a.publicKey, true)
+	if err != nil {
+		return "", err
+	}
+	var lockingScript *script.Script
+	if lockingScript, err = p2pkh.Lock(address); err != nil {
+		return "", err
+	}
+	return lockingScript.String(), nil
+}
+
+// Sign will sign a message using the alias private key (empty if there is no private key)
+func (a *Alias) Sign(message string) (string, error) {
+	if a.privateKey == nil {
+		return "", nil
+	}
+	signature, err := bsm.SignMessage(a.privateKey, []byte(message))
+	if err != nil {
+		return "", err
+	}
+	return paymail.EncodeSignature(signature), nil
+}
+
+// parsePrivateKey will parse a WIF or hex private key
+func parsePrivateKey(key string) (*ec.PrivateKey, error) {
+	key = strings.TrimSpace(key)
+	if len(key) == privateKeyHexLength {
+		if _, err := hex.DecodeString(key); err == nil {
+			return ec.PrivateKeyFromHex(key)
+		}
+	}
+	return ec.PrivateKeyFromWif(key)
+}
+
+// SelfSignedCertificate will create a short-lived certificate for the given hosts (names or ips)
+func SelfSignedCertificate(hosts []string) (certificate tls.Certificate, err error) {
+	var key *ecdsa.PrivateKey
+	if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return certificate, err
+	}
+
+	var serial *big.Int
+	if serial, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128)); err != nil {
+		return certificate, err
+	}
+
+	template := &x509.Certificate{
+		BasicConstraintsValid: true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		NotAfter:              time.Now().Add(certificateLifetime),
+		NotBefore:             time.Now().Add(-time.Minute),
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: hosts[0], Organization: []string{"paymail-inspector mock"}},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	var der []byte
+	if der, err = x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key); err != nil {
+		return certificate, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
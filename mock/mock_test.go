@@ -0,0 +1,223 @@
+package mock
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Keys for local testing only (same as serve-example.yaml)
+const (
+	testKeyAlice   = "L1YwponZeY3juwv9RZ3DEszqQxbnCB1jg7H3Uzmk3RWiqbm8esbi"
+	testKeySatoshi = "L5Z8DRrx5ysuMJe3nZMhBTewrm4Z5egxemskvzKRWeFhkaMxKZRU"
+)
+
+// newTestConfig will create a valid config with two aliases (satoshi and alice)
+func newTestConfig(t *testing.T, senderValidation bool) *Config {
+	config := &Config{
+		Aliases: []*Alias{
+			{Alias: "satoshi", Avatar: "https://example.com/avatar.png", Name: "Satoshi Nakamoto", PrivateKey: testKeySatoshi},
+			{Alias: "alice", Name: "Alice", PrivateKey: testKeyAlice},
+		},
+		SenderValidation: senderValidation,
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("%s Failed: received an error validating the config: %s", t.Name(), err.Error())
+	}
+	return config
+}
+
+// TestConfig_Validate will test the method Validate()
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	pubKey := newTestConfig(t, false).Aliases[0].PubKeyHex()
+
+	var tests = []struct {
+		name           string
+		config         *Config
+		expectedDomain string
+		expectedError  string
+	}{
+		{"default domain", &Config{Aliases: []*Alias{{Alias: "satoshi", PrivateKey: testKeySatoshi}}}, DefaultDomain, ""},
+		{"domain is sanitized", &Config{Domain: " Example.COM ", Aliases: []*Alias{{Alias: "satoshi", PubKey: pubKey}}}, "example.com", ""},
+		{"invalid domain", &Config{Domain: "not a domain", Aliases: []*Alias{{Alias: "satoshi", PrivateKey: testKeySatoshi}}}, "not a domain", "domain"},
+		{"no aliases", &Config{}, DefaultDomain, "at least one alias is required"},
+		{"empty alias", &Config{Aliases: []*Alias{{Alias: " ", PrivateKey: testKeySatoshi}}}, DefaultDomain, "alias is required"},
+		{"duplicate alias", &Config{Aliases: []*Alias{
+			{Alias: "satoshi", PrivateKey: testKeySatoshi},
+			{Alias: "Satoshi", PrivateKey: testKeyAlice},
+		}}, DefaultDomain, "duplicate alias: satoshi"},
+		{"no keys", &Config{Aliases: []*Alias{{Alias: "satoshi"}}}, DefaultDomain, "alias satoshi: private_key or pubkey is required"},
+		{"invalid private key", &Config{Aliases: []*Alias{{Alias: "satoshi", PrivateKey: "invalid"}}}, DefaultDomain, "alias satoshi:"},
+		{"invalid pubkey", &Config{Aliases: []*Alias{{Alias: "satoshi", PubKey: "0201"}}}, DefaultDomain, "alias satoshi:"},
+	}
+
+	for _, test := range tests {
+		err := test.config.Validate()
+		if len(test.expectedError) == 0 && err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.name, err.Error())
+		} else if len(test.expectedError) > 0 && (err == nil || !strings.Contains(err.Error(), test.expectedError)) {
+			t.Errorf("%s Failed: [%s] inputted and error [%s] expected, received: [%v]", t.Name(), test.name, test.expectedError, err)
+		} else if test.config.Domain != test.expectedDomain {
+			t.Errorf("%s Failed: [%s] inputted and domain [%s] expected, received: [%s]", t.Name(), test.name, test.expectedDomain, test.config.Domain)
+		}
+	}
+}
+
+// TestLoadConfig will test the method LoadConfig()
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name          string
+		contents      string
+		expectedError bool
+	}{
+		{"valid", "domain: paymail.test\nsender_validation: true\naliases:\n  - alias: satoshi\n    private_key: " + testKeySatoshi + "\n", false},
+		{"invalid yaml", "aliases: [", true},
+		{"invalid config", "domain: paymail.test\n", true},
+	}
+
+	for _, test := range tests {
+		filename := filepath.Join(t.TempDir(), "serve.yaml")
+		if err := os.WriteFile(filename, []byte(test.contents), 0o600); err != nil {
+			t.Fatalf("%s Failed: error writing the config: %s", t.Name(), err.Error())
+		}
+		config, err := LoadConfig(filename)
+		if (err != nil) != test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted and error %v expected, received: [%v]", t.Name(), test.name, test.expectedError, err)
+		} else if !test.expectedError && (!config.SenderValidation || config.GetAlias("satoshi", "paymail.test") == nil) {
+			t.Errorf("%s Failed: [%s] inputted and the alias with sender validation expected, received: [%+v]", t.Name(), test.name, config)
+		}
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Errorf("%s Failed: an error expected for a missing file", t.Name())
+	}
+}
+
+// TestConfig_GetAlias will test the method GetAlias()
+func TestConfig_GetAlias(t *testing.T) {
+	t.Parallel()
+
+	config := newTestConfig(t, false)
+
+	var tests = []struct {
+		alias    string
+		domain   string
+		expected string
+	}{
+		{"satoshi", "paymail.test", "satoshi"},
+		{"Alice", "PAYMAIL.test", "alice"},
+		{"bob", "paymail.test", ""},
+		{"satoshi", "example.com", ""},
+	}
+
+	for _, test := range tests {
+		var output string
+		if alias := config.GetAlias(test.alias, test.domain); alias != nil {
+			output = alias.Alias
+		}
+		if output != test.expected {
+			t.Errorf("%s Failed: [%s@%s] inputted and [%s] expected, received: [%s]", t.Name(), test.alias, test.domain, test.expected, output)
+		}
+	}
+}
+
+// TestParsePrivateKey will test the method parsePrivateKey()
+func TestParsePrivateKey(t *testing.T) {
+	t.Parallel()
+
+	wif, err := parsePrivateKey(testKeySatoshi)
+	if err != nil {
+		t.Fatalf("%s Failed: [%s] inputted, received an error: %s", t.Name(), testKeySatoshi, err.Error())
+	}
+
+	var tests = []struct {
+		key           string
+		expectedError bool
+	}{
+		{testKeySatoshi, false},
+		{" " + testKeySatoshi + "\n", false},
+		{wif.Hex(), false},
+		{"zz" + wif.Hex()[2:], true},
+		{"invalid", true},
+		{"", true},
+	}
+
+	for _, test := range tests {
+		output, err := parsePrivateKey(test.key)
+		if (err != nil) != test.expectedError {
+			t.Errorf("%s Failed: [%s] inputted and error %v expected, received: [%v]", t.Name(), test.key, test.expectedError, err)
+		} else if !test.expectedError && output.Hex() != wif.Hex() {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.key, wif.Hex(), output.Hex())
+		}
+	}
+}
+
+// TestAlias_Sign will test the method Sign()
+func TestAlias_Sign(t *testing.T) {
+	t.Parallel()
+
+	config := newTestConfig(t, false)
+	pubKeyOnly := &Alias{Alias: "pubkey", PubKey: config.Aliases[0].PubKeyHex()}
+	if err := pubKeyOnly.loadKeys(); err != nil {
+		t.Fatalf("%s Failed: received an error loading the pubkey: %s", t.Name(), err.Error())
+	}
+
+	var tests = []struct {
+		name          string
+		alias         *Alias
+		expectedEmpty bool
+	}{
+		{"private key", config.Aliases[0], false},
+		{"pubkey only", pubKeyOnly, true},
+	}
+
+	for _, test := range tests {
+		signature, err := test.alias.Sign("message")
+		if err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.name, err.Error())
+		} else if (len(signature) == 0) != test.expectedEmpty {
+			t.Errorf("%s Failed: [%s] inputted and empty %v expected, received: [%s]", t.Name(), test.name, test.expectedEmpty, signature)
+		}
+	}
+}
+
+// TestSelfSignedCertificate will test the method SelfSignedCertificate()
+func TestSelfSignedCertificate(t *testing.T) {
+	t.Parallel()
+
+	certificate, err := SelfSignedCertificate([]string{"paymail.test", "localhost", "127.0.0.1", "::1"})
+	if err != nil {
+		t.Fatalf("%s Failed: received an error: %s", t.Name(), err.Error())
+	}
+	var leaf *x509.Certificate
+	if leaf, err = x509.ParseCertificate(certificate.Certificate[0]); err != nil {
+		t.Fatalf("%s Failed: received an error parsing: %s", t.Name(), err.Error())
+	}
+
+	var tests = []struct {
+		host     string
+		expected bool
+	}{
+		{"paymail.test", true},
+		{"localhost", true},
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"example.com", false},
+	}
+
+	for _, test := range tests {
+		if output := leaf.VerifyHostname(test.host) == nil; output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.host, test.expected, output)
+		}
+	}
+	if leaf.Subject.CommonName != "paymail.test" || leaf.NotAfter.Sub(leaf.NotBefore) > certificateLifetime+time.Minute {
+		t.Errorf("%s Failed: a short-lived certificate for paymail.test expected, received: [%s] %s to %s", t.Name(), leaf.Subject.CommonName, leaf.NotBefore, leaf.NotAfter)
+	}
+}
@@ -0,0 +1,283 @@
+package mock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/bsv-blockchain/go-paymail"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// Routes for the mock provider (all bsvalias endpoints)
+const (
+	routeCapabilities   = "/.well-known/" + paymail.DefaultServiceName
+	routeP2PDestination = "/v1/bsvalias/p2p-payment-destination/"
+	routePKI            = "/v1/bsvalias/id/"
+	routePublicProfile  = "/v1/bsvalias/public-profile/"
+	routeReceiveTx      = "/v1/bsvalias/receive-transaction/"
+	routeResolveAddress = "/v1/bsvalias/address/"
+	routeVerifyPubKey   = "/v1/bsvalias/verify-pubkey/"
+	templatePaymail     = "{alias}@{domain.tld}"
+)
+
+// Server is the mock bsvalias provider (implements http.Handler)
+type Server struct {
+	baseURL    string            // Base url used in the capabilities (https://host:port)
+	config     *Config           // Aliases and settings
+	mux        *http.ServeMux    // All the routes
+	mu         sync.Mutex        // Protects the references
+	references map[string]string // P2P references -> alias@domain (kept in memory)
+}
+
+// NewServer will create a new mock provider using the config and the public base url (https://host:port)
+func NewServer(config *Config, baseURL string) *Server {
+	s := &Server{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		config:     config,
+		mux:        http.NewServeMux(),
+		references: make(map[string]string),
+	}
+
+	s.mux.HandleFunc("GET "+routeCapabilities, s.capabilities)
+	s.mux.HandleFunc("GET "+routePKI+"{paymail}", s.pki)
+	s.mux.HandleFunc("GET "+routePublicProfile+"{paymail}", s.publicProfile)
+	s.mux.HandleFunc("POST "+routeResolveAddress+"{paymail}", s.resolveAddress)
+	s.mux.HandleFunc("POST "+routeP2PDestination+"{paymail}", s.p2pDestination)
+	s.mux.HandleFunc("POST "+routeReceiveTx+"{paymail}", s.receiveTransaction)
+	s.mux.HandleFunc("GET "+routeVerifyPubKey+"{paymail}/{pubkey}", s.verifyPubKey)
+
+	return s
+}
+
+// ServeHTTP will serve all the bsvalias routes
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Capabilities returns the capabilities document served by the mock provider
+func (s *Server) Capabilities() *paymail.CapabilitiesPayload {
+	return &paymail.CapabilitiesPayload{
+		BsvAlias: paymail.DefaultBsvAliasVersion,
+		Capabilities: map[string]interface{}{
+			paymail.BRFCPkiAlternate:          s.baseURL + routePKI + templatePaymail,
+			paymail.BRFCPaymentDestination:    s.baseURL + routeResolveAddress + templatePaymail,
+			paymail.BRFCP2PPaymentDestination: s.baseURL + routeP2PDestination + templatePaymail,
+			paymail.BRFCP2PTransactions:       s.baseURL + routeReceiveTx + templatePaymail,
+			paymail.BRFCPublicProfile:         s.baseURL + routePublicProfile + templatePaymail,
+			paymail.BRFCSenderValidation:      s.config.SenderValidation,
+			paymail.BRFCVerifyPublicKeyOwner:  s.baseURL + routeVerifyPubKey + templatePaymail + "/{pubkey}",
+		},
+	}
+}
+
+// capabilities will return the capabilities document
+func (s *Server) capabilities(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.Capabilities())
+}
+
+// pki will return the pubkey for the alias
+func (s *Server) pki(w http.ResponseWriter, r *http.Request) {
+	alias, handle := s.getAlias(w, r)
+	if alias == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, &paymail.PKIPayload{
+		BsvAlias: paymail.DefaultBsvAliasVersion,
+		Handle:   handle,
+		PubKey:   alias.PubKeyHex(),
+	})
+}
+
+// publicProfile will return the name and avatar for the alias
+func (s *Server) publicProfile(w http.ResponseWriter, r *http.Request) {
+	alias, _ := s.getAlias(w, r)
+	if alias == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, &paymail.PublicProfilePayload{
+		Avatar: alias.Avatar,
+		Name:   alias.Name,
+	})
+}
+
+// resolveAddress will return an output script for the alias (basic address resolution)
+func (s *Server) resolveAddress(w http.ResponseWriter, r *http.Request) {
+	alias, _ := s.getAlias(w, r)
+	if alias == nil {
+		return
+	}
+
+	// Decode the sender request
+	senderRequest := new(paymail.SenderRequest)
+	if err := json.NewDecoder(r.Body).Decode(senderRequest); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid-request", "invalid sender request: "+err.Error())
+		return
+	} else if len(senderRequest.SenderHandle) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid-sender-handle", "missing senderHandle")
+		return
+	} else if err = paymail.ValidateTimestamp(senderRequest.Dt); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid-dt", "invalid dt: "+err.Error())
+		return
+	}
+
+	// Sender validation (only local aliases can be verified offline)
+	if s.config.SenderValidation {
+		if code, message := s.verifySender(senderRequest); len(code) > 0 {
+			writeError(w, http.StatusUnauthorized, code, message)
+			return
+		}
+	}
+
+	output, err := alias.OutputScript()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "script-error", err.Error())
+		return
+	}
+	// Sign the output (only if the alias has a private key)
+	var signature string
+	if signature, err = alias.Sign(output); err != nil {
+		writeError(w, http.StatusInternalServerError, "signature-error", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, &paymail.ResolutionPayload{Output: output, Signature: signature})
+}
+
+// verifySender will check the signature of the sender request (returns an error code and message if invalid)
+func (s *Server) verifySender(senderRequest *paymail.SenderRequest) (code, message string) {
+	if len(senderRequest.Signature) == 0 {
+		return "missing-signature", "sender validation is enforced: missing signature"
+	}
+	alias, domain, _ := paymail.SanitizePaymail(senderRequest.SenderHandle)
+	sender := s.config.GetAlias(alias, domain)
+	if sender == nil {
+		return "unknown-sender", "sender validation is enforced: unknown sender " + senderRequest.SenderHandle
+	}
+	address, err := sender.Address()
+	if err != nil {
+		return "invalid-sender", err.Error()
+	}
+	if err = senderRequest.Verify(address, senderRequest.Signature); err != nil {
+		return "invalid-signature", "invalid signature: " + err.Error()
+	}
+	return "", ""
+}
+
+// p2pDestination will return an output and a new reference for the alias
+func (s *Server) p2pDestination(w http.ResponseWriter, r *http.Request) {
+	alias, handle := s.getAlias(w, r)
+	if alias == nil {
+		return
+	}
+
+	// Decode the payment request
+	paymentRequest := new(paymail.PaymentRequest)
+	if err := json.NewDecoder(r.Body).Decode(paymentRequest); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid-request", "invalid payment request: "+err.Error())
+		return
+	} else if paymentRequest.Satoshis == 0 {
+		writeError(w, http.StatusBadRequest, "invalid-satoshis", "satoshis is required")
+		return
+	}
+
+	output, err := alias.OutputScript()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "script-error", err.Error())
+		return
+	}
+
+	// Create a new reference
+	reference := make([]byte, 16)
+	if _, err = rand.Read(reference); err != nil {
+		writeError(w, http.StatusInternalServerError, "reference-error", err.Error())
+		return
+	}
+	s.mu.Lock()
+	s.references[hex.EncodeToString(reference)] = handle
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, &paymail.PaymentDestinationPayload{
+		Outputs:   []*paymail.PaymentOutput{{Satoshis: paymentRequest.Satoshis, Script: output}},
+		Reference: hex.EncodeToString(reference),
+	})
+}
+
+// receiveTransaction will accept a transaction for a reference from a prior p2p destination request
+func (s *Server) receiveTransaction(w http.ResponseWriter, r *http.Request) {
+	alias, handle := s.getAlias(w, r)
+	if alias == nil {
+		return
+	}
+
+	// Decode the transaction
+	p2pTransaction := new(paymail.P2PTransaction)
+	if err := json.NewDecoder(r.Body).Decode(p2pTransaction); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid-request", "invalid transaction request: "+err.Error())
+		return
+	} else if len(p2pTransaction.Hex) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid-hex", "hex is required")
+		return
+	}
+	tx, err := transaction.NewTransactionFromHex(p2pTransaction.Hex)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid-hex", "invalid transaction: "+err.Error())
+		return
+	}
+
+	// The reference can only be used once (and only for the same alias)
+	s.mu.Lock()
+	referenceHandle, ok := s.references[p2pTransaction.Reference]
+	if ok && referenceHandle == handle {
+		delete(s.references, p2pTransaction.Reference)
+	}
+	s.mu.Unlock()
+	if !ok || referenceHandle != handle {
+		writeError(w, http.StatusBadRequest, "invalid-reference", "reference not found: "+p2pTransaction.Reference)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &paymail.P2PTransactionPayload{
+		Note: "received by the mock provider (not broadcast)",
+		TxID: tx.TxID().String(),
+	})
+}
+
+// verifyPubKey will check if the pubkey belongs to the alias
+func (s *Server) verifyPubKey(w http.ResponseWriter, r *http.Request) {
+	alias, handle := s.getAlias(w, r)
+	if alias == nil {
+		return
+	}
+	pubKey := r.PathValue("pubkey")
+	writeJSON(w, http.StatusOK, &paymail.VerificationPayload{
+		BsvAlias: paymail.DefaultBsvAliasVersion,
+		Handle:   handle,
+		Match:    strings.EqualFold(pubKey, alias.PubKeyHex()),
+		PubKey:   pubKey,
+	})
+}
+
+// getAlias will find the alias from the request path (writes a not-found error if missing)
+func (s *Server) getAlias(w http.ResponseWriter, r *http.Request) (*Alias, string) {
+	alias, domain, address := paymail.SanitizePaymail(r.PathValue("paymail"))
+	if found := s.config.GetAlias(alias, domain); found != nil {
+		return found, address
+	}
+	writeError(w, http.StatusNotFound, "not-found", "Paymail not found: "+address)
+	return nil, address
+}
+
+// writeJSON will write a JSON response
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// writeError will write a standard paymail error response
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, &paymail.ServerError{Code: code, Message: message})
+}
@@ -0,0 +1,217 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-paymail"
+	bsm "github.com/bsv-blockchain/go-sdk/compat/bsm"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// testBaseURL is the public url of the test provider
+const testBaseURL = "https://paymail.test:3443"
+
+// serve will send the request to the mock provider and decode the JSON response (if any)
+func serve(t *testing.T, server *Server, method, path, body string, response interface{}) int {
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if response != nil && recorder.Header().Get("Content-Type") == "application/json" {
+		if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+			t.Fatalf("%s Failed: [%s %s] inputted, received an invalid response: %s", t.Name(), method, path, recorder.Body.String())
+		}
+	}
+	return recorder.Code
+}
+
+// senderRequestBody will create a sender request (signed by the signer alias if set)
+func senderRequestBody(t *testing.T, senderHandle string, signer *Alias, dt string) string {
+	senderRequest := &paymail.SenderRequest{Dt: dt, SenderHandle: senderHandle, SenderName: "Test"}
+	if signer != nil {
+		signature, err := bsm.SignMessage(signer.privateKey, []byte(
+			fmt.Sprintf("%s%d%s%s", senderRequest.SenderHandle, senderRequest.Amount, senderRequest.Dt, senderRequest.Purpose),
+		))
+		if err != nil {
+			t.Fatalf("%s Failed: received an error signing: %s", t.Name(), err.Error())
+		}
+		senderRequest.Signature = paymail.EncodeSignature(signature)
+	}
+	data, _ := json.Marshal(senderRequest)
+	return string(data)
+}
+
+// TestServer_Capabilities will test the method Capabilities()
+func TestServer_Capabilities(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		senderValidation bool
+	}{
+		{false},
+		{true},
+	}
+
+	for _, test := range tests {
+		server := NewServer(newTestConfig(t, test.senderValidation), testBaseURL+"/")
+
+		capabilities := new(paymail.CapabilitiesPayload)
+		if status := serve(t, server, http.MethodGet, "/.well-known/bsvalias", "", capabilities); status != http.StatusOK {
+			t.Fatalf("%s Failed: [%v] inputted and [%d] expected, received: [%d]", t.Name(), test.senderValidation, http.StatusOK, status)
+		}
+		if capabilities.BsvAlias != paymail.DefaultBsvAliasVersion || len(capabilities.Capabilities) != 7 {
+			t.Errorf("%s Failed: [%v] inputted and 7 capabilities expected, received: [%+v]", t.Name(), test.senderValidation, capabilities)
+		}
+		if pki := capabilities.Capabilities[paymail.BRFCPkiAlternate]; pki != testBaseURL+"/v1/bsvalias/id/{alias}@{domain.tld}" {
+			t.Errorf("%s Failed: [%v] inputted and the pki url expected, received: [%v]", t.Name(), test.senderValidation, pki)
+		}
+		if output := capabilities.Capabilities[paymail.BRFCSenderValidation]; output != test.senderValidation {
+			t.Errorf("%s Failed: [%v] inputted and sender validation [%v] expected, received: [%v]", t.Name(), test.senderValidation, test.senderValidation, output)
+		}
+	}
+}
+
+// TestServer_lookups will test the methods pki(), publicProfile() and verifyPubKey()
+func TestServer_lookups(t *testing.T) {
+	t.Parallel()
+
+	config := newTestConfig(t, false)
+	server := NewServer(config, testBaseURL)
+	pubKey := config.Aliases[0].PubKeyHex()
+
+	var tests = []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expected       string // Field=value expected in the response
+	}{
+		{"pki", "/v1/bsvalias/id/satoshi@paymail.test", http.StatusOK, "pubkey=" + pubKey},
+		{"pki handle is sanitized", "/v1/bsvalias/id/Satoshi@Paymail.test", http.StatusOK, "handle=satoshi@paymail.test"},
+		{"pki unknown alias", "/v1/bsvalias/id/bob@paymail.test", http.StatusNotFound, "code=not-found"},
+		{"pki unknown domain", "/v1/bsvalias/id/satoshi@example.com", http.StatusNotFound, "message=Paymail not found: satoshi@example.com"},
+		{"public profile", "/v1/bsvalias/public-profile/satoshi@paymail.test", http.StatusOK, "name=Satoshi Nakamoto"},
+		{"public profile avatar", "/v1/bsvalias/public-profile/satoshi@paymail.test", http.StatusOK, "avatar=https://example.com/avatar.png"},
+		{"public profile unknown alias", "/v1/bsvalias/public-profile/bob@paymail.test", http.StatusNotFound, "code=not-found"},
+		{"verify pubkey match", "/v1/bsvalias/verify-pubkey/satoshi@paymail.test/" + strings.ToUpper(pubKey), http.StatusOK, "match=true"},
+		{"verify pubkey other alias", "/v1/bsvalias/verify-pubkey/alice@paymail.test/" + pubKey, http.StatusOK, "match=false"},
+		{"verify pubkey unknown alias", "/v1/bsvalias/verify-pubkey/bob@paymail.test/" + pubKey, http.StatusNotFound, "code=not-found"},
+		{"unknown route", "/v1/bsvalias/unknown/satoshi@paymail.test", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		response := make(map[string]interface{})
+		status := serve(t, server, http.MethodGet, test.path, "", &response)
+		if status != test.expectedStatus {
+			t.Errorf("%s Failed: [%s] inputted and [%d] expected, received: [%d]", t.Name(), test.name, test.expectedStatus, status)
+		} else if field, value, ok := strings.Cut(test.expected, "="); ok && fmt.Sprint(response[field]) != value {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%v]", t.Name(), test.name, test.expected, response[field])
+		}
+	}
+}
+
+// TestServer_resolveAddress will test the method resolveAddress() (and the sender validation)
+func TestServer_resolveAddress(t *testing.T) {
+	t.Parallel()
+
+	config := newTestConfig(t, true)
+	satoshi := config.GetAlias("satoshi", DefaultDomain)
+	alice := config.GetAlias("alice", DefaultDomain)
+	now := time.Now().UTC().Format(time.RFC3339)
+	output, _ := satoshi.OutputScript()
+
+	var tests = []struct {
+		name             string
+		senderValidation bool
+		path             string
+		body             string
+		expectedStatus   int
+		expectedCode     string
+	}{
+		{"no sender validation", false, "/v1/bsvalias/address/satoshi@paymail.test",
+			senderRequestBody(t, "someone@example.com", nil, now), http.StatusOK, ""},
+		{"signed by a local alias", true, "/v1/bsvalias/address/satoshi@paymail.test",
+			senderRequestBody(t, "alice@paymail.test", alice, now), http.StatusOK, ""},
+		{"missing signature", true, "/v1/bsvalias/address/satoshi@paymail.test",
+			senderRequestBody(t, "alice@paymail.test", nil, now), http.StatusUnauthorized, "missing-signature"},
+		{"unknown sender", true, "/v1/bsvalias/address/satoshi@paymail.test",
+			senderRequestBody(t, "someone@example.com", alice, now), http.StatusUnauthorized, "unknown-sender"},
+		{"signed by another alias", true, "/v1/bsvalias/address/satoshi@paymail.test",
+			senderRequestBody(t, "alice@paymail.test", satoshi, now), http.StatusUnauthorized, "invalid-signature"},
+		{"invalid json", false, "/v1/bsvalias/address/satoshi@paymail.test", "{", http.StatusBadRequest, "invalid-request"},
+		{"missing sender handle", false, "/v1/bsvalias/address/satoshi@paymail.test",
+			senderRequestBody(t, "", nil, now), http.StatusBadRequest, "invalid-sender-handle"},
+		{"invalid dt", false, "/v1/bsvalias/address/satoshi@paymail.test",
+			senderRequestBody(t, "alice@paymail.test", nil, "yesterday"), http.StatusBadRequest, "invalid-dt"},
+		{"unknown alias", false, "/v1/bsvalias/address/bob@paymail.test",
+			senderRequestBody(t, "alice@paymail.test", nil, now), http.StatusNotFound, "not-found"},
+	}
+
+	for _, test := range tests {
+		config.SenderValidation = test.senderValidation
+		server := NewServer(config, testBaseURL)
+
+		response := make(map[string]interface{})
+		status := serve(t, server, http.MethodPost, test.path, test.body, &response)
+		code, _ := response["code"].(string)
+		if status != test.expectedStatus || code != test.expectedCode {
+			t.Errorf("%s Failed: [%s] inputted and [%d] [%s] expected, received: [%d] %v", t.Name(), test.name, test.expectedStatus, test.expectedCode, status, response)
+		} else if status == http.StatusOK && (response["output"] != output || len(fmt.Sprint(response["signature"])) == 0) {
+			t.Errorf("%s Failed: [%s] inputted and a signed output expected, received: %v", t.Name(), test.name, response)
+		}
+	}
+}
+
+// TestServer_p2p will test the methods p2pDestination() and receiveTransaction()
+func TestServer_p2p(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer(newTestConfig(t, false), testBaseURL)
+	txHex := transaction.NewTransaction().Hex()
+
+	// Get a reference for satoshi
+	destination := new(paymail.PaymentDestinationPayload)
+	if status := serve(t, server, http.MethodPost, "/v1/bsvalias/p2p-payment-destination/satoshi@paymail.test", `{"satoshis":1000}`, destination); status != http.StatusOK {
+		t.Fatalf("%s Failed: [%d] expected, received: [%d]", t.Name(), http.StatusOK, status)
+	}
+	if len(destination.Reference) != 32 || len(destination.Outputs) != 1 || destination.Outputs[0].Satoshis != 1000 {
+		t.Fatalf("%s Failed: a reference and one output expected, received: [%+v]", t.Name(), destination)
+	}
+
+	transactionBody := func(hex, reference string) string {
+		data, _ := json.Marshal(&paymail.P2PTransaction{Hex: hex, Reference: reference})
+		return string(data)
+	}
+
+	var tests = []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"destination without satoshis", "/v1/bsvalias/p2p-payment-destination/satoshi@paymail.test", `{"satoshis":0}`, http.StatusBadRequest, "invalid-satoshis"},
+		{"destination invalid json", "/v1/bsvalias/p2p-payment-destination/satoshi@paymail.test", "{", http.StatusBadRequest, "invalid-request"},
+		{"destination unknown alias", "/v1/bsvalias/p2p-payment-destination/bob@paymail.test", `{"satoshis":1}`, http.StatusNotFound, "not-found"},
+		{"transaction without hex", "/v1/bsvalias/receive-transaction/satoshi@paymail.test", transactionBody("", destination.Reference), http.StatusBadRequest, "invalid-hex"},
+		{"transaction invalid hex", "/v1/bsvalias/receive-transaction/satoshi@paymail.test", transactionBody("zz", destination.Reference), http.StatusBadRequest, "invalid-hex"},
+		{"reference of another alias", "/v1/bsvalias/receive-transaction/alice@paymail.test", transactionBody(txHex, destination.Reference), http.StatusBadRequest, "invalid-reference"},
+		{"unknown reference", "/v1/bsvalias/receive-transaction/satoshi@paymail.test", transactionBody(txHex, "unknown"), http.StatusBadRequest, "invalid-reference"},
+		{"transaction received", "/v1/bsvalias/receive-transaction/satoshi@paymail.test", transactionBody(txHex, destination.Reference), http.StatusOK, ""},
+		{"reference is used once", "/v1/bsvalias/receive-transaction/satoshi@paymail.test", transactionBody(txHex, destination.Reference), http.StatusBadRequest, "invalid-reference"},
+	}
+
+	for _, test := range tests {
+		response := make(map[string]interface{})
+		status := serve(t, server, http.MethodPost, test.path, test.body, &response)
+		code, _ := response["code"].(string)
+		if status != test.expectedStatus || code != test.expectedCode {
+			t.Errorf("%s Failed: [%s] inputted and [%d] [%s] expected, received: [%d] %v", t.Name(), test.name, test.expectedStatus, test.expectedCode, status, response)
+		} else if status == http.StatusOK && response["txid"] != transaction.NewTransaction().TxID().String() {
+			t.Errorf("%s Failed: [%s] inputted and the txid expected, received: %v", t.Name(), test.name, response)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Package retry is the retry policy (exponential backoff with jitter and Retry-After) shared by all resty clients
+*/
+package retry
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Defaults for the retry policy
+const (
+	DefaultAttempts = 3                      // Total attempts per request (2 retries)
+	DefaultMaxWait  = 10 * time.Second       // Longest wait between attempts (also the limit for Retry-After)
+	DefaultMinWait  = 250 * time.Millisecond // First wait between attempts (doubles each attempt, with jitter)
+)
+
+// transientStatusCodes are the status codes worth another attempt
+var transientStatusCodes = map[int]bool{
+	http.StatusRequestTimeout:      true,
+	http.StatusTooEarly:            true,
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// idempotentMethods are the only methods that are retried
+var idempotentMethods = map[string]bool{
+	http.MethodDelete:  true,
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodTrace:   true,
+}
+
+// Policy is how often and how long to wait before retrying a failed request
+type Policy struct {
+	Attempts int           // Total attempts per request (1 = no retries)
+	MaxWait  time.Duration // Longest wait between attempts (also the limit for Retry-After)
+	MinWait  time.Duration // First wait between attempts (doubles each attempt, with jitter)
+}
+
+// Attempt is a failed attempt of a request that is being retried
+type Attempt struct {
+	Err        error           `json:"-"`           // Error of the attempt (if any)
+	Number     int             `json:"number"`      // Attempt number (starts at 1)
+	StatusCode int             `json:"status_code"` // Status code returned on the attempt (0 on a network error)
+	Tracing    resty.TraceInfo `json:"-"`           // Trace information if enabled on the request
+	URL        string          `json:"url"`         // URL of the request
+}
+
+// Apply will set the policy on the client, onRetry (optional) is called for each failed attempt before it's retried
+func (p Policy) Apply(client *resty.Client, onRetry func(attempt *Attempt)) *resty.Client {
+	if p.Attempts < 1 {
+		p.Attempts = 1
+	}
+	if p.MinWait <= 0 {
+		p.MinWait = DefaultMinWait
+	}
+	if p.MaxWait < p.MinWait {
+		p.MaxWait = DefaultMaxWait
+	}
+	retries := p.Attempts - 1
+
+	client.
+		SetRetryCount(retries).
+		SetRetryWaitTime(p.MinWait).
+		SetRetryMaxWaitTime(p.MaxWait).
+		SetRetryAfter(RetryAfter).
+		AddRetryCondition(Transient)
+
+	if onRetry != nil {
+		client.AddRetryHook(func(resp *resty.Response, err error) {
+			// The hook also runs after the last attempt (that one is returned to the caller)
+			if resp == nil || resp.Request == nil || resp.Request.Attempt > retries {
+				return
+			}
+			onRetry(&Attempt{
+				Err:        err,
+				Number:     resp.Request.Attempt,
+				StatusCode: resp.StatusCode(),
+				Tracing:    resp.Request.TraceInfo(),
+				URL:        resp.Request.URL,
+			})
+		})
+	}
+	return client
+}
+
+// Transient returns true if the request is idempotent and failed with a network error or a transient status code
+func Transient(resp *resty.Response, err error) bool {
+	if resp == nil || resp.Request == nil || !idempotentMethods[strings.ToUpper(resp.Request.Method)] {
+		return false
+	} else if err != nil {
+		var certErr *tls.CertificateVerificationError
+		return !errors.As(err, &certErr) // A bad certificate will not fix itself
+	}
+	return transientStatusCodes[resp.StatusCode()]
+}
+
+// RetryAfter returns the wait from the Retry-After header on a 429 or 503 response (0 uses the backoff)
+func RetryAfter(_ *resty.Client, resp *resty.Response) (time.Duration, error) {
+	if resp == nil || (resp.StatusCode() != http.StatusTooManyRequests && resp.StatusCode() != http.StatusServiceUnavailable) {
+		return 0, nil
+	}
+	return ParseRetryAfter(resp.Header().Get("Retry-After"), time.Now()), nil
+}
+
+// ParseRetryAfter returns the wait from a Retry-After value (seconds or an HTTP date), 0 if missing or invalid
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if len(value) == 0 {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
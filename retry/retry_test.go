@@ -0,0 +1,119 @@
+package retry
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// TestParseRetryAfter will test the method ParseRetryAfter()
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var tests = []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{"empty", "", 0},
+		{"spaces", "   ", 0},
+		{"seconds", "120", 120 * time.Second},
+		{"seconds with spaces", " 5 ", 5 * time.Second},
+		{"zero seconds", "0", 0},
+		{"negative seconds", "-10", 0},
+		{"http date", now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{"http date in the past", now.Add(-30 * time.Second).Format(http.TimeFormat), 0},
+		{"http date now", now.Format(http.TimeFormat), 0},
+		{"invalid", "soon", 0},
+		{"fraction", "1.5", 0},
+	}
+
+	for _, test := range tests {
+		if output := ParseRetryAfter(test.value, now); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
+
+// TestTransient will test the method Transient()
+func TestTransient(t *testing.T) {
+	t.Parallel()
+
+	response := func(method string, statusCode int) *resty.Response {
+		return &resty.Response{
+			RawResponse: &http.Response{StatusCode: statusCode},
+			Request:     &resty.Request{Method: method},
+		}
+	}
+
+	var tests = []struct {
+		name     string
+		response *resty.Response
+		err      error
+		expected bool
+	}{
+		{"no response", nil, errors.New("failed"), false},
+		{"no request", &resty.Response{}, errors.New("failed"), false},
+		{"get network error", response(http.MethodGet, 0), errors.New("connection reset"), true},
+		{"get certificate error", response(http.MethodGet, 0), fmt.Errorf("get: %w", &tls.CertificateVerificationError{Err: errors.New("unknown authority")}), false},
+		{"post network error", response(http.MethodPost, 0), errors.New("connection reset"), false},
+		{"get 200", response(http.MethodGet, http.StatusOK), nil, false},
+		{"get 404", response(http.MethodGet, http.StatusNotFound), nil, false},
+		{"get 408", response(http.MethodGet, http.StatusRequestTimeout), nil, true},
+		{"get 429", response(http.MethodGet, http.StatusTooManyRequests), nil, true},
+		{"get 500", response(http.MethodGet, http.StatusInternalServerError), nil, true},
+		{"get 501", response(http.MethodGet, http.StatusNotImplemented), nil, false},
+		{"get 503", response(http.MethodGet, http.StatusServiceUnavailable), nil, true},
+		{"lowercase get 502", response("get", http.StatusBadGateway), nil, true},
+		{"put 504", response(http.MethodPut, http.StatusGatewayTimeout), nil, true},
+		{"post 503", response(http.MethodPost, http.StatusServiceUnavailable), nil, false},
+	}
+
+	for _, test := range tests {
+		if output := Transient(test.response, test.err); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%v] expected, received: [%v]", t.Name(), test.name, test.expected, output)
+		}
+	}
+}
+
+// TestRetryAfter will test the method RetryAfter()
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name       string
+		statusCode int
+		header     string
+		expected   time.Duration
+	}{
+		{"429 with seconds", http.StatusTooManyRequests, "3", 3 * time.Second},
+		{"503 with seconds", http.StatusServiceUnavailable, "7", 7 * time.Second},
+		{"503 without header", http.StatusServiceUnavailable, "", 0},
+		{"500 with seconds", http.StatusInternalServerError, "3", 0},
+	}
+
+	for _, test := range tests {
+		raw := &http.Response{Header: http.Header{}, StatusCode: test.statusCode}
+		if len(test.header) > 0 {
+			raw.Header.Set("Retry-After", test.header)
+		}
+		output, err := RetryAfter(nil, &resty.Response{RawResponse: raw})
+		if err != nil {
+			t.Errorf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.name, err.Error())
+		} else if output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.name, test.expected, output)
+		}
+	}
+
+	// No response uses the backoff
+	if output, _ := RetryAfter(nil, nil); output != 0 {
+		t.Errorf("%s Failed: [nil] inputted and [0s] expected, received: [%s]", t.Name(), output)
+	}
+}
@@ -0,0 +1,344 @@
+/*
+Package tlsreport connects to a paymail host and inspects the TLS connection (certificate chain, issuer, SANs,
+expiry, TLS versions and cipher, OCSP stapling and HSTS)
+*/
+package tlsreport
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// Defaults for the client
+const (
+	DefaultExpiryError   = 7 * 24 * time.Hour  // A certificate expiring within this is an error
+	DefaultExpiryWarning = 30 * 24 * time.Hour // A certificate expiring within this is a warning
+	DefaultPort          = 443                 // Port of the host (if not set)
+	DefaultTimeout       = 10 * time.Second    // Time allowed for one connection
+	hstsPath             = "/.well-known/bsvalias"
+)
+
+// Levels of an issue
+const (
+	LevelError = "error"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+)
+
+// Status of a stapled OCSP response
+const (
+	OCSPGood    = "good"
+	OCSPRevoked = "revoked"
+	OCSPUnknown = "unknown"
+)
+
+// versions are the TLS versions probed (oldest first)
+var versions = []uint16{tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13}
+
+// Client connects to the host
+type Client struct {
+	Insecure bool           // An untrusted chain (IE: a self-signed certificate) is a warning instead of an error
+	Roots    *x509.CertPool // Trusted roots (nil uses the system roots)
+	Timeout  time.Duration  // Time allowed for one connection
+}
+
+// Certificate is one certificate of the chain (as sent by the host)
+type Certificate struct {
+	DaysLeft           int       `json:"days_left"`
+	DNSNames           []string  `json:"dns_names,omitempty"`
+	IPAddresses        []string  `json:"ip_addresses,omitempty"`
+	IsCA               bool      `json:"is_ca"`
+	Issuer             string    `json:"issuer"`
+	NotAfter           time.Time `json:"not_after"`
+	NotBefore          time.Time `json:"not_before"`
+	SerialNumber       string    `json:"serial_number"`
+	SignatureAlgorithm string    `json:"signature_algorithm"`
+	Subject            string    `json:"subject"`
+}
+
+// Issue is a problem (or a note) found in the TLS connection
+type Issue struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// OCSP is the stapled OCSP response
+type OCSP struct {
+	Error      string    `json:"error,omitempty"`
+	NextUpdate time.Time `json:"next_update,omitempty"`
+	Stapled    bool      `json:"stapled"`
+	Status     string    `json:"status,omitempty"`
+}
+
+// Report is the TLS connection of a host and the issues found
+type Report struct {
+	Certificates []*Certificate `json:"certificates"`
+	CipherSuite  string         `json:"cipher_suite"`
+	Covered      bool           `json:"covered"` // The certificate covers the host (SANs)
+	HSTS         string         `json:"hsts,omitempty"`
+	Host         string         `json:"host"`
+	Issues       []*Issue       `json:"issues"`
+	OCSP         *OCSP          `json:"ocsp"`
+	Port         int            `json:"port"`
+	Trusted      bool           `json:"trusted"` // The chain verifies to a trusted root
+	Valid        bool           `json:"valid"`   // Trusted (or insecure), covers the host and not expiring
+	Version      string         `json:"version"`
+	Versions     []string       `json:"versions"` // All versions the host accepts
+}
+
+// Inspect will connect to the host and check the certificate chain, versions, OCSP stapling and HSTS
+//
+// Certificates expiring within expiryError are an error, within expiryWarning a warning (0 uses the defaults)
+func (c *Client) Inspect(ctx context.Context, host string, port int, expiryWarning, expiryError time.Duration, now time.Time) (*Report, error) {
+	if port <= 0 {
+		port = DefaultPort
+	}
+	if expiryWarning <= 0 {
+		expiryWarning = DefaultExpiryWarning
+	}
+	if expiryError <= 0 {
+		expiryError = DefaultExpiryError
+	}
+	report := &Report{Certificates: []*Certificate{}, Host: host, Issues: []*Issue{}, Port: port, Versions: []string{}}
+
+	// Connect (the chain is verified below, so it can be shown even if it's not trusted)
+	conn, err := c.dial(ctx, host, port, 0)
+	if err != nil {
+		return report, err
+	}
+	state := conn.ConnectionState()
+	_ = conn.Close()
+	report.Version = tls.VersionName(state.Version)
+	report.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	if len(state.PeerCertificates) == 0 {
+		report.addIssue(LevelError, "no certificate sent by the host")
+		return report, nil
+	}
+
+	// The chain (as sent)
+	for _, cert := range state.PeerCertificates {
+		report.Certificates = append(report.Certificates, newCertificate(cert, now))
+	}
+	leaf := state.PeerCertificates[0]
+
+	// Trusted? (a self-signed certificate is only a warning with insecure)
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err = leaf.Verify(x509.VerifyOptions{CurrentTime: now, Intermediates: intermediates, Roots: c.Roots}); err != nil {
+		level := LevelError
+		if c.Insecure {
+			level = LevelWarn
+		}
+		report.addIssue(level, fmt.Sprintf("the chain is not trusted: %s", err.Error()))
+	} else {
+		report.Trusted = true
+	}
+
+	// Covers the host?
+	if err = leaf.VerifyHostname(host); err != nil {
+		report.addIssue(LevelError, fmt.Sprintf("the certificate does not cover %s (SANs: %s)", host, strings.Join(leaf.DNSNames, ", ")))
+	} else {
+		report.Covered = true
+	}
+
+	// Expiry (every certificate in the chain, expiring within expiryError fails the check)
+	expired := false
+	for index, cert := range state.PeerCertificates {
+		name := "the certificate"
+		if index > 0 {
+			name = fmt.Sprintf("the issuer certificate (%s)", cert.Subject.CommonName)
+		}
+		left := cert.NotAfter.Sub(now)
+		switch {
+		case now.Before(cert.NotBefore):
+			expired = true
+			report.addIssue(LevelError, fmt.Sprintf("%s is not valid until %s", name, cert.NotBefore.Format(time.RFC3339)))
+		case left <= 0:
+			expired = true
+			report.addIssue(LevelError, fmt.Sprintf("%s expired on %s", name, cert.NotAfter.Format(time.RFC3339)))
+		case left < expiryError:
+			expired = true
+			report.addIssue(LevelError, fmt.Sprintf("%s expires in %s on %s", name, expiresIn(left), cert.NotAfter.Format(time.RFC3339)))
+		case left < expiryWarning:
+			report.addIssue(LevelWarn, fmt.Sprintf("%s expires in %s on %s", name, expiresIn(left), cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+	report.Valid = (report.Trusted || c.Insecure) && report.Covered && !expired
+
+	// OCSP stapling
+	report.checkOCSP(state, now)
+
+	// Versions accepted by the host (old versions are flagged)
+	if err = c.checkVersions(ctx, report); err != nil {
+		return report, err
+	}
+
+	// HSTS
+	if err = c.checkHSTS(ctx, report); err != nil {
+		return report, err
+	}
+
+	return report, nil
+}
+
+// dial will open a TLS connection to the host (version 0 allows all versions the client supports)
+func (c *Client) dial(ctx context.Context, host string, port int, version uint16) (*tls.Conn, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	config := &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec // G402 - the chain is verified in Inspect (and reported)
+		ServerName:         host,
+	}
+	if version > 0 {
+		config.MinVersion, config.MaxVersion = version, version
+	}
+	dialer := &tls.Dialer{Config: config, NetDialer: &net.Dialer{Timeout: timeout}}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	return conn.(*tls.Conn), nil
+}
+
+// checkVersions will connect once per TLS version (versions before TLS 1.2 are flagged)
+func (c *Client) checkVersions(ctx context.Context, report *Report) error {
+	for _, version := range versions {
+		conn, err := c.dial(ctx, report.Host, report.Port, version)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		_ = conn.Close()
+		report.Versions = append(report.Versions, tls.VersionName(version))
+		if version < tls.VersionTLS12 {
+			report.addIssue(LevelWarn, fmt.Sprintf("%s is accepted (deprecated, RFC 8996)", tls.VersionName(version)))
+		}
+	}
+	return nil
+}
+
+// checkHSTS will look for the Strict-Transport-Security header on the capabilities path
+func (c *Client) checkHSTS(ctx context.Context, report *Report) error {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		Timeout:       timeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true, //nolint:gosec // G402 - the chain is verified in Inspect (and reported)
+			ServerName:         report.Host,
+		}},
+	}
+	url := "https://" + net.JoinHostPort(report.Host, strconv.Itoa(report.Port)) + hstsPath
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		report.addIssue(LevelWarn, fmt.Sprintf("HSTS could not be checked: %s", err.Error()))
+		return nil
+	}
+	_ = response.Body.Close()
+
+	if report.HSTS = response.Header.Get("Strict-Transport-Security"); len(report.HSTS) == 0 {
+		report.addIssue(LevelWarn, "no HSTS header (Strict-Transport-Security)")
+	}
+	return nil
+}
+
+// checkOCSP will check the stapled OCSP response (if any) against the certificate
+func (r *Report) checkOCSP(state tls.ConnectionState, now time.Time) {
+	r.OCSP = &OCSP{Stapled: len(state.OCSPResponse) > 0}
+	if !r.OCSP.Stapled {
+		r.addIssue(LevelInfo, "no OCSP response stapled (clients have to ask the CA)")
+		return
+	}
+
+	var issuer *x509.Certificate
+	if len(state.PeerCertificates) > 1 {
+		issuer = state.PeerCertificates[1]
+	}
+	response, err := ocsp.ParseResponseForCert(state.OCSPResponse, state.PeerCertificates[0], issuer)
+	if err != nil {
+		r.OCSP.Error = err.Error()
+		r.addIssue(LevelWarn, fmt.Sprintf("the stapled OCSP response is invalid: %s", err.Error()))
+		return
+	}
+	r.OCSP.NextUpdate = response.NextUpdate
+	switch response.Status {
+	case ocsp.Good:
+		r.OCSP.Status = OCSPGood
+		if !response.NextUpdate.IsZero() && now.After(response.NextUpdate) {
+			r.addIssue(LevelWarn, fmt.Sprintf("the stapled OCSP response is stale (next update was %s)", response.NextUpdate.Format(time.RFC3339)))
+		}
+	case ocsp.Revoked:
+		r.OCSP.Status = OCSPRevoked
+		r.Valid = false
+		r.addIssue(LevelError, fmt.Sprintf("the certificate was revoked on %s", response.RevokedAt.Format(time.RFC3339)))
+	default:
+		r.OCSP.Status = OCSPUnknown
+		r.addIssue(LevelWarn, "the stapled OCSP response has an unknown status")
+	}
+}
+
+// addIssue will add an issue to the report
+func (r *Report) addIssue(level, message string) {
+	r.Issues = append(r.Issues, &Issue{Level: level, Message: message})
+}
+
+// newCertificate will convert a certificate
+func newCertificate(cert *x509.Certificate, now time.Time) *Certificate {
+	certificate := &Certificate{
+		DaysLeft:           daysLeft(cert, now),
+		DNSNames:           cert.DNSNames,
+		IsCA:               cert.IsCA,
+		Issuer:             cert.Issuer.String(),
+		NotAfter:           cert.NotAfter.UTC(),
+		NotBefore:          cert.NotBefore.UTC(),
+		SerialNumber:       fmt.Sprintf("%X", cert.SerialNumber),
+		SignatureAlgorithm: cert.SignatureAlgorithm.String(),
+		Subject:            cert.Subject.String(),
+	}
+	for _, ip := range cert.IPAddresses {
+		certificate.IPAddresses = append(certificate.IPAddresses, ip.String())
+	}
+	return certificate
+}
+
+// daysLeft returns the whole days until the certificate expires (negative if expired)
+func daysLeft(cert *x509.Certificate, now time.Time) int {
+	left := cert.NotAfter.Sub(now)
+	if left < 0 {
+		return -int((-left).Hours() / 24)
+	}
+	return int(left.Hours() / 24)
+}
+
+// expiresIn returns the time left in days (or hours if less than a day)
+func expiresIn(left time.Duration) string {
+	if left < 24*time.Hour {
+		return fmt.Sprintf("%d hour(s)", int(left.Hours()))
+	}
+	return fmt.Sprintf("%d day(s)", int(left.Hours()/24))
+}
@@ -0,0 +1,205 @@
+package tlsreport
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startServer will start a TLS server (the test certificate covers 127.0.0.1, ::1 and example.com)
+func startServer(t *testing.T, hsts string) (host string, port int, roots *x509.CertPool) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if len(hsts) > 0 {
+			w.Header().Set("Strict-Transport-Security", hsts)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	hostPort := strings.TrimPrefix(server.URL, "https://")
+	var portString string
+	var err error
+	if host, portString, err = net.SplitHostPort(hostPort); err != nil {
+		t.Fatalf("%s Failed: invalid server address %s: %s", t.Name(), hostPort, err.Error())
+	}
+	port, _ = strconv.Atoi(portString)
+	roots = x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	return host, port, roots
+}
+
+// issueMessages returns the issues as "level: message"
+func issueMessages(issues []*Issue) []string {
+	list := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		list = append(list, issue.Level+": "+issue.Message)
+	}
+	return list
+}
+
+// TestClient_Inspect will test the method Inspect()
+func TestClient_Inspect(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		name            string
+		hsts            string
+		trustRoots      bool
+		insecure        bool
+		host            string // Override the host (the server still listens on 127.0.0.1)
+		nowOffset       func(notAfter time.Time) time.Time
+		expectedValid   bool
+		expectedTrusted bool
+		expectedCovered bool
+		expectedIssues  []string // Prefix of each issue in order
+	}{
+		{"trusted with hsts", "max-age=31536000", true, false, "", nil, true, true, true, []string{
+			"info: no OCSP response stapled",
+		}},
+		{"trusted without hsts", "", true, false, "", nil, true, true, true, []string{
+			"info: no OCSP response stapled",
+			"warn: no HSTS header",
+		}},
+		{"not trusted", "max-age=1", false, false, "", nil, false, false, true, []string{
+			"error: the chain is not trusted",
+			"info: no OCSP response stapled",
+		}},
+		{"not trusted but insecure", "max-age=1", false, true, "", nil, true, false, true, []string{
+			"warn: the chain is not trusted",
+			"info: no OCSP response stapled",
+		}},
+		{"host not covered", "max-age=1", true, false, "localhost", nil, false, true, false, []string{
+			"error: the certificate does not cover localhost",
+			"info: no OCSP response stapled",
+		}},
+		{"expires soon", "max-age=1", true, false, "", func(notAfter time.Time) time.Time {
+			return notAfter.Add(-3 * 24 * time.Hour)
+		}, false, true, true, []string{
+			"error: the certificate expires in 3 day(s)",
+			"info: no OCSP response stapled",
+		}},
+		{"expires in a few weeks", "max-age=1", true, false, "", func(notAfter time.Time) time.Time {
+			return notAfter.Add(-20 * 24 * time.Hour)
+		}, true, true, true, []string{
+			"warn: the certificate expires in 20 day(s)",
+			"info: no OCSP response stapled",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			host, port, roots := startServer(t, test.hsts)
+			if len(test.host) > 0 {
+				host = test.host
+			}
+			client := &Client{Insecure: test.insecure, Timeout: 5 * time.Second}
+			if test.trustRoots {
+				client.Roots = roots
+			}
+
+			// Get the expiry of the certificate first
+			now := time.Now()
+			if test.nowOffset != nil {
+				first, err := client.Inspect(context.Background(), host, port, 0, 0, now)
+				if err != nil || len(first.Certificates) == 0 {
+					t.Fatalf("%s Failed: [%s] inputted, received an error: %v", t.Name(), test.name, err)
+				}
+				now = test.nowOffset(first.Certificates[0].NotAfter)
+			}
+
+			report, err := client.Inspect(context.Background(), host, port, 0, 0, now)
+			if err != nil {
+				t.Fatalf("%s Failed: [%s] inputted, received an error: %s", t.Name(), test.name, err.Error())
+			}
+			if report.Valid != test.expectedValid || report.Trusted != test.expectedTrusted || report.Covered != test.expectedCovered {
+				t.Errorf("%s Failed: [%s] inputted and valid %v trusted %v covered %v expected, received: %v %v %v",
+					t.Name(), test.name, test.expectedValid, test.expectedTrusted, test.expectedCovered, report.Valid, report.Trusted, report.Covered)
+			}
+
+			issues := issueMessages(report.Issues)
+			if len(issues) != len(test.expectedIssues) {
+				t.Fatalf("%s Failed: [%s] inputted and issues %q expected, received: %q", t.Name(), test.name, test.expectedIssues, issues)
+			}
+			for index, prefix := range test.expectedIssues {
+				if !strings.HasPrefix(issues[index], prefix) {
+					t.Errorf("%s Failed: [%s] inputted and issue [%s] expected, received: [%s]", t.Name(), test.name, prefix, issues[index])
+				}
+			}
+
+			if report.HSTS != test.hsts {
+				t.Errorf("%s Failed: [%s] inputted and hsts [%s] expected, received: [%s]", t.Name(), test.name, test.hsts, report.HSTS)
+			}
+			if !slices.Equal(report.Versions, []string{"TLS 1.2", "TLS 1.3"}) || report.Version != "TLS 1.3" || len(report.CipherSuite) == 0 {
+				t.Errorf("%s Failed: [%s] inputted and TLS 1.2 and 1.3 expected, received: %v %s %s", t.Name(), test.name, report.Versions, report.Version, report.CipherSuite)
+			}
+			if len(report.Certificates) != 1 || !slices.Contains(report.Certificates[0].DNSNames, "example.com") || report.OCSP == nil || report.OCSP.Stapled {
+				t.Errorf("%s Failed: [%s] inputted and the test certificate without OCSP expected, received: %+v", t.Name(), test.name, report.Certificates)
+			}
+		})
+	}
+}
+
+// TestClient_InspectErrors will test the method Inspect() when the host can't be inspected
+func TestClient_InspectErrors(t *testing.T) {
+	t.Parallel()
+
+	// A closed port
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("%s Failed: error listening: %s", t.Name(), err.Error())
+	}
+	closedPort := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+
+	host, port, roots := startServer(t, "")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var tests = []struct {
+		name string
+		ctx  context.Context //nolint:containedctx // test input
+		port int
+	}{
+		{"connection refused", context.Background(), closedPort},
+		{"canceled", canceled, port},
+	}
+
+	for _, test := range tests {
+		report, err := (&Client{Roots: roots, Timeout: 2 * time.Second}).Inspect(test.ctx, host, test.port, 0, 0, time.Now())
+		if err == nil {
+			t.Errorf("%s Failed: [%s] inputted and an error expected", t.Name(), test.name)
+		} else if report == nil || report.Valid || report.Port != test.port {
+			t.Errorf("%s Failed: [%s] inputted and an invalid report expected, received: %+v", t.Name(), test.name, report)
+		}
+	}
+}
+
+// TestExpiresIn will test the method expiresIn()
+func TestExpiresIn(t *testing.T) {
+	t.Parallel()
+
+	var tests = []struct {
+		left     time.Duration
+		expected string
+	}{
+		{30 * time.Minute, "0 hour(s)"},
+		{23 * time.Hour, "23 hour(s)"},
+		{3 * 24 * time.Hour, "3 day(s)"},
+		{20*24*time.Hour + 5*time.Hour, "20 day(s)"},
+	}
+
+	for _, test := range tests {
+		if output := expiresIn(test.left); output != test.expected {
+			t.Errorf("%s Failed: [%s] inputted and [%s] expected, received: [%s]", t.Name(), test.left, test.expected, output)
+		}
+	}
+}